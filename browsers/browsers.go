@@ -7,8 +7,11 @@
 package browsers
 
 import (
+	"context"
 	"errors"
 	"log"
+
+	cmn "github.com/lordofscripts/wipechromium"
 )
 
 /* ----------------------------------------------------------------
@@ -52,13 +55,52 @@ type IBrowsers interface {
 	// Browser-specific profile name enumerator
 	FindProfileNames() ([]string, error)
 
-	// Clears a user profile and/or cache.
+	// Clears a user profile and/or cache. A cancelled context (signal or
+	// timeout) stops it after the current item.
 	// Returns: error (or nil) and if error, an error code
-	ClearProfile(doCache, doProfile bool) (error, int)
+	ClearProfile(ctx context.Context, doCache, doProfile bool) (error, int)
 	// Prints out the location of the directories the program
 	// thinks (as per configuration) it should use. Should be checked
 	// prior to cleaning the first time!
 	Tell() bool
+	// Same information as Tell() (plus installation & profiles) but
+	// in a machine-readable form.
+	Inspect() *cmn.BrowserScan
+	// Detailed result of the last ClearProfile() or nil if none.
+	Report() *cmn.WipeReport
+	// Where console output & progress events should go. Cleaners
+	// default to the human (console) renderer.
+	SetRenderer(out cmn.IRenderer)
+	// Library users may follow the progress of ClearProfile() with
+	// their own handlers, i.e. cmn.ObserverFunc(...)
+	Subscribe(observers ...cmn.IObserver)
+	// Go on with the remaining items & phases after a failure instead of
+	// stopping. ClearProfile() then returns a *cmn.MultiError with all of
+	// them and cmn.ExitPartial if anything was removed.
+	SetKeepGoing(on bool)
+	// Keep the profile's data of these categories (i.e. cookies) on top
+	// of the usual exceptions.
+	KeepCategories(categories ...cmn.Category)
+	// Move what the profile phase removes into a dated subdirectory of
+	// this one instead of deleting it. None if empty.
+	SetBackupDir(dir string)
+	// Overwrite what is removed with zeros before deleting it
+	SetShred(on bool)
+	// What the system policy demands of the profile (nil for none). It
+	// has the last word on the phases & what the profile phase keeps.
+	SetPolicy(policy *cmn.ProfilePolicy)
+	// Only remove these top-level items of the profile (i.e. those of a
+	// reviewed plan), keeping the rest. Nil for no limit.
+	LimitProfile(names []string)
+	// The profile's data directory
+	ProfileDir() string
+	// The lock of the running browser that holds the profile (empty if
+	// it isn't running). Wiping or restoring under its feet corrupts it.
+	HeldLock() string
+	// A du-style tree of the profile's data & cache, limited to depth
+	// levels and the top (largest) entries per directory. Every node is
+	// labeled with its category and what the current rules would do.
+	AnalyzeDiskUsage(depth, top int) (*cmn.DiskUsageReport, error)
 
 	// Browser data for ALL profiles. A user account has ONE browser AppDataRoot,
 	// but therein it may have more than one user Profile, each with its
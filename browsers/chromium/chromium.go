@@ -7,9 +7,11 @@
 package chromium
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	cmn "github.com/lordofscripts/wipechromium"
@@ -53,6 +55,22 @@ var (
 		"Local Extension Settings",
 		"Web Applications", // therein remove Temp
 	}
+	// Extension data directories (within a profile) with junk to clear
+	ExtensionJunkDirs []string = []string{
+		"Extension Scripts",
+		"Extension State",
+		"Extension Rules",
+	}
+	// held by a running Chromium, in its data root (Unix & Windows)
+	ChromiumLocks []string = []string{
+		"SingletonLock",
+		"lockfile",
+	}
+	// glob patterns of the junk files in ExtensionJunkDirs
+	ExtensionJunkPatterns []string = []string{
+		"*.log",
+		"LOG*",
+	}
 )
 
 /* ----------------------------------------------------------------
@@ -69,39 +87,75 @@ var (
 
 type ChromiumCleaner struct {
 	Class       browsers.Browser
+	Env         *cmn.Environment
 	ProfileName string
 	CacheRoot   string
 	ProfileRoot string
-	cleanedSize int64
+	cleaned     cmn.DiskSize
 	sizeMode    cmn.SizeMode
 	doDryRun    bool
-	logx        cmn.ILogger
+	logx        *cmn.ConditionalLogger
+	out         cmn.IRenderer
+	report      *cmn.WipeReport
+	observers   []cmn.IObserver
+	keepGoing   bool
+	exceptions  []string
+	backupDir   string
+	shred       bool
+	policy      *cmn.ProfilePolicy
+	// the profile phase only removes these (nil is no limit)
+	only []string
 }
 
 /* ----------------------------------------------------------------
  *							C o n s t r u c t o r s
  *-----------------------------------------------------------------*/
 
-func NewChromiumCleaner(profile string, smode cmn.SizeMode, dry bool, logger ...cmn.ILogger) *ChromiumCleaner {
+// The cleaner of a profile of the Chromium in env (see cmn.NewEnvironment)
+// or cmn.ErrProfileDoesNotExist if the name can't be that of a profile.
+func NewChromiumCleaner(env *cmn.Environment, profile string, smode cmn.SizeMode, dry bool, logger ...cmn.ILogger) (*ChromiumCleaner, error) {
 	const cName = "ChromiumCleaner"
-	var logCtx cmn.ILogger
+	var logCtx *cmn.ConditionalLogger
 	if len(logger) == 0 {
 		logCtx = cmn.NewConditionalLogger(false, cName)
 	} else {
 		logCtx = logger[0].InheritAs(cName)
 	}
-
-	ChromiumDataDir, ChromiumCachesDir := GetChromiumDirs()
+	logCtx = logCtx.With(cmn.LogKeyBrowser, browsers.ChromiumBrowser.String(), cmn.LogKeyProfile, profile)
+
+	// a profile is a directory right under the data root, i.e. not ../..
+	// nor the root itself, and named as it is. Without one (a scan) there
+	// is nothing to wipe.
+	var dataDir, cacheDir string
+	if len(profile) != 0 {
+		ChromiumDataDir, ChromiumCachesDir := GetChromiumDirs(env)
+		var ok bool
+		dataDir, ok = cmn.JoinWithin(ChromiumDataDir, profile)
+		if !ok || profile == "." || profile != strings.Trim(profile, " \t") || strings.ContainsAny(profile, `/\`) {
+			return nil, fmt.Errorf("%w: %q", cmn.ErrProfileDoesNotExist, profile)
+		}
+		cacheDir = filepath.Join(ChromiumCachesDir, profile)
+	}
 
 	return &ChromiumCleaner{browsers.ChromiumBrowser,
-		strings.Trim(profile, " \t"),
-		filepath.Join(ChromiumCachesDir, profile),
-		filepath.Join(ChromiumDataDir, profile),
-		0,
+		env,
+		profile,
+		cacheDir,
+		dataDir,
+		cmn.DiskSize{},
 		smode,
 		dry,
 		logCtx,
-	}
+		cmn.NewRenderer(cmn.OutputHuman, nil, smode),
+		nil,
+		nil,
+		false,
+		ProfileExceptions,
+		"",
+		false,
+		nil,
+		nil,
+	}, nil
 }
 
 /* ----------------------------------------------------------------
@@ -109,11 +163,10 @@ func NewChromiumCleaner(profile string, smode cmn.SizeMode, dry bool, logger ...
  *-----------------------------------------------------------------*/
 
 func (c *ChromiumCleaner) String() string {
-	reportedSize := cmn.ReportByteCount(c.cleanedSize, c.sizeMode)
+	reportedSize := c.cleaned.Format(c.sizeMode)
 
-	c.logx.Print("Cleaned ", cmn.AddThousands(c.cleanedSize, ','))
-	c.logx.Print("Cleaned ", cmn.ByteCountSI(c.cleanedSize))
-	c.logx.Print("Cleaned ", cmn.ByteCountIEC(c.cleanedSize))
+	c.logx.Debug("cleaned", cmn.LogKeyBytes, c.cleaned.Apparent, "si", cmn.ByteCountSI(c.cleaned.Apparent),
+		"iec", cmn.ByteCountIEC(c.cleaned.Apparent))
 	return fmt.Sprintf("%sCleaner %q cleaned %s aka %q", c.Class, c.ProfileName, reportedSize, CODENAME)
 }
 
@@ -124,35 +177,90 @@ func (c *ChromiumCleaner) Name() browsers.Browser {
 // Top level function to clear a Chromium user profile directory. Rather than
 // saving important data to a Temp directory and then restoring (as previous version)
 // now we simply go through the top level with a list of exceptions
+// The context stops it (after the current item) on a signal or timeout.
 // Example: clearProfile("Profile 1")
-func (c *ChromiumCleaner) ClearProfile(doCache, doProfile bool) (error, int) {
-	fmt.Printf("Clearing profile %q (Dry-run: %t)\n", c.ProfileName, c.doDryRun)
+func (c *ChromiumCleaner) ClearProfile(ctx context.Context, doCache, doProfile bool) (error, int) {
+	c.out.Printf("Clearing profile %q (Dry-run: %t)\n", c.ProfileName, c.doDryRun)
+
+	c.cleaned = cmn.DiskSize{}
+	c.report = cmn.NewWipeReport(c.Class.String(), c.ProfileName, c.doDryRun)
+	c.report.Subscribe(c.observers...)
+	c.report.Start(c.out)
+	// the policy has the last word on what goes & what stays
+	doCache, doProfile = c.policy.Phases(doCache, doProfile)
+	defer c.report.Finish()
+	defer c.report.Categorize(&ChromiumClassifier{c.keptNames()}, c.ProfileRoot, c.CacheRoot)
+
+	// with -keep-going a failed phase does not stop the others
+	var failures cmn.MultiError
+	stop := func(err error) bool {
+		if err == nil {
+			return false
+		}
+		if !c.keepGoing || cmn.IsInterruption(err) {
+			return true
+		}
+		failures.Add(err)
+		return false
+	}
 
-	c.cleanedSize = 0
 	if len(c.ProfileName) == 0 {
 		// we can only operate in AppData root only as no profile is given
-		return cmn.ErrNoProfile, 40
+		c.report.Fail(cmn.ErrNoProfile, cmn.ExitNoProfile)
+		return cmn.ErrNoProfile, cmn.ExitNoProfile
+	}
+	if !c.Env.IsDirectory(c.ProfileRoot) {
+		err := cmn.NewError(cmn.ExitNoSuchProfile, "profile", c.ProfileRoot, nil)
+		c.report.Fail(err, err.Code)
+		return err, err.Code
+	}
+
+	// wiping it under the feet of a running browser corrupts it
+	if lock := c.heldLock(); len(lock) != 0 && !c.doDryRun {
+		err := cmn.NewError(cmn.ExitProfileInUse, "lock", lock, nil)
+		c.report.Fail(err, err.Code)
+		return err, err.Code
 	}
 
 	// 1. Profile Cache
 	if doCache {
-		if err := c.clearCache(); err != nil {
-			return err, 50
+		action := c.report.Begin(cmn.PhaseCache, c.CacheRoot)
+		err := c.clearCache(ctx, action)
+		if code := c.report.End(action, err, cmn.ExitCachePhase); stop(err) {
+			return failures.Final(err), code
 		}
 	}
 
 	// 2. Profile Data
 	if doProfile {
-		if err := c.eraseProfile(); err != nil {
-			return err, 60
+		if err, code := c.report.Check(ctx); err != nil {
+			return failures.Final(err), code
+		}
+		action := c.report.Begin(cmn.PhaseProfile, c.ProfileRoot)
+		err := c.eraseProfile(ctx, action)
+		if code := c.report.End(action, err, cmn.ExitProfilePhase); stop(err) {
+			return failures.Final(err), code
 		}
 
-		if err := c.clearExtensions(); err != nil {
-			return err, 70
+		if err, code := c.report.Check(ctx); err != nil {
+			return failures.Final(err), code
+		}
+		if c.policy.Forbids(cmn.CategoryExtensions) {
+			c.logx.Info("extension junk kept as per the policy")
+		} else {
+			action = c.report.Begin(cmn.PhaseExtensions, c.ProfileRoot)
+			err = c.clearExtensions(ctx, action)
+			if code := c.report.End(action, err, cmn.ExitExtensionsPhase); stop(err) {
+				return failures.Final(err), code
+			}
 		}
 	}
 
-	c.logx.Printf("Profile %q cleared of private/junk data", c.ProfileName)
+	if err := failures.Err(); err != nil {
+		return err, c.report.PartialExitCode()
+	}
+
+	c.logx.Info("profile cleared", cmn.LogKeyBytes, c.cleaned.Apparent)
 	return nil, 0
 }
 
@@ -160,20 +268,77 @@ func (c *ChromiumCleaner) ClearProfile(doCache, doProfile bool) (error, int) {
 // It should print out the supposed location of the Data & Cache directories
 // so that the user can verify prior to running the program for the 1st time.
 func (c *ChromiumCleaner) Tell() bool {
-	ChromiumDataDir, ChromiumCachesDir := GetChromiumDirs()
-	dataExists := cmn.IsDirectory(ChromiumDataDir)
-	cacheExists := cmn.IsDirectory(ChromiumCachesDir)
-	var sizeD, sizeC int64
-	if dataExists {
-		sizeD, _ = cmn.GetDirectorySize(ChromiumDataDir)
-	}
-	if cacheExists {
-		sizeC, _ = cmn.GetDirectorySize(ChromiumCachesDir)
+	scan := c.inspectDirs()
+	c.out.Printf("❋✦ Chromium Directories:\n")
+	c.out.Printf("\tData : %5t %s %s\n", scan.DataExists, scan.DataDir, scan.DataUsage().Format(c.sizeMode))
+	c.out.Printf("\tCache: %5t %s %s\n", scan.CacheExists, scan.CacheDir, scan.CacheUsage().Format(c.sizeMode))
+	return scan.DataExists && scan.CacheExists
+}
+
+// Machine-readable equivalent of Tell() plus installation & profiles.
+func (c *ChromiumCleaner) Inspect() *cmn.BrowserScan {
+	scan := c.inspectDirs()
+	scan.Installed = c.IdentifyAppDataRoot()
+	if profiles, err := c.FindProfileNames(); err == nil {
+		scan.Profiles = profiles
 	}
-	fmt.Println("❋✦ Chromium Directories:")
-	fmt.Printf("\tData : %5t %s %s\n", dataExists, ChromiumDataDir, cmn.ReportByteCount(sizeD, c.sizeMode))
-	fmt.Printf("\tCache: %5t %s %s\n", cacheExists, ChromiumCachesDir, cmn.ReportByteCount(sizeC, c.sizeMode))
-	return dataExists && cacheExists
+	return scan
+}
+
+// Detailed result of the last ClearProfile()
+func (c *ChromiumCleaner) Report() *cmn.WipeReport {
+	return c.report
+}
+
+// Console output & progress events go to this renderer
+func (c *ChromiumCleaner) SetRenderer(out cmn.IRenderer) {
+	c.out = out
+}
+
+// Go on with the other items & phases after a failure. ClearProfile()
+// then returns a *cmn.MultiError with all of them.
+func (c *ChromiumCleaner) SetKeepGoing(on bool) {
+	c.keepGoing = on
+}
+
+// Keep the profile's data of these categories on top of ProfileExceptions
+func (c *ChromiumCleaner) KeepCategories(categories ...cmn.Category) {
+	c.exceptions = append(slices.Clone(ProfileExceptions), cmn.NamesOf(ProfileCategories, categories...)...)
+}
+
+// Move what the profile phase removes there instead of deleting it
+func (c *ChromiumCleaner) SetBackupDir(dir string) {
+	c.backupDir = dir
+}
+
+// Overwrite what is removed with zeros before deleting it
+func (c *ChromiumCleaner) SetShred(on bool) {
+	c.shred = on
+}
+
+// What the system policy demands of the profile
+func (c *ChromiumCleaner) SetPolicy(policy *cmn.ProfilePolicy) {
+	c.policy = policy
+}
+
+// Limit the profile phase to these top-level items (i.e. of a plan)
+func (c *ChromiumCleaner) LimitProfile(names []string) {
+	c.only = names
+}
+
+// The profile's data directory
+func (c *ChromiumCleaner) ProfileDir() string {
+	return c.ProfileRoot
+}
+
+// The lock of the running browser that holds the profile, if any
+func (c *ChromiumCleaner) HeldLock() string {
+	return c.heldLock()
+}
+
+// Progress events also go to these observers (besides the renderer)
+func (c *ChromiumCleaner) Subscribe(observers ...cmn.IObserver) {
+	c.observers = append(c.observers, observers...)
 }
 
 // FindProfileNames() in Chromium we scan the top-level directories in the
@@ -182,26 +347,20 @@ func (c *ChromiumCleaner) Tell() bool {
 func (c *ChromiumCleaner) FindProfileNames() ([]string, error) {
 	names := make([]string, 0)
 
-	ChromiumDataDir, _ := GetChromiumDirs()
-	if dataExists := cmn.IsDirectory(ChromiumDataDir); !dataExists {
+	ChromiumDataDir, _ := GetChromiumDirs(c.Env)
+	if dataExists := c.Env.IsDirectory(ChromiumDataDir); !dataExists {
 		return names, browsers.ErrNoProfilesFound
 	}
 
-	dirRead, err := os.Open(ChromiumDataDir)
-	if err != nil {
-		return names, err
-	}
 	// we only need to examine the top-level Chromium data directory
-	dirFiles, err := dirRead.Readdir(0)
-	defer dirRead.Close()
-
+	dirFiles, err := c.Env.ReadDir(ChromiumDataDir)
 	if err != nil {
 		return names, err
 	}
 
 	for _, fileHere := range dirFiles {
 		if fileHere.IsDir() {
-			if IdentifyProfileData(fileHere.Name()) {
+			if IdentifyProfileData(c.Env, fileHere.Name()) {
 				names = append(names, fileHere.Name())
 			}
 		}
@@ -216,13 +375,13 @@ func (c *ChromiumCleaner) FindProfileNames() ([]string, error) {
 // different settings, extensions, bookmarks, etc.
 // Returns: true if GetDataDir() is the root of all user account profiles.
 func (c *ChromiumCleaner) IdentifyAppDataRoot() bool {
-	return IdentifyAppDataRoot()
+	return IdentifyAppDataRoot(c.Env)
 }
 
 // A user profile's cache directory.
 // Returns: true if GetCacheDir()+profile is a valid browser Cache directory.
 func (c *ChromiumCleaner) IdentifyProfileCache(profile string) bool {
-	return IdentifyProfileCache(profile)
+	return IdentifyProfileCache(c.Env, profile)
 }
 
 // A user profile specific data (extensions, Bookmarks, etc.).
@@ -230,148 +389,260 @@ func (c *ChromiumCleaner) IdentifyProfileCache(profile string) bool {
 // by IdentifyAppDataRoot().
 // Returns: true if directory contains browser user profile data & settings.
 func (c *ChromiumCleaner) IdentifyProfileData(profile string) bool {
-	return IdentifyProfileData(profile)
+	return IdentifyProfileData(c.Env, profile)
+}
+
+// A du-style tree of this profile's data & cache directories, every
+// node labeled with its category and what the current rules would do.
+func (c *ChromiumCleaner) AnalyzeDiskUsage(depth, top int) (*cmn.DiskUsageReport, error) {
+	if len(c.ProfileName) == 0 {
+		return nil, cmn.ErrNoProfile
+	}
+
+	classifier := &ChromiumClassifier{c.keptNames()}
+	roots := make([]*cmn.DUNode, 0, 2)
+	kinds := []cmn.RootKind{cmn.RootProfile, cmn.RootCache}
+	for i, dir := range []string{c.ProfileRoot, c.CacheRoot} {
+		node, err := cmn.AnalyzeDiskUsage(dir, kinds[i], classifier, depth)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		roots = append(roots, node)
+	}
+	if len(roots) == 0 {
+		return nil, cmn.ErrProfileDoesNotExist
+	}
+
+	return cmn.NewDiskUsageReport(c.Class.String(), c.ProfileName, depth, top, roots...), nil
 }
 
 /* ----------------------------------------------------------------
  *					I n t e r n a l 	M e t h o d s
  *-----------------------------------------------------------------*/
 
+// Data & Cache directories (not profile-specific), whether they exist
+// and their size.
+func (c *ChromiumCleaner) inspectDirs() *cmn.BrowserScan {
+	ChromiumDataDir, ChromiumCachesDir := GetChromiumDirs(c.Env)
+	scan := &cmn.BrowserScan{
+		Browser:     c.Class.String(),
+		Flavor:      c.Class.String(),
+		DataDir:     ChromiumDataDir,
+		CacheDir:    ChromiumCachesDir,
+		DataExists:  c.Env.IsDirectory(ChromiumDataDir),
+		CacheExists: c.Env.IsDirectory(ChromiumCachesDir),
+	}
+	if scan.DataExists {
+		usage, _ := c.Env.DirectoryUsage(context.Background(), ChromiumDataDir)
+		scan.DataSize, scan.DataAllocated = usage.Apparent, usage.Allocated
+	}
+	if scan.CacheExists {
+		usage, _ := c.Env.DirectoryUsage(context.Background(), ChromiumCachesDir)
+		scan.CacheSize, scan.CacheAllocated = usage.Apparent, usage.Allocated
+	}
+	return scan
+}
+
+// The lock of a running Chromium (it is one for all profiles) if any
+func (c *ChromiumCleaner) heldLock() string {
+	return c.Env.HeldLock(GetDataDir(c.Env), ChromiumLocks...)
+}
+
+// the profile items the profile phase keeps: the exceptions as per the
+// kept categories & the policy, or all but those of a plan
+func (c *ChromiumCleaner) keptNames() []string {
+	kept := c.policy.Exceptions(c.exceptions, func(categories ...cmn.Category) []string {
+		return cmn.NamesOf(ProfileCategories, categories...)
+	})
+	if c.only != nil {
+		kept = append(kept, cmn.NamesBesides(c.ProfileRoot, c.only)...)
+	}
+	return kept
+}
+
+// returns a DryRunner in the appropriate mode for this cleaner
+func (c *ChromiumCleaner) dryRunner() *cmn.DryRun {
+	return c.Env.DryRunner(c.doDryRun, c.out.Writer())
+}
+
 // Clears the entire cache dir of a profile
 // Example: clearCache("Profile 1")
-func (c *ChromiumCleaner) clearCache() error {
-	fmt.Println("\tClearing cache...")
+func (c *ChromiumCleaner) clearCache(ctx context.Context, action *cmn.WipeAction) error {
+	c.out.Printf("\tClearing cache...\n")
 
-	cacheSize, err := cmn.GetDirectorySize(c.CacheRoot)
-	if err != nil {
-		c.logx.Printf("clearCache WARN %s", err)
+	if !IdentifyProfileCache(c.Env, c.ProfileName) {
+		c.logx.Error(cmn.ErrNotBrowserCache.Error(), cmn.LogKeyPath, c.CacheRoot)
+		return cmn.NewError(cmn.ExitNotCache, "identify", c.CacheRoot, nil)
 	}
 
-	if !IdentifyProfileCache(c.ProfileName) {
-		c.logx.Printf("%s: %s", cmn.ErrNotBrowserCache, c.CacheRoot)
-		return cmn.ErrNotBrowserCache
-	}
+	dry := c.dryRunner()
 
-	dry := cmn.NewDryRunner()
-	if !c.doDryRun {
-		dry.Disable()
+	// 'Cache' 'Code Cache' and sometimes 'Storage' sized as they go
+	walker := cmn.NewWalker(cmn.WalkerJobs).OnProgress(cmn.ProgressInterval, func(done cmn.DiskSize, files int) {
+		c.report.Event(cmn.NewProgressEvent(c.CacheRoot, done, files))
+	})
+	c.report.Event(cmn.NewItemEvent(cmn.EventItemStarted, &cmn.ItemRecord{Path: c.CacheRoot, IsDir: true}))
+	var err error
+	if c.shred || c.policy.MustShred() {
+		err = dry.Shred(ctx, c.CacheRoot)
 	}
-
-	// 'Cache' 'Code Cache' and sometimes 'Storage'
-	if err := dry.RemoveAll(c.CacheRoot); err != nil {
-		return cmn.WrapError(err, 41, "Could not remove cache dir %q", c.CacheRoot)
+	var cacheUsage cmn.DiskSize
+	if err == nil {
+		cacheUsage, err = dry.RemoveAllSized(ctx, walker, c.CacheRoot)
+	}
+	cacheSize := cacheUsage.Apparent
+	if err != nil {
+		// whatever was removed before the failure is gone nonetheless
+		c.cleaned.Add(cacheUsage)
+		action.Bytes += cacheSize
+		action.Allocated += cacheUsage.Allocated
+		item := cmn.NewFailedItem(c.CacheRoot, true, cacheSize, cmn.RuleCache+"*", err)
+		c.report.Record(cmn.PhaseCache, item)
+		c.report.Event(cmn.NewItemEvent(cmn.EventError, item))
+		return cmn.WrapError(err, cmn.ExitCacheRemove, "Could not remove cache dir %q", c.CacheRoot)
 	}
+	item := cmn.NewDeletedItem(c.CacheRoot, true, cacheSize, cmn.RuleCache+"*")
+	c.report.Record(cmn.PhaseCache, item)
+	c.report.Event(cmn.NewItemEvent(cmn.EventItemDeleted, item))
 
 	if RecreateCacheDir {
 		if err := dry.MkDir(c.CacheRoot, PERMS); err != nil {
-			c.logx.Printf("Could not recreate CACHE %s", c.CacheRoot)
+			c.logx.Warn("could not recreate cache", cmn.LogKeyPath, c.CacheRoot, cmn.LogKeyErr, err)
 		}
 	}
 
-	c.cleanedSize += cacheSize
+	c.cleaned.Add(cacheUsage)
+	action.Bytes += cacheSize
+	action.Allocated += cacheUsage.Allocated
+	action.Items += 1
 	if !c.doDryRun {
-		fmt.Printf("\tDeleted %s bytes from cache\n", cmn.ReportByteCount(cacheSize, c.sizeMode))
+		c.out.Printf("\tDeleted %s bytes from cache\n", cacheUsage.Format(c.sizeMode))
 	} else {
-		fmt.Printf("\tWOULD have deleted %s bytes from cache\n", cmn.ReportByteCount(cacheSize, c.sizeMode))
+		c.out.Printf("\tWOULD have deleted %s bytes from cache\n", cacheUsage.Format(c.sizeMode))
 	}
-	c.logx.Print("clearCache DONE")
+	c.logx.Debug("cache cleared", cmn.LogKeyPath, c.CacheRoot, cmn.LogKeyBytes, cacheSize)
 	return nil
 }
 
 // erases a User Profile but keeps important profile data such as
 // extensions and settings.
-func (c *ChromiumCleaner) eraseProfile() error {
-	fmt.Println("\tClearing profile")
+func (c *ChromiumCleaner) eraseProfile(ctx context.Context, action *cmn.WipeAction) error {
+	c.out.Printf("\tClearing profile\n")
 
 	// (a )Identify it is a profile directory
-	if !IdentifyProfileData(c.ProfileName) {
-		return cmn.ErrNotBrowserProfile
+	if !IdentifyProfileData(c.Env, c.ProfileName) {
+		return cmn.NewError(cmn.ExitNotProfile, "identify", c.ProfileRoot, nil)
 	}
 
 	// (b) we are going to clean the profile's top level
-	var filter cmn.IDirCleaner
-	if !c.doDryRun {
-		filter = cmn.NewDirCleaner(c.ProfileRoot, c.sizeMode, false)
-	} else {
-		filter = cmn.NewDirCleanerDryVFS(c.ProfileRoot, c.sizeMode, c.logx)
-	}
+	// a dry run lists the real profile (a plan is made of it) but
+	// removes nothing
+	var filter cmn.IDirCleaner = c.Env.DirCleaner(c.ProfileRoot, c.sizeMode, c.doDryRun, c.logx)
 
 	// (c) except these important profile items
-	err := filter.CleanUp(ProfileExceptions)
+	filter.Subscribe(c.report)
+	filter.SetKeepGoing(c.keepGoing)
+	filter.SetOutput(c.out.Writer())
+	backupDir, err := c.policy.BackupTarget(c.backupDir)
 	if err != nil {
-		c.logx.Print(err)
-		return cmn.WrapError(err, 51, "EraseProfile fault.")
+		return cmn.WrapError(err, cmn.ExitPolicy, "EraseProfile policy")
+	}
+	if len(backupDir) != 0 {
+		filter.SetBackupDir(cmn.BackupPath(backupDir, c.Class.String(), c.ProfileName, c.report.Started))
+	}
+	filter.SetShred(c.shred || c.policy.MustShred())
+	err = filter.CleanUp(ctx, c.keptNames())
+	c.report.Record(cmn.PhaseProfile, filter.Items()...)
+	c.cleaned.Add(filter.CleanedUsage())
+	action.Bytes += filter.CleanedSize()
+	action.Allocated += filter.CleanedUsage().Allocated
+	action.Items += filter.RemovedCount()
+	action.Skipped += filter.SkippedCount()
+	if err != nil {
+		c.logx.Error("erase profile", cmn.LogKeyPath, c.ProfileRoot, cmn.LogKeyErr, err)
+		return cmn.WrapError(err, cmn.ExitProfileErase, "EraseProfile fault")
 	}
-
-	c.cleanedSize += filter.CleanedSize()
 	if !c.doDryRun {
-		fmt.Printf("\t...Erased %s bytes\n", cmn.ReportByteCount(c.cleanedSize, c.sizeMode))
+		c.out.Printf("\t...Erased %s bytes\n", c.cleaned.Format(c.sizeMode))
 	} else {
-		fmt.Println("\t...Be happy! we didn't erase anything!")
+		c.out.Printf("\t...Be happy! we didn't erase anything!\n")
 	}
 	return nil
 }
 
-func (c *ChromiumCleaner) clearExtensions() error {
-	// (a) extension subdirs to cleanup
-	categories := []string{
-		"Extension Scripts",
-		"Extension State",
-		"Extension Rules",
-	}
-
-	// (b) iterate through profile extension category subdirs
-	for _, subDir := range categories {
-		fmt.Println("\tClearing ", subDir, "...")
+func (c *ChromiumCleaner) clearExtensions(ctx context.Context, action *cmn.WipeAction) error {
+	// (a) iterate through profile extension category subdirs
+	var failures cmn.MultiError
+	for _, subDir := range ExtensionJunkDirs {
+		c.out.Printf("\tClearing  %s ...\n", subDir)
 
-		// (b.1) root of that extension data category
+		// (a.1) root of that extension data category
 		root := filepath.Join(c.ProfileRoot, subDir)
-		// (b.2) glob patterns to remove matching files
-		patterns := []string{
-			"*.log",
-			"LOG*",
-		}
-		// (b.3) delete those files based on pattern matching
-		if err := c.removeWithPatterns(root, patterns); err != nil {
-			c.logx.Print("WARN", err)
+		// (a.2) delete those files based on pattern matching
+		if err := c.removeWithPatterns(ctx, action, root, ExtensionJunkPatterns); err != nil {
+			if cmn.IsInterruption(err) {
+				return failures.Final(err)
+			}
+			c.logx.Warn("clear extensions", cmn.LogKeyPath, root, cmn.LogKeyErr, err)
+			if c.keepGoing {
+				failures.Add(err)
+			}
 		}
 	}
 
-	fmt.Println("\t...Cleared extension junk")
-	return nil
+	c.out.Printf("\t...Cleared extension junk\n")
+	return failures.Err()
 }
 
 // Removes all files matching a Pattern at Dir.
 // Example: removeWithPattern("/home/lordofscripts/.cache", "*.log")
-func (c *ChromiumCleaner) removeWithPatterns(dir string, patterns []string) error {
-	dry := cmn.NewDryRunner()
-	if !c.doDryRun {
-		dry.Disable()
-	}
+func (c *ChromiumCleaner) removeWithPatterns(ctx context.Context, action *cmn.WipeAction, dir string, patterns []string) error {
+	dry := c.dryRunner()
 
+	var failures cmn.MultiError
+	sizer := cmn.NewDiskSizer()
 	for _, pattern := range patterns {
-		glob := dir + string(os.PathSeparator) + pattern
-		files, err := filepath.Glob(glob)
+		files, err := c.Env.Glob(dir, pattern)
 		if err != nil {
 			return err
 		}
 
 		for _, fname := range files {
-			var fileSize int64 = 0
-			if finfo, err := os.Stat(fname); err != nil {
+			if err := ctx.Err(); err != nil {
+				return failures.Final(err)
+			}
+			var usage cmn.DiskSize
+			if finfo, err := c.Env.Lstat(fname); err != nil {
 				return err
 			} else {
-				fileSize = finfo.Size()
+				usage = sizer.Of(finfo)
 			}
+			fileSize := usage.Apparent
 			// remove file or empty directory
 			if err := dry.Remove(fname); err != nil {
-				return err
+				item := cmn.NewFailedItem(fname, false, fileSize, cmn.RulePattern+pattern, err)
+				c.report.Record(action.Phase, item)
+				c.report.Event(cmn.NewItemEvent(cmn.EventError, item))
+				if c.keepGoing {
+					failures.Add(cmn.NewItemError(fname, "remove", 0, err))
+					continue
+				}
+				return failures.Final(err)
 			}
-			c.cleanedSize += fileSize
+			item := cmn.NewDeletedItem(fname, false, fileSize, cmn.RulePattern+pattern)
+			c.report.Record(action.Phase, item)
+			c.report.Event(cmn.NewItemEvent(cmn.EventItemDeleted, item))
+			c.cleaned.Add(usage)
+			action.Bytes += fileSize
+			action.Allocated += usage.Allocated
+			action.Items += 1
 		}
 	}
 
-	return nil
+	return failures.Err()
 }
 
 /* ----------------------------------------------------------------
@@ -382,26 +653,26 @@ func (c *ChromiumCleaner) removeWithPatterns(dir string, patterns []string) erro
 // Remember every user can have several profiles. This identifies just the
 // root where the profiles are located
 // At least 'System Profile', 'Avatars' & 'Safe Browsing' exist
-func IdentifyAppDataRoot() bool {
-	appdata := GetDataDir()
-	return cmn.IsDirectory(filepath.Join(appdata, "System Profile")) &&
-		cmn.IsDirectory(filepath.Join(appdata, "Default")) &&
-		cmn.IsDirectory(filepath.Join(appdata, "Avatars")) &&
-		cmn.IsDirectory(filepath.Join(appdata, "Safe Browsing"))
+func IdentifyAppDataRoot(env *cmn.Environment) bool {
+	appdata := GetDataDir(env)
+	return env.IsDirectory(filepath.Join(appdata, "System Profile")) &&
+		env.IsDirectory(filepath.Join(appdata, "Default")) &&
+		env.IsDirectory(filepath.Join(appdata, "Avatars")) &&
+		env.IsDirectory(filepath.Join(appdata, "Safe Browsing"))
 }
 
 // Identify GetCacheDir() as a proper Chromium Cache directory
 // Both 'Cache' & 'Code Cache' dirs exist
-func IdentifyProfileCache(profile string) bool {
-	cache := GetCacheDir()
-	return cmn.IsDirectory(filepath.Join(cache, profile, "Cache")) &&
-		cmn.IsDirectory(filepath.Join(cache, profile, "Code Cache"))
+func IdentifyProfileCache(env *cmn.Environment, profile string) bool {
+	cache := GetCacheDir(env)
+	return env.IsDirectory(filepath.Join(cache, profile, "Cache")) &&
+		env.IsDirectory(filepath.Join(cache, profile, "Code Cache"))
 }
 
 // At least Bookmarks exist && Cookies
-func IdentifyProfileData(profile string) bool {
-	user := filepath.Join(GetDataDir(), profile)
-	return cmn.IsDirectory(filepath.Join(user, "Extension Rules")) &&
-		cmn.IsFile(filepath.Join(user, "Preferences")) == cmn.Yes &&
-		cmn.IsFile(filepath.Join(user, "Bookmarks")) == cmn.Yes
+func IdentifyProfileData(env *cmn.Environment, profile string) bool {
+	user := filepath.Join(GetDataDir(env), profile)
+	return env.IsDirectory(filepath.Join(user, "Extension Rules")) &&
+		env.IsFile(filepath.Join(user, "Preferences")) == cmn.Yes &&
+		env.IsFile(filepath.Join(user, "Bookmarks")) == cmn.Yes
 }
@@ -0,0 +1,160 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * What each file & directory of a Chromium profile is for
+ *-----------------------------------------------------------------*/
+package chromium
+
+import (
+	"path/filepath"
+	"slices" // GO v1.18
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	_ cmn.IClassifier = (*ChromiumClassifier)(nil)
+
+	// Category of the top-level items of a Chromium user profile.
+	// Anything not listed here is cmn.CategoryOther.
+	ProfileCategories = map[string]cmn.Category{
+		"Application Cache":                cmn.CategoryCache,
+		"Cache":                            cmn.CategoryCache,
+		"Code Cache":                       cmn.CategoryCache,
+		"DawnCache":                        cmn.CategoryCache,
+		"DawnGraphiteCache":                cmn.CategoryCache,
+		"DawnWebGPUCache":                  cmn.CategoryCache,
+		"GPUCache":                         cmn.CategoryCache,
+		"GrShaderCache":                    cmn.CategoryCache,
+		"Media Cache":                      cmn.CategoryCache,
+		"ShaderCache":                      cmn.CategoryCache,
+		"blob_storage":                     cmn.CategorySiteStorage,
+		"databases":                        cmn.CategorySiteStorage,
+		"IndexedDB":                        cmn.CategorySiteStorage,
+		"Local Storage":                    cmn.CategorySiteStorage,
+		"Session Storage":                  cmn.CategorySiteStorage,
+		"Shared Dictionary":                cmn.CategorySiteStorage,
+		"SharedStorage":                    cmn.CategorySiteStorage,
+		"Storage":                          cmn.CategorySiteStorage,
+		"Web Storage":                      cmn.CategorySiteStorage,
+		"WebStorage":                       cmn.CategorySiteStorage,
+		"Cookies":                          cmn.CategoryCookies,
+		"Cookies-journal":                  cmn.CategoryCookies,
+		"Extension Cookies":                cmn.CategoryCookies,
+		"Extension Cookies-journal":        cmn.CategoryCookies,
+		"Trust Tokens":                     cmn.CategoryCookies,
+		"Trust Tokens-journal":             cmn.CategoryCookies,
+		"BrowsingTopicsSiteData":           cmn.CategoryHistory,
+		"BrowsingTopicsState":              cmn.CategoryHistory,
+		"Favicons":                         cmn.CategoryHistory,
+		"Favicons-journal":                 cmn.CategoryHistory,
+		"History":                          cmn.CategoryHistory,
+		"History-journal":                  cmn.CategoryHistory,
+		"Network Action Predictor":         cmn.CategoryHistory,
+		"Network Action Predictor-journal": cmn.CategoryHistory,
+		"Shortcuts":                        cmn.CategoryHistory,
+		"Shortcuts-journal":                cmn.CategoryHistory,
+		"Top Sites":                        cmn.CategoryHistory,
+		"Top Sites-journal":                cmn.CategoryHistory,
+		"Visited Links":                    cmn.CategoryHistory,
+		"Current Session":                  cmn.CategorySessions,
+		"Current Tabs":                     cmn.CategorySessions,
+		"Last Session":                     cmn.CategorySessions,
+		"Last Tabs":                        cmn.CategorySessions,
+		"Sessions":                         cmn.CategorySessions,
+		"Extension Rules":                  cmn.CategoryExtensions,
+		"Extension Scripts":                cmn.CategoryExtensions,
+		"Extension State":                  cmn.CategoryExtensions,
+		"Extensions":                       cmn.CategoryExtensions,
+		"Local Extension Settings":         cmn.CategoryExtensions,
+		"Managed Extension Settings":       cmn.CategoryExtensions,
+		"Sync Extension Settings":          cmn.CategoryExtensions,
+		"Service Worker":                   cmn.CategoryServiceWorkers,
+		"Affiliation Database":             cmn.CategoryCredentials,
+		"Affiliation Database-journal":     cmn.CategoryCredentials,
+		"Login Data":                       cmn.CategoryCredentials,
+		"Login Data-journal":               cmn.CategoryCredentials,
+		"Login Data For Account":           cmn.CategoryCredentials,
+		"Login Data For Account-journal":   cmn.CategoryCredentials,
+		"Web Data":                         cmn.CategoryCredentials,
+		"Web Data-journal":                 cmn.CategoryCredentials,
+		"LOCK":                             cmn.CategorySettings,
+		"Network Persistent State":         cmn.CategorySettings,
+		"Preferences":                      cmn.CategorySettings,
+		"PreferredApps":                    cmn.CategorySettings,
+		"Secure Preferences":               cmn.CategorySettings,
+		"Bookmarks":                        cmn.CategoryBookmarks,
+		"Bookmarks.bak":                    cmn.CategoryBookmarks,
+		"File System":                      cmn.CategoryWebApps,
+		"Web Applications":                 cmn.CategoryWebApps,
+		"Crashpad":                         cmn.CategoryCrashDumps,
+		"Crash Reports":                    cmn.CategoryCrashDumps,
+		"LOG":                              cmn.CategoryLogs,
+		"LOG.old":                          cmn.CategoryLogs,
+	}
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Classifies Chromium profile & cache items as per the rules used by
+// the ChromiumCleaner.
+type ChromiumClassifier struct {
+	Exceptions []string
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements cmn.IClassifier
+func (c *ChromiumClassifier) Classify(kind cmn.RootKind, relPath string, isDir bool) (cmn.Category, cmn.ItemAction, string) {
+	// the whole cache directory goes
+	if kind == cmn.RootCache {
+		return cmn.CategoryCache, cmn.ItemDeleted, cmn.RuleCache + "*"
+	}
+
+	// the profile directory itself stays
+	if len(relPath) == 0 || relPath == "." {
+		return cmn.CategoryOther, cmn.ItemKept, ""
+	}
+
+	top := cmn.TopLevel(relPath)
+	base := filepath.Base(relPath)
+	category, ok := ProfileCategories[top]
+	if !ok {
+		category = cmn.CategoryOther
+	}
+
+	// junk left by extensions within kept directories
+	if !isDir && top != relPath && slices.Contains(ExtensionJunkDirs, top) {
+		if pattern := matchAny(ExtensionJunkPatterns, base); len(pattern) != 0 {
+			return cmn.CategoryLogs, cmn.ItemDeleted, cmn.RulePattern + pattern
+		}
+	}
+
+	if slices.Contains(c.Exceptions, top) {
+		return category, cmn.ItemKept, cmn.RuleException + top
+	}
+	return category, cmn.ItemDeleted, cmn.RuleWipe + "*"
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// the first glob pattern that matches name (or empty)
+func matchAny(patterns []string, name string) string {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return pattern
+		}
+	}
+	return ""
+}
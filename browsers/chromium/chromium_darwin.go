@@ -19,31 +19,30 @@ import (
  *-----------------------------------------------------------------*/
 
 const (
-	cCHROMIUM        string = "Chromium"
-	cCHROME_CACHES   string = "Library/Caches/Google"
-	cCHROME_PROFILES string = "Library/Application Support"
+	cCHROMIUM      string = "Chromium"
+	cCHROME_CACHES string = "Google" // in ~/Library/Caches
 )
 
 /* ----------------------------------------------------------------
  *							F u n c t i o n s
  *-----------------------------------------------------------------*/
 
-func GetChromiumDirs() (string, string) {
-	return GetDataDir(), GetCacheDir()
+func GetChromiumDirs(env *cmn.Environment) (string, string) {
+	return GetDataDir(env), GetCacheDir(env)
 }
 
 // Profile-specific Cache directory
 // *Unix/Linux: ~/.cache/chromium/Default
 // *MacOS: ~/Library/Caches/Google/Chromium/Default
-func GetCacheDir() string {
-	ChromiumCachesDir := filepath.Join(cmn.AtHome(cCHROME_CACHES), cCHROMIUM)
+func GetCacheDir(env *cmn.Environment) string {
+	ChromiumCachesDir := filepath.Join(env.CacheHome, cCHROME_CACHES, cCHROMIUM)
 	return ChromiumCachesDir
 }
 
 // Profile-specific Profile directory
 // *Unix/Linux: ~/.config/chromium
 // *MacOS: ~/Library/Application Support/Chromium/Default
-func GetDataDir() string {
-	ChromiumProfilesDir := filepath.Join(cmn.AtHome(cCHROME_PROFILES), cCHROMIUM)
+func GetDataDir(env *cmn.Environment) string {
+	ChromiumProfilesDir := filepath.Join(env.ConfigHome, cCHROMIUM)
 	return ChromiumProfilesDir
 }
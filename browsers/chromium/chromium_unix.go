@@ -19,9 +19,7 @@ import (
  *-----------------------------------------------------------------*/
 
 const (
-	cCHROMIUM        string = "chromium"
-	cCHROME_CACHES   string = ".cache"
-	cCHROME_PROFILES string = ".config"
+	cCHROMIUM string = "chromium"
 )
 
 /* ----------------------------------------------------------------
@@ -30,20 +28,20 @@ const (
 
 // Returns the Data & Cache directories which are NOT profile-specific.
 // The profile name still has to be added for each specific profile.
-func GetChromiumDirs() (string, string) {
-	return GetDataDir(), GetCacheDir()
+func GetChromiumDirs(env *cmn.Environment) (string, string) {
+	return GetDataDir(env), GetCacheDir(env)
 }
 
 // Profile-specific Cache directory
-// *Unix/Linux: ~/.cache/chromium/
-func GetCacheDir() string {
-	ChromiumCachesDir := filepath.Join(cmn.AtHome(cCHROME_CACHES), cCHROMIUM)
+// *Unix/Linux: ~/.cache/chromium/ ($XDG_CACHE_HOME)
+func GetCacheDir(env *cmn.Environment) string {
+	ChromiumCachesDir := filepath.Join(env.CacheHome, cCHROMIUM)
 	return ChromiumCachesDir
 }
 
 // Profiles-specific Profile directory
-// *Unix/Linux: ~/.config/
-func GetDataDir() string {
-	ChromiumProfilesDir := filepath.Join(cmn.AtHome(cCHROME_PROFILES), cCHROMIUM)
+// *Unix/Linux: ~/.config/chromium ($XDG_CONFIG_HOME)
+func GetDataDir(env *cmn.Environment) string {
+	ChromiumProfilesDir := filepath.Join(env.ConfigHome, cCHROMIUM)
 	return ChromiumProfilesDir
 }
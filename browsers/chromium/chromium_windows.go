@@ -9,8 +9,9 @@
 package chromium
 
 import (
-	"os"
 	"path/filepath"
+
+	cmn "github.com/lordofscripts/wipechromium"
 )
 
 /* ----------------------------------------------------------------
@@ -21,20 +22,16 @@ import (
  *							F u n c t i o n s
  *-----------------------------------------------------------------*/
 
-func GetChromiumDirs() (string, string) {
-	return GetDataDir(), GetCacheDir()
+func GetChromiumDirs(env *cmn.Environment) (string, string) {
+	return GetDataDir(env), GetCacheDir(env)
 }
 
 // Profile-specific Cache directory
 // *Unix/Linux: ~/.cache/chromium/Default
 // *MacOS: ~/Library/Caches/Google/Chromium/Default
 // *Windows:
-func GetCacheDir() string { // TODO: (Windows) needs to be verified!
-	cacheDir, err := os.UserCacheDir()
-	if err != nil {
-		panic(err.Error())
-	}
-	ChromiumCachesDir := filepath.Join(cacheDir, "Chromium", "User Data", "Default", "Cache")
+func GetCacheDir(env *cmn.Environment) string { // TODO: (Windows) needs to be verified!
+	ChromiumCachesDir := filepath.Join(env.CacheHome, "Chromium", "User Data", "Default", "Cache")
 
 	return ChromiumCachesDir
 }
@@ -43,8 +40,8 @@ func GetCacheDir() string { // TODO: (Windows) needs to be verified!
 // *Unix/Linux: ~/.config/chromium
 // *MacOS: ~/Library/Application Support/Chromium/Default
 // *Windows: %LOCALAPPDATA%\Google\Chrome\User Data\Default
-func GetDataDir() string { // TODO: (Windows) needs to be verified!
-	cacheDir := GetCacheDir()
+func GetDataDir(env *cmn.Environment) string { // TODO: (Windows) needs to be verified!
+	cacheDir := GetCacheDir(env)
 	ChromiumProfilesDir := filepath.Join(cacheDir, "Chromium", "User Data", "Default")
 	return ChromiumProfilesDir
 }
@@ -7,10 +7,12 @@
 package firefox
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	cmn "github.com/lordofscripts/wipechromium"
@@ -52,6 +54,11 @@ var (
 		"settings",
 		"features",
 	}
+	// held by a running Firefox, in the profile directory (Unix & Windows)
+	FirefoxLocks []string = []string{
+		"lock",
+		"parent.lock",
+	}
 )
 
 /* ----------------------------------------------------------------
@@ -64,15 +71,26 @@ var (
 
 type FirefoxCleaner struct {
 	Class       browsers.Browser
+	Env         *cmn.Environment
 	ProfileName string
 	CacheRoot   string
 	ProfileRoot string
 	Profiles    map[string]firefoxProfile
-	cleanedSize int64
+	cleaned     cmn.DiskSize
 	sizeMode    cmn.SizeMode
 	doDryRun    bool
 	scanOnly    bool
-	logx        cmn.ILogger
+	logx        *cmn.ConditionalLogger
+	out         cmn.IRenderer
+	report      *cmn.WipeReport
+	observers   []cmn.IObserver
+	keepGoing   bool
+	exceptions  []string
+	backupDir   string
+	shred       bool
+	policy      *cmn.ProfilePolicy
+	// the profile phase only removes these (nil is no limit)
+	only []string
 }
 
 // A [Profile*] section in Firefox's profiles.ini
@@ -86,19 +104,23 @@ type firefoxProfile struct {
  *							C o n s t r u c t o r s
  *-----------------------------------------------------------------*/
 
-func NewFirefoxCleaner(profile string, scanOnly bool, smode cmn.SizeMode, dry bool, logger ...cmn.ILogger) *FirefoxCleaner {
+// The cleaner of a profile of the Firefox in env (see cmn.NewEnvironment),
+// cmn.ErrProfileDoesNotExist if profiles.ini has no such profile or
+// cmn.ErrCleanerFailure if there are no Firefox profiles at all.
+func NewFirefoxCleaner(env *cmn.Environment, profile string, scanOnly bool, smode cmn.SizeMode, dry bool, logger ...cmn.ILogger) (*FirefoxCleaner, error) {
 	const cName = "FirefoxCleaner"
-	var logCtx cmn.ILogger
+	var logCtx *cmn.ConditionalLogger
 	if len(logger) == 0 {
 		logCtx = cmn.NewConditionalLogger(false, cName)
 	} else {
 		logCtx = logger[0].InheritAs(cName)
 	}
+	logCtx = logCtx.With(cmn.LogKeyBrowser, browsers.FirefoxBrowser.String(), cmn.LogKeyProfile, profile)
 
 	// find out which Firefox user profiles are defined
-	err, mapping := getProfiles()
+	err, mapping := getProfiles(env)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("%w: %v", cmn.ErrCleanerFailure, err)
 	}
 
 	var subPath string = "" // empty if not profile-specific
@@ -107,30 +129,38 @@ func NewFirefoxCleaner(profile string, scanOnly bool, smode cmn.SizeMode, dry bo
 		profile = strings.ToLower(profile)
 		pinfo, ok := mapping[profile]
 		if !ok {
-			cmn.SpitOutError(1, cmn.ErrProfileDoesNotExist)
-			return nil
+			return nil, fmt.Errorf("%w: %q", cmn.ErrProfileDoesNotExist, profile)
 		}
 		subPath = pinfo.SubPath
 	}
 
-	err, dataDir, cachesDir := GetFirefoxDirs(subPath)
+	err, dataDir, cachesDir := GetFirefoxDirs(env, subPath)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return nil, fmt.Errorf("%w: %v", cmn.ErrCleanerFailure, err)
 	}
 
 	return &FirefoxCleaner{
 		browsers.FirefoxBrowser,
+		env,
 		strings.Trim(profile, " \t"),
 		cachesDir, //filepath.Join(cachesDir, subPath),
 		dataDir,   //filepath.Join(dataDir, subPath),
 		mapping,
-		0,
+		cmn.DiskSize{},
 		smode,
 		dry,
 		scanOnly,
 		logCtx,
-	}
+		cmn.NewRenderer(cmn.OutputHuman, nil, smode),
+		nil,
+		nil,
+		false,
+		FirefoxProfileExceptions,
+		"",
+		false,
+		nil,
+		nil,
+	}, nil
 }
 
 /* ----------------------------------------------------------------
@@ -142,11 +172,10 @@ func (f firefoxProfile) String() string {
 }
 
 func (c *FirefoxCleaner) String() string {
-	reportedSize := cmn.ReportByteCount(c.cleanedSize, c.sizeMode)
+	reportedSize := c.cleaned.Format(c.sizeMode)
 
-	c.logx.Print("Cleaned ", cmn.AddThousands(c.cleanedSize, ','))
-	c.logx.Print("Cleaned ", cmn.ByteCountSI(c.cleanedSize))
-	c.logx.Print("Cleaned ", cmn.ByteCountIEC(c.cleanedSize))
+	c.logx.Debug("cleaned", cmn.LogKeyBytes, c.cleaned.Apparent, "si", cmn.ByteCountSI(c.cleaned.Apparent),
+		"iec", cmn.ByteCountIEC(c.cleaned.Apparent))
 	return fmt.Sprintf("%sCleaner %q cleaned %s aka %q", c.Class, c.ProfileName, reportedSize, CODENAME)
 }
 
@@ -157,38 +186,88 @@ func (c *FirefoxCleaner) Name() browsers.Browser {
 // Top level function to clear a Chromium user profile directory. Rather than
 // saving important data to a Temp directory and then restoring (as previous version)
 // now we simply go through the top level with a list of exceptions
+// The context stops it (after the current item) on a signal or timeout.
 // Example: clearProfile("Profile 1")
-func (c *FirefoxCleaner) ClearProfile(doCache, doProfile bool) (error, int) {
+func (c *FirefoxCleaner) ClearProfile(ctx context.Context, doCache, doProfile bool) (error, int) {
 	if c.scanOnly {
 		return browsers.ErrInvalidOperation, 0
 	}
-	fmt.Printf("Clearing profile %q\n", c.ProfileName)
+	c.out.Printf("Clearing profile %q\n", c.ProfileName)
+
+	c.cleaned = cmn.DiskSize{}
+	c.report = cmn.NewWipeReport(c.Class.String(), c.ProfileName, c.doDryRun)
+	c.report.Subscribe(c.observers...)
+	c.report.Start(c.out)
+	// the policy has the last word on what goes & what stays
+	doCache, doProfile = c.policy.Phases(doCache, doProfile)
+	defer c.report.Finish()
+	defer c.report.Categorize(&FirefoxClassifier{c.keptNames()}, c.ProfileRoot, c.CacheRoot)
+
+	// with -keep-going a failed phase does not stop the others
+	var failures cmn.MultiError
+	stop := func(err error) bool {
+		if err == nil {
+			return false
+		}
+		if !c.keepGoing || cmn.IsInterruption(err) {
+			return true
+		}
+		failures.Add(err)
+		return false
+	}
 
-	c.cleanedSize = 0
 	if len(c.ProfileName) == 0 {
 		// we can only operate in AppData root only as no profile is given
-		return cmn.ErrNoProfile, 40
+		c.report.Fail(cmn.ErrNoProfile, cmn.ExitNoProfile)
+		return cmn.ErrNoProfile, cmn.ExitNoProfile
+	}
+
+	// wiping it under the feet of a running browser corrupts it
+	if lock := c.heldLock(); len(lock) != 0 && !c.doDryRun {
+		err := cmn.NewError(cmn.ExitProfileInUse, "lock", lock, nil)
+		c.report.Fail(err, err.Code)
+		return err, err.Code
 	}
 
 	// 1. Profile Cache
 	if doCache {
-		if err := c.clearCache(); err != nil {
-			return err, 50
+		action := c.report.Begin(cmn.PhaseCache, c.CacheRoot)
+		err := c.clearCache(ctx, action)
+		if code := c.report.End(action, err, cmn.ExitCachePhase); stop(err) {
+			return failures.Final(err), code
 		}
 	}
 
 	// 2. Profile Data
 	if doProfile {
-		if err := c.eraseProfile(); err != nil {
-			return err, 60
+		if err, code := c.report.Check(ctx); err != nil {
+			return failures.Final(err), code
+		}
+		action := c.report.Begin(cmn.PhaseProfile, c.ProfileRoot)
+		err := c.eraseProfile(ctx, action)
+		if code := c.report.End(action, err, cmn.ExitProfilePhase); stop(err) {
+			return failures.Final(err), code
 		}
 
-		if err := c.clearExtensions(); err != nil {
-			return err, 70
+		if err, code := c.report.Check(ctx); err != nil {
+			return failures.Final(err), code
+		}
+		if c.policy.Forbids(cmn.CategoryExtensions) {
+			c.logx.Info("extension junk kept as per the policy")
+		} else {
+			action = c.report.Begin(cmn.PhaseExtensions, c.ProfileRoot)
+			err = c.clearExtensions(ctx, action)
+			if code := c.report.End(action, err, cmn.ExitExtensionsPhase); stop(err) {
+				return failures.Final(err), code
+			}
 		}
 	}
 
-	c.logx.Printf("Profile %q cleared of private/junk data", c.ProfileName)
+	if err := failures.Err(); err != nil {
+		return err, c.report.PartialExitCode()
+	}
+
+	c.logx.Info("profile cleared", cmn.LogKeyBytes, c.cleaned.Apparent)
 	return nil, 0
 }
 
@@ -196,41 +275,93 @@ func (c *FirefoxCleaner) ClearProfile(doCache, doProfile bool) (error, int) {
 // It should print out the supposed location of the Data & Cache directories
 // so that the user can verify prior to running the program for the 1st time.
 func (c *FirefoxCleaner) Tell() bool {
-	fmt.Printf("❋✦ %s Directories:\n", VARIANT)
-
-	// the profile's existence has been verified at the constructor, and
-	// the name had been normalized to lowercase by getProfiles()
+	c.out.Printf("❋✦ %s Directories:\n", VARIANT)
 
-	var profileSubDir string = "" // all profiles
-	if !c.scanOnly {
-		// user-profile specific
-		profileSubDir = c.Profiles[strings.ToLower(c.ProfileName)].SubPath
-	}
-
-	if err, dataDir, cachesDir := GetFirefoxDirs(profileSubDir); err != nil {
-		cmn.SpitOutError(1, err)
+	if scan, err := c.inspectDirs(); err != nil {
+		c.out.Printf("\t%s\n", err)
 		return false
 	} else {
-		dataExists := cmn.IsDirectory(dataDir)
-		cacheExists := cmn.IsDirectory(cachesDir)
-
-		var sizeD, sizeC int64
-		if dataExists {
-			sizeD, _ = cmn.GetDirectorySize(dataDir)
-		}
-		if cacheExists {
-			sizeC, _ = cmn.GetDirectorySize(cachesDir)
-		}
-
-		fmt.Printf("\tData : %5t %s %s\n", dataExists, dataDir, cmn.ReportByteCount(sizeD, c.sizeMode))
-		fmt.Printf("\tCache: %5t %s %s\n", cacheExists, cachesDir, cmn.ReportByteCount(sizeC, c.sizeMode))
+		c.out.Printf("\tData : %5t %s %s\n", scan.DataExists, scan.DataDir, scan.DataUsage().Format(c.sizeMode))
+		c.out.Printf("\tCache: %5t %s %s\n", scan.CacheExists, scan.CacheDir, scan.CacheUsage().Format(c.sizeMode))
 		/*		// list all registered Firefox user profiles
 				fmt.Println("\tProfiles:")
 				for _, pe := range c.Profiles {
 					fmt.Printf("\t%s\n", pe)
 				}*/
-		return dataExists && cacheExists
+		return scan.DataExists && scan.CacheExists
+	}
+}
+
+// Machine-readable equivalent of Tell() plus installation & profiles.
+func (c *FirefoxCleaner) Inspect() *cmn.BrowserScan {
+	scan, err := c.inspectDirs()
+	if err != nil {
+		scan.Error = err.Error()
+		return scan
+	}
+
+	scan.Installed = c.IdentifyAppDataRoot()
+	if profiles, err := c.FindProfileNames(); err == nil {
+		scan.Profiles = profiles
 	}
+	return scan
+}
+
+// Detailed result of the last ClearProfile()
+func (c *FirefoxCleaner) Report() *cmn.WipeReport {
+	return c.report
+}
+
+// Console output & progress events go to this renderer
+func (c *FirefoxCleaner) SetRenderer(out cmn.IRenderer) {
+	c.out = out
+}
+
+// Go on with the other items & phases after a failure. ClearProfile()
+// then returns a *cmn.MultiError with all of them.
+func (c *FirefoxCleaner) SetKeepGoing(on bool) {
+	c.keepGoing = on
+}
+
+// Keep the profile's data of these categories (SQLite companion files
+// included) on top of FirefoxProfileExceptions
+func (c *FirefoxCleaner) KeepCategories(categories ...cmn.Category) {
+	c.exceptions = append(slices.Clone(FirefoxProfileExceptions), categoryNames(categories...)...)
+}
+
+// Move what the profile phase removes there instead of deleting it
+func (c *FirefoxCleaner) SetBackupDir(dir string) {
+	c.backupDir = dir
+}
+
+// Overwrite what is removed with zeros before deleting it
+func (c *FirefoxCleaner) SetShred(on bool) {
+	c.shred = on
+}
+
+// What the system policy demands of the profile
+func (c *FirefoxCleaner) SetPolicy(policy *cmn.ProfilePolicy) {
+	c.policy = policy
+}
+
+// Limit the profile phase to these top-level items (i.e. of a plan)
+func (c *FirefoxCleaner) LimitProfile(names []string) {
+	c.only = names
+}
+
+// The profile's data directory
+func (c *FirefoxCleaner) ProfileDir() string {
+	return c.ProfileRoot
+}
+
+// The lock of the running browser that holds the profile, if any
+func (c *FirefoxCleaner) HeldLock() string {
+	return c.heldLock()
+}
+
+// Progress events also go to these observers (besides the renderer)
+func (c *FirefoxCleaner) Subscribe(observers ...cmn.IObserver) {
+	c.observers = append(c.observers, observers...)
 }
 
 // Find all known user profiles. In Firefox ESR these are found in an INI file,
@@ -238,7 +369,7 @@ func (c *FirefoxCleaner) Tell() bool {
 func (c *FirefoxCleaner) FindProfileNames() ([]string, error) {
 	names := make([]string, 0)
 
-	err, mapping := getProfiles()
+	err, mapping := getProfiles(c.Env)
 	if err != nil {
 		return names, err
 	}
@@ -259,13 +390,13 @@ func (c *FirefoxCleaner) FindProfileNames() ([]string, error) {
 // different settings, extensions, bookmarks, etc.
 // Returns: true if GetDataDir() is the root of all user account profiles.
 func (c *FirefoxCleaner) IdentifyAppDataRoot() bool {
-	return IdentifyAppDataRoot()
+	return IdentifyAppDataRoot(c.Env)
 }
 
 // A user profile's cache directory.
 // Returns: true if GetCacheDir()+profile is a valid browser Cache directory.
 func (c *FirefoxCleaner) IdentifyProfileCache(profileDir string) bool {
-	return IdentifyProfileCache(profileDir)
+	return IdentifyProfileCache(c.Env, profileDir)
 }
 
 // A user profile specific data (extensions, Bookmarks, etc.).
@@ -273,121 +404,245 @@ func (c *FirefoxCleaner) IdentifyProfileCache(profileDir string) bool {
 // by IdentifyAppDataRoot().
 // Returns: true if directory contains browser user profile data & settings.
 func (c *FirefoxCleaner) IdentifyProfileData(profileDir string) bool {
-	return IdentifyProfileData(profileDir)
+	return IdentifyProfileData(c.Env, profileDir)
+}
+
+// A du-style tree of this profile's data & cache directories, every
+// node labeled with its category and what the current rules would do.
+func (c *FirefoxCleaner) AnalyzeDiskUsage(depth, top int) (*cmn.DiskUsageReport, error) {
+	if len(c.ProfileName) == 0 {
+		return nil, cmn.ErrNoProfile
+	}
+
+	classifier := &FirefoxClassifier{c.keptNames()}
+	roots := make([]*cmn.DUNode, 0, 2)
+	kinds := []cmn.RootKind{cmn.RootProfile, cmn.RootCache}
+	for i, dir := range []string{c.ProfileRoot, c.CacheRoot} {
+		node, err := cmn.AnalyzeDiskUsage(dir, kinds[i], classifier, depth)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		roots = append(roots, node)
+	}
+	if len(roots) == 0 {
+		return nil, cmn.ErrProfileDoesNotExist
+	}
+
+	return cmn.NewDiskUsageReport(c.Class.String(), c.ProfileName, depth, top, roots...), nil
 }
 
 /* ----------------------------------------------------------------
  *					I n t e r n a l 	M e t h o d s
  *-----------------------------------------------------------------*/
 
-// Clears the entire cache dir of a profile
-// Example: clearCache("Profile 1")
-// NOTE: Supports dry run.
-func (c *FirefoxCleaner) clearCache() error {
-	fmt.Println("\tClearing cache...")
+// Data & Cache directories (profile-specific unless scanning), whether
+// they exist and their size.
+func (c *FirefoxCleaner) inspectDirs() (*cmn.BrowserScan, error) {
+	scan := &cmn.BrowserScan{Browser: c.Class.String(), Flavor: VARIANT}
 
-	dry := cmn.NewDryRunner()
-	if !c.doDryRun {
-		dry.Disable()
+	// the profile's existence has been verified at the constructor, and
+	// the name had been normalized to lowercase by getProfiles()
+
+	var profileSubDir string = "" // all profiles
+	if !c.scanOnly {
+		// user-profile specific
+		profileSubDir = c.Profiles[strings.ToLower(c.ProfileName)].SubPath
 	}
 
-	cacheSize, err := cmn.GetDirectorySize(c.CacheRoot)
+	err, dataDir, cachesDir := GetFirefoxDirs(c.Env, profileSubDir)
 	if err != nil {
-		c.logx.Printf("clearCache WARN %s", err)
+		return scan, err
+	}
+
+	scan.DataDir, scan.CacheDir = dataDir, cachesDir
+	scan.DataExists = c.Env.IsDirectory(dataDir)
+	scan.CacheExists = c.Env.IsDirectory(cachesDir)
+	if scan.DataExists {
+		usage, _ := c.Env.DirectoryUsage(context.Background(), dataDir)
+		scan.DataSize, scan.DataAllocated = usage.Apparent, usage.Allocated
+	}
+	if scan.CacheExists {
+		usage, _ := c.Env.DirectoryUsage(context.Background(), cachesDir)
+		scan.CacheSize, scan.CacheAllocated = usage.Apparent, usage.Allocated
+	}
+	return scan, nil
+}
+
+// returns a DryRunner in the appropriate mode for this cleaner
+// The lock of a Firefox running with this profile if any
+func (c *FirefoxCleaner) heldLock() string {
+	return c.Env.HeldLock(c.ProfileRoot, FirefoxLocks...)
+}
+
+// the profile items the profile phase keeps: the exceptions as per the
+// kept categories & the policy, or all but those of a plan
+func (c *FirefoxCleaner) keptNames() []string {
+	kept := c.policy.Exceptions(c.exceptions, categoryNames)
+	if c.only != nil {
+		kept = append(kept, cmn.NamesBesides(c.ProfileRoot, c.only)...)
 	}
+	return kept
+}
+
+func (c *FirefoxCleaner) dryRunner() *cmn.DryRun {
+	return c.Env.DryRunner(c.doDryRun, c.out.Writer())
+}
+
+// Clears the entire cache dir of a profile
+// Example: clearCache("Profile 1")
+// NOTE: Supports dry run.
+func (c *FirefoxCleaner) clearCache(ctx context.Context, action *cmn.WipeAction) error {
+	c.out.Printf("\tClearing cache...\n")
 
-	if !IdentifyProfileCache(c.Profiles[c.ProfileName].SubPath) {
-		c.logx.Printf("%s: %s", cmn.ErrNotBrowserCache, c.CacheRoot)
-		return cmn.ErrNotBrowserCache
+	dry := c.dryRunner()
+
+	if !IdentifyProfileCache(c.Env, c.Profiles[c.ProfileName].SubPath) {
+		c.logx.Error(cmn.ErrNotBrowserCache.Error(), cmn.LogKeyPath, c.CacheRoot)
+		return cmn.NewError(cmn.ExitNotCache, "identify", c.CacheRoot, nil)
 	}
 
-	// 'Cache' 'Code Cache' and sometimes 'Storage'
-	if err := dry.RemoveAll(c.CacheRoot); err != nil {
-		werr := cmn.WrapError(err, 41, "Could not remove cache dir %q.\n\t%s", c.CacheRoot, cmn.ThisLocation(1))
-		cmn.SpitOutError(1, werr)
+	// 'Cache' 'Code Cache' and sometimes 'Storage' sized as they go
+	walker := cmn.NewWalker(cmn.WalkerJobs).OnProgress(cmn.ProgressInterval, func(done cmn.DiskSize, files int) {
+		c.report.Event(cmn.NewProgressEvent(c.CacheRoot, done, files))
+	})
+	c.report.Event(cmn.NewItemEvent(cmn.EventItemStarted, &cmn.ItemRecord{Path: c.CacheRoot, IsDir: true}))
+	var err error
+	if c.shred || c.policy.MustShred() {
+		err = dry.Shred(ctx, c.CacheRoot)
+	}
+	var cacheUsage cmn.DiskSize
+	if err == nil {
+		cacheUsage, err = dry.RemoveAllSized(ctx, walker, c.CacheRoot)
+	}
+	cacheSize := cacheUsage.Apparent
+	if err != nil {
+		// whatever was removed before the failure is gone nonetheless
+		c.cleaned.Add(cacheUsage)
+		action.Bytes += cacheSize
+		action.Allocated += cacheUsage.Allocated
+		item := cmn.NewFailedItem(c.CacheRoot, true, cacheSize, cmn.RuleCache+"*", err)
+		c.report.Record(cmn.PhaseCache, item)
+		c.report.Event(cmn.NewItemEvent(cmn.EventError, item))
+		werr := cmn.WrapError(err, cmn.ExitCacheRemove, "Could not remove cache dir %q.\n\t%s", c.CacheRoot, cmn.ThisLocation(1))
+		c.logx.Error("could not remove the cache", cmn.LogKeyPath, c.CacheRoot, cmn.LogKeyErr, err)
 		return werr
 	}
+	item := cmn.NewDeletedItem(c.CacheRoot, true, cacheSize, cmn.RuleCache+"*")
+	c.report.Record(cmn.PhaseCache, item)
+	c.report.Event(cmn.NewItemEvent(cmn.EventItemDeleted, item))
 
 	if RecreateCacheDir {
 		if err := dry.MkDir(c.CacheRoot, PERMS); err != nil {
-			c.logx.Printf("Could not recreate CACHE %s %s", c.CacheRoot, cmn.ThisLocation(1))
+			c.logx.Warn("could not recreate cache", cmn.LogKeyPath, c.CacheRoot, cmn.LogKeyErr, err)
 		}
 	}
 
-	c.cleanedSize += cacheSize
-	fmt.Printf("\tDeleted %s bytes from cache\n", cmn.ReportByteCount(cacheSize, c.sizeMode))
-	c.logx.Print("clearCache DONE")
+	c.cleaned.Add(cacheUsage)
+	action.Bytes += cacheSize
+	action.Allocated += cacheUsage.Allocated
+	action.Items += 1
+	c.out.Printf("\tDeleted %s bytes from cache\n", cacheUsage.Format(c.sizeMode))
+	c.logx.Debug("cache cleared", cmn.LogKeyPath, c.CacheRoot, cmn.LogKeyBytes, cacheSize)
 	return nil
 }
 
 // erases a User Profile but keeps important profile data such as
 // extensions and settings.
-func (c *FirefoxCleaner) eraseProfile() error {
-	fmt.Println("\tClearing profile")
+func (c *FirefoxCleaner) eraseProfile(ctx context.Context, action *cmn.WipeAction) error {
+	c.out.Printf("\tClearing profile\n")
 
 	// (a )Identify it is a profile directory
-	if !IdentifyProfileData(c.Profiles[c.ProfileName].SubPath) {
-		return cmn.ErrNotBrowserProfile
+	if !IdentifyProfileData(c.Env, c.Profiles[c.ProfileName].SubPath) {
+		return cmn.NewError(cmn.ExitNotProfile, "identify", c.ProfileRoot, nil)
 	}
 
 	// (b) we are going to clean the profile's top level
-	fmt.Printf("%s DirCleanerRoot %s\n", cmn.ThisLocation(1), c.ProfileRoot)
-	var filter cmn.IDirCleaner
-	if !c.doDryRun {
-		filter = cmn.NewDirCleaner(c.ProfileRoot, c.sizeMode, false)
-	} else {
-		filter = cmn.NewDirCleanerDryVFS(c.ProfileRoot, c.sizeMode, c.logx)
-	}
+	c.out.Printf("%s DirCleanerRoot %s\n", cmn.ThisLocation(1), c.ProfileRoot)
+	// a dry run lists the real profile (a plan is made of it) but
+	// removes nothing
+	var filter cmn.IDirCleaner = c.Env.DirCleaner(c.ProfileRoot, c.sizeMode, c.doDryRun, c.logx)
 
 	// (c) except these important profile items
-	err := filter.CleanUp(FirefoxProfileExceptions)
+	filter.Subscribe(c.report)
+	filter.SetKeepGoing(c.keepGoing)
+	filter.SetOutput(c.out.Writer())
+	backupDir, err := c.policy.BackupTarget(c.backupDir)
 	if err != nil {
-		c.logx.Print(err)
-		return cmn.WrapError(err, 51, "EraseProfile fault.")
+		return cmn.WrapError(err, cmn.ExitPolicy, "EraseProfile policy")
+	}
+	if len(backupDir) != 0 {
+		filter.SetBackupDir(cmn.BackupPath(backupDir, c.Class.String(), c.ProfileName, c.report.Started))
+	}
+	filter.SetShred(c.shred || c.policy.MustShred())
+	err = filter.CleanUp(ctx, c.keptNames())
+	c.report.Record(cmn.PhaseProfile, filter.Items()...)
+	c.cleaned.Add(filter.CleanedUsage())
+	action.Bytes += filter.CleanedSize()
+	action.Allocated += filter.CleanedUsage().Allocated
+	action.Items += filter.RemovedCount()
+	action.Skipped += filter.SkippedCount()
+	if err != nil {
+		c.logx.Error("erase profile", cmn.LogKeyPath, c.ProfileRoot, cmn.LogKeyErr, err)
+		return cmn.WrapError(err, cmn.ExitProfileErase, "EraseProfile fault")
 	}
-
-	c.cleanedSize += filter.CleanedSize()
-	fmt.Printf("\t...Erased %s bytes\n", cmn.ReportByteCount(c.cleanedSize, c.sizeMode))
+	c.out.Printf("\t...Erased %s bytes\n", c.cleaned.Format(c.sizeMode))
 	return nil
 }
 
 // Apparently nothing to clear in Firefox Extensions
-func (c *FirefoxCleaner) clearExtensions() error {
+func (c *FirefoxCleaner) clearExtensions(ctx context.Context, action *cmn.WipeAction) error {
 	return nil
 }
 
 // Removes all files matching a Pattern at Dir.
 // Example: removeWithPattern("/home/lordofscripts/.cache", "*.log")
-func (c *FirefoxCleaner) removeWithPatterns(dir string, patterns []string) error {
-	dry := cmn.NewDryRunner()
-	if !c.doDryRun {
-		dry.Disable()
-	}
+func (c *FirefoxCleaner) removeWithPatterns(ctx context.Context, action *cmn.WipeAction, dir string, patterns []string) error {
+	dry := c.dryRunner()
 
+	var failures cmn.MultiError
+	sizer := cmn.NewDiskSizer()
 	for _, pattern := range patterns {
-		glob := dir + string(os.PathSeparator) + pattern
-		files, err := filepath.Glob(glob)
+		files, err := c.Env.Glob(dir, pattern)
 		if err != nil {
-			cmn.SpitOutError(1, err)
 			return err
 		}
 
 		for _, fname := range files {
-			var fileSize int64 = 0
-			if finfo, err := os.Stat(fname); err != nil {
+			if err := ctx.Err(); err != nil {
+				return failures.Final(err)
+			}
+			var usage cmn.DiskSize
+			if finfo, err := c.Env.Lstat(fname); err != nil {
 				return err
 			} else {
-				fileSize = finfo.Size()
+				usage = sizer.Of(finfo)
 			}
+			fileSize := usage.Apparent
 			// remove file or empty directory
 			if err := dry.Remove(fname); err != nil {
-				return err
+				item := cmn.NewFailedItem(fname, false, fileSize, cmn.RulePattern+pattern, err)
+				c.report.Record(action.Phase, item)
+				c.report.Event(cmn.NewItemEvent(cmn.EventError, item))
+				if c.keepGoing {
+					failures.Add(cmn.NewItemError(fname, "remove", 0, err))
+					continue
+				}
+				return failures.Final(err)
 			}
-			c.cleanedSize += fileSize
+			item := cmn.NewDeletedItem(fname, false, fileSize, cmn.RulePattern+pattern)
+			c.report.Record(action.Phase, item)
+			c.report.Event(cmn.NewItemEvent(cmn.EventItemDeleted, item))
+			c.cleaned.Add(usage)
+			action.Bytes += fileSize
+			action.Allocated += usage.Allocated
+			action.Items += 1
 		}
 	}
 
-	return nil
+	return failures.Err()
 }
 
 /* ----------------------------------------------------------------
@@ -398,46 +653,59 @@ func (c *FirefoxCleaner) removeWithPatterns(dir string, patterns []string) error
 // Remember every user can have several profiles. This identifies just the
 // root where the profiles are located
 // At least 'System Profile', 'Avatars' & 'Safe Browsing' exist
-func IdentifyAppDataRoot() bool {
-	err, appdata := GetRootDataDir()
+func IdentifyAppDataRoot(env *cmn.Environment) bool {
+	err, appdata := GetRootDataDir(env)
 	if err != nil {
-		fmt.Println(err) // TODO refactor app to pass error back to caller!
 		return false
 	}
 
-	return cmn.IsDirectory(filepath.Join(appdata, "firefox-mpris")) &&
-		cmn.IsDirectory(filepath.Join(appdata, "Crash Reports")) &&
-		cmn.IsDirectory(filepath.Join(appdata, "Pending Pings")) &&
-		cmn.IsFile(filepath.Join(appdata, "installs.ini")) == cmn.Yes &&
-		cmn.IsFile(filepath.Join(appdata, "profiles.ini")) == cmn.Yes
+	return env.IsDirectory(filepath.Join(appdata, "firefox-mpris")) &&
+		env.IsDirectory(filepath.Join(appdata, "Crash Reports")) &&
+		env.IsDirectory(filepath.Join(appdata, "Pending Pings")) &&
+		env.IsFile(filepath.Join(appdata, "installs.ini")) == cmn.Yes &&
+		env.IsFile(filepath.Join(appdata, "profiles.ini")) == cmn.Yes
 }
 
 // Identify GetCacheDir() as a proper Chromium Cache directory
 // Both 'Cache' & 'Code Cache' dirs exist
 // Linux: .cache/mozilla/firefox/
-func IdentifyProfileCache(profileDir string) bool {
-	err, userCacheDir := GetCacheDir(profileDir)
+func IdentifyProfileCache(env *cmn.Environment, profileDir string) bool {
+	err, userCacheDir := GetCacheDir(env, profileDir)
 	if err != nil {
-		fmt.Println(err)
 		return false
 	}
 
-	return cmn.IsDirectory(filepath.Join(userCacheDir, "cache2")) &&
-		cmn.IsDirectory(filepath.Join(userCacheDir, "startupCache"))
+	return env.IsDirectory(filepath.Join(userCacheDir, "cache2")) &&
+		env.IsDirectory(filepath.Join(userCacheDir, "startupCache"))
 }
 
 // At least Bookmarks exist && Cookies
-func IdentifyProfileData(profileDir string) bool {
-	err, userDir := GetDataDir(profileDir)
+func IdentifyProfileData(env *cmn.Environment, profileDir string) bool {
+	err, userDir := GetDataDir(env, profileDir)
 	if err != nil {
-		fmt.Println(err) // TODO refactor app to pass error back to caller!
 		return false
 	}
 
-	return cmn.IsDirectory(filepath.Join(userDir, "bookmarkbackups")) &&
-		cmn.IsDirectory(filepath.Join(userDir, "extensions")) &&
-		cmn.IsFile(filepath.Join(userDir, "places.sqlite")) == cmn.Yes &&
-		cmn.IsFile(filepath.Join(userDir, "cookies.sqlite")) == cmn.Yes
+	return env.IsDirectory(filepath.Join(userDir, "bookmarkbackups")) &&
+		env.IsDirectory(filepath.Join(userDir, "extensions")) &&
+		env.IsFile(filepath.Join(userDir, "places.sqlite")) == cmn.Yes &&
+		env.IsFile(filepath.Join(userDir, "cookies.sqlite")) == cmn.Yes
+}
+
+// The profiles of the profiles.ini (data) of the Firefox at root: their
+// name (lowercase) & directory relative to root. A profile whose Path
+// leads out of root is left out, whether it is absolute (IsRelative=0)
+// or has ".." in it.
+func ProfileDirs(root string, data []byte) (map[string]string, error) {
+	err, profiles := parseProfiles(root, data)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]string, len(profiles))
+	for name, profile := range profiles {
+		dirs[name] = profile.SubPath
+	}
+	return dirs, nil
 }
 
 // Gets the list of Firefox user profiles and their mapping to an actual
@@ -445,47 +713,62 @@ func IdentifyProfileData(profileDir string) bool {
 // for their user-profile directories. The maping between profile name and
 // that directory is on ¿FireFoxAppDir?/profiles.ini
 // NOTE: The profile Name we normalize to lowercase.
-func getProfiles() (error, map[string]firefoxProfile) {
+func getProfiles(env *cmn.Environment) (error, map[string]firefoxProfile) {
 	const (
 		PROFILES_INI = "profiles.ini"
 	)
-	profiles := make(map[string]firefoxProfile, 0)
 
 	// 1. Find loction
-	err, pathStr := GetRootDataDir()
+	err, pathStr := GetRootDataDir(env)
 	if err != nil {
 		return err, nil
 	}
 
 	// 1.1 Location of INI
 	iniFilename := filepath.Join(pathStr, PROFILES_INI)
-	if cmn.IsFile(iniFilename) != cmn.Yes {
+	if env.IsFile(iniFilename) != cmn.Yes {
 		return fmt.Errorf("Couldn't find FIREFOX %q", iniFilename), nil
 	}
+	data, err := env.ReadFile(iniFilename)
+	if err != nil {
+		return err, nil
+	}
+
+	return parseProfiles(pathStr, data)
+}
+
+// The profiles of the profiles.ini of the Firefox at root, see ProfileDirs()
+func parseProfiles(root string, data []byte) (error, map[string]firefoxProfile) {
+	profiles := make(map[string]firefoxProfile, 0)
 
 	// 1.2 open INI with section & key names normalized to lowercase
-	pcfg, err := ini.InsensitiveLoad(iniFilename)
+	pcfg, err := ini.InsensitiveLoad(data)
 	if err != nil {
-		fmt.Printf("FIREFOX Fail to read file: %v", err)
-		return err, nil
+		return fmt.Errorf("FIREFOX %s: %w", filepath.Join(root, "profiles.ini"), err), nil
 	}
 
 	// 2. Iterate over INI Sections titled 'ProfileN' where N is a number
 	for _, sectionName := range pcfg.SectionStrings() {
 		if strings.HasPrefix(sectionName, "profile") {
 			const (
-				KEY_NAME    = "name"
-				KEY_PATH    = "path"
-				KEY_DEFAULT = "default"
+				KEY_NAME     = "name"
+				KEY_PATH     = "path"
+				KEY_RELATIVE = "isrelative"
+				KEY_DEFAULT  = "default"
 			)
 			section := pcfg.Section(sectionName)
 
 			// 2.1 Read Name, Path (what we want) and Default
 			name := section.Key(KEY_NAME).String()
 			name = strings.ToLower(name)
+			subPath, ok := profileSubPath(root, section.Key(KEY_PATH).String(), section.Key(KEY_RELATIVE).MustBool(true))
+			if !ok {
+				// not ours to wipe: it would take us out of the root
+				continue
+			}
 			entry := firefoxProfile{
 				name,
-				section.Key(KEY_PATH).String(),
+				subPath,
 				section.Key(KEY_DEFAULT).MustBool(false),
 			}
 			profiles[name] = entry
@@ -494,3 +777,33 @@ func getProfiles() (error, map[string]firefoxProfile) {
 
 	return nil, profiles
 }
+
+// The directory (relative to root) of the Path of a profile, if it is
+// a directory under root. An absolute path (IsRelative=0) is allowed if
+// it is in root, a relative one if no ".." takes it out.
+func profileSubPath(root, path string, isRelative bool) (string, bool) {
+	path = filepath.FromSlash(path)
+	if !isRelative {
+		if !filepath.IsAbs(path) {
+			return "", false
+		}
+		rel, ok := cmn.Within(root, path)
+		if !ok {
+			return "", false
+		}
+		path = rel
+	}
+	if _, ok := cmn.JoinWithin(root, path); !ok || len(path) == 0 || filepath.Clean(path) == "." {
+		return "", false
+	}
+	return filepath.Clean(path), true
+}
+
+// the profile items of the categories, SQLite companion files included
+func categoryNames(categories ...cmn.Category) []string {
+	names := make([]string, 0)
+	for _, name := range cmn.NamesOf(ProfileCategories, categories...) {
+		names = append(names, name, name+"-wal", name+"-shm", name+"-journal")
+	}
+	return names
+}
@@ -0,0 +1,134 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * What each file & directory of a Firefox profile is for
+ *-----------------------------------------------------------------*/
+package firefox
+
+import (
+	"path/filepath"
+	"slices" // GO v1.18
+	"strings"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	_ cmn.IClassifier = (*FirefoxClassifier)(nil)
+
+	// Category of the top-level items of a Firefox user profile.
+	// SQLite companions (-wal, -shm, -journal) share the category of
+	// their database. Anything not listed is cmn.CategoryOther.
+	ProfileCategories = map[string]cmn.Category{
+		"cache2":                     cmn.CategoryCache,
+		"jumpListCache":              cmn.CategoryCache,
+		"OfflineCache":               cmn.CategoryCache,
+		"shader-cache":               cmn.CategoryCache,
+		"startupCache":               cmn.CategoryCache,
+		"thumbnails":                 cmn.CategoryCache,
+		"indexedDB":                  cmn.CategorySiteStorage,
+		"storage":                    cmn.CategorySiteStorage,
+		"storage.sqlite":             cmn.CategorySiteStorage,
+		"storage-sync-v2.sqlite":     cmn.CategorySiteStorage,
+		"webappsstore.sqlite":        cmn.CategorySiteStorage,
+		"cookies.sqlite":             cmn.CategoryCookies,
+		"favicons.sqlite":            cmn.CategoryHistory,
+		"formhistory.sqlite":         cmn.CategoryHistory,
+		"sessionCheckpoints.json":    cmn.CategorySessions,
+		"sessionstore-backups":       cmn.CategorySessions,
+		"sessionstore.jsonlz4":       cmn.CategorySessions,
+		"addons.json":                cmn.CategoryExtensions,
+		"addonStartup.json.lz4":      cmn.CategoryExtensions,
+		"browser-extension-data":     cmn.CategoryExtensions,
+		"extension-preferences.json": cmn.CategoryExtensions,
+		"extension-settings.json":    cmn.CategoryExtensions,
+		"extension-store":            cmn.CategoryExtensions,
+		"extensions":                 cmn.CategoryExtensions,
+		"extensions.json":            cmn.CategoryExtensions,
+		"serviceworker.txt":          cmn.CategoryServiceWorkers,
+		"cert9.db":                   cmn.CategoryCredentials,
+		"key4.db":                    cmn.CategoryCredentials,
+		"logins-backup.json":         cmn.CategoryCredentials,
+		"logins.json":                cmn.CategoryCredentials,
+		"pkcs11.txt":                 cmn.CategoryCredentials,
+		"signedInUser.json":          cmn.CategoryCredentials,
+		".parentlock":                cmn.CategorySettings,
+		"compatibility.ini":          cmn.CategorySettings,
+		"containers.json":            cmn.CategorySettings,
+		"content-prefs.sqlite":       cmn.CategorySettings,
+		"features":                   cmn.CategorySettings,
+		"handlers.json":              cmn.CategorySettings,
+		"lock":                       cmn.CategorySettings,
+		"permissions.sqlite":         cmn.CategorySettings,
+		"prefs.js":                   cmn.CategorySettings,
+		"search.json.mozlz4":         cmn.CategorySettings,
+		"security_state":             cmn.CategorySettings,
+		"settings":                   cmn.CategorySettings,
+		"times.json":                 cmn.CategorySettings,
+		"user.js":                    cmn.CategorySettings,
+		"xulstore.json":              cmn.CategorySettings,
+		"bookmarkbackups":            cmn.CategoryBookmarks,
+		"places.sqlite":              cmn.CategoryBookmarks, // also history!
+		"crashes":                    cmn.CategoryCrashDumps,
+		"minidumps":                  cmn.CategoryCrashDumps,
+	}
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Classifies Firefox profile & cache items as per the rules used by
+// the FirefoxCleaner.
+type FirefoxClassifier struct {
+	Exceptions []string
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements cmn.IClassifier
+func (c *FirefoxClassifier) Classify(kind cmn.RootKind, relPath string, isDir bool) (cmn.Category, cmn.ItemAction, string) {
+	// the whole cache directory goes
+	if kind == cmn.RootCache {
+		return cmn.CategoryCache, cmn.ItemDeleted, cmn.RuleCache + "*"
+	}
+
+	// the profile directory itself stays
+	if len(relPath) == 0 || relPath == "." {
+		return cmn.CategoryOther, cmn.ItemKept, ""
+	}
+
+	top := cmn.TopLevel(relPath)
+	category := categoryOf(top)
+	if !isDir && strings.HasSuffix(filepath.Base(relPath), ".log") {
+		category = cmn.CategoryLogs
+	}
+
+	if slices.Contains(c.Exceptions, top) {
+		return category, cmn.ItemKept, cmn.RuleException + top
+	}
+	return category, cmn.ItemDeleted, cmn.RuleWipe + "*"
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// category of a top-level profile item taking SQLite companion files
+// into account, i.e. cookies.sqlite-wal
+func categoryOf(name string) cmn.Category {
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if category, ok := ProfileCategories[name]; ok {
+		return category
+	}
+	return cmn.CategoryOther
+}
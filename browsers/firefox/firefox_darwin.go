@@ -27,27 +27,24 @@ const (
  *-----------------------------------------------------------------*/
 
 // NOTE: 'profileDir' here is not its name but its actual sub-path directory!
-func GetFirefoxDirs(profileDir string) (error, string, string) {
-	_, dataDir := GetDataDir(profileDir)
-	_, cacheDir := GetCacheDir(profileDir)
+func GetFirefoxDirs(env *cmn.Environment, profileDir string) (error, string, string) {
+	_, dataDir := GetDataDir(env, profileDir)
+	_, cacheDir := GetCacheDir(env, profileDir)
 	return nil, dataDir, cacheDir
 }
 
-func GetRootDataDir() (error, string) {
-	subPath := filepath.Join("Library", "Caches", "Firefox")
-	return nil, cmn.AtHome(subPath)
+func GetRootDataDir(env *cmn.Environment) (error, string) {
+	return nil, filepath.Join(env.CacheHome, "Firefox")
 }
 
 // Profile-specific Cache directory
 // *MacOS: ~/Users/USERNAME/Library/Caches/Firefox/Profiles/PROFILE/{cache|cache2}/
-func GetCacheDir(profileDir string) (error, string) {
-	subPath := filepath.Join("Library", "Caches", "Firefox", "Profiles", profileDir, "cache2")
-	return nil, cmn.AtHome(subPath)
+func GetCacheDir(env *cmn.Environment, profileDir string) (error, string) {
+	return nil, filepath.Join(env.CacheHome, "Firefox", "Profiles", profileDir, "cache2")
 }
 
 // Profile-specific Profile directory
 // *MacOS: ~/Users/USERNAME/Library/Caches/Firefox/Profiles/PROFILE/
-func GetDataDir(profileDir string) (error, string) {
-	subPath := filepath.Join("Library", "Caches", "Firefox", "Profiles", profileDir)
-	return nil, cmn.AtHome(subPath)
+func GetDataDir(env *cmn.Environment, profileDir string) (error, string) {
+	return nil, filepath.Join(env.CacheHome, "Firefox", "Profiles", profileDir)
 }
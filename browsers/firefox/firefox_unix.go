@@ -30,24 +30,24 @@ const (
 // The profile name still has to be added for each specific profile.
 // NOTE: In Linux/Un*x we are already working within a user account dir
 // NOTE: 'profileDir' here is not its name but its actual sub-path directory!
-func GetFirefoxDirs(profileDir string) (error, string, string) {
-	_, dataDir := GetDataDir(profileDir)
-	_, cacheDir := GetCacheDir(profileDir)
+func GetFirefoxDirs(env *cmn.Environment, profileDir string) (error, string, string) {
+	_, dataDir := GetDataDir(env, profileDir)
+	_, cacheDir := GetCacheDir(env, profileDir)
 	return nil, dataDir, cacheDir
 }
 
-func GetRootDataDir() (error, string) {
-	return nil, cmn.AtHome(filepath.Join(".mozilla", "firefox"))
+func GetRootDataDir(env *cmn.Environment) (error, string) {
+	return nil, env.AtHome(".mozilla", "firefox")
 }
 
 // Profile-specific Cache directory
-// *Unix/Linux: ~/.cache/mozilla/firefox/
-func GetCacheDir(profileDir string) (error, string) {
-	return nil, cmn.AtHome(filepath.Join(".cache", "mozilla", "firefox", profileDir))
+// *Unix/Linux: ~/.cache/mozilla/firefox/ ($XDG_CACHE_HOME)
+func GetCacheDir(env *cmn.Environment, profileDir string) (error, string) {
+	return nil, filepath.Join(env.CacheHome, "mozilla", "firefox", profileDir)
 }
 
 // Profiles-specific Profiles directory
 // *Unix/Linux: ~/.mozilla/
-func GetDataDir(profileDir string) (error, string) {
-	return nil, cmn.AtHome(filepath.Join(".mozilla", "firefox", profileDir))
+func GetDataDir(env *cmn.Environment, profileDir string) (error, string) {
+	return nil, env.AtHome(".mozilla", "firefox", profileDir)
 }
@@ -11,7 +11,6 @@ package firefox
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	cmn "github.com/lordofscripts/wipechromium"
@@ -36,9 +35,9 @@ const (
 */
 // NOTE: In Windows we need the User Account folder AND the profile name
 // NOTE: 'profileDir' here is not its name but its actual sub-path directory!
-func GetFirefoxDirs(profileDir string) (error, string, string) {
-	err1, dataDir := GetDataDir(profileDir)
-	err2, cacheDir := GetCacheDir(profileDir)
+func GetFirefoxDirs(env *cmn.Environment, profileDir string) (error, string, string) {
+	err1, dataDir := GetDataDir(env, profileDir)
+	err2, cacheDir := GetCacheDir(env, profileDir)
 	if err1 == nil && err2 == nil {
 		return nil, dataDir, cacheDir
 	} else {
@@ -47,18 +46,9 @@ func GetFirefoxDirs(profileDir string) (error, string, string) {
 	}
 }
 
-func GetRootDataDir() (error, string) {
-	appDataRoaming, err1 := os.UserConfigDir()
-	if err1 != nil {
-		return err1, ""
-	}
-	appDataLocal, err2 := os.UserCacheDir()
-	if err2 != nil {
-		return err2, ""
-	}
-
+func GetRootDataDir(env *cmn.Environment) (error, string) {
 	subPath := filepath.Join("Mozilla", "Firefox")
-	if err, location := locate([]string{appDataRoaming, appDataLocal}, subPath, "ROOT"); err != nil {
+	if err, location := locate(env, subPath, "ROOT"); err != nil {
 		return err, ""
 	} else {
 		return nil, location
@@ -68,18 +58,9 @@ func GetRootDataDir() (error, string) {
 // Profile-specific Cache directory
 // *Windows: C:\Documents and Settings\<user>\Local Settings\Application Data\Mozilla\Firefox\Profiles\<profile>
 // %APPDATA%\Mozilla\Firefox\Profiles\PROFILE\{cache2|Cache}
-func GetCacheDir(profileDir string) (error, string) { // TODO: (Windows) needs to be verified!
-	appDataRoaming, err1 := os.UserConfigDir()
-	if err1 != nil {
-		return err1, ""
-	}
-	appDataLocal, err2 := os.UserCacheDir()
-	if err2 != nil {
-		return err2, ""
-	}
-
+func GetCacheDir(env *cmn.Environment, profileDir string) (error, string) { // TODO: (Windows) needs to be verified!
 	subPath := filepath.Join("Mozilla", "Firefox", "Profiles", profileDir, "cache2")
-	if err, location := locate([]string{appDataRoaming, appDataLocal}, subPath, "CACHE"); err != nil {
+	if err, location := locate(env, subPath, "CACHE"); err != nil {
 		return err, ""
 	} else {
 		return nil, location
@@ -89,29 +70,21 @@ func GetCacheDir(profileDir string) (error, string) { // TODO: (Windows) needs t
 // Profile-specific Profile directory
 // *Windows: C:\Documents and Settings\<user>\Application Data\Mozilla\Firefox\Profiles\<profile>
 // %APPDATA%\Mozilla\Firefox\Profiles\7wdvp18g.default
-func GetDataDir(profileDir string) (error, string) { // TODO: (Windows) needs to be verified!
-	appDataRoaming, err1 := os.UserConfigDir()
-	if err1 != nil {
-		return err1, ""
-	}
-	appDataLocal, err2 := os.UserCacheDir()
-	if err2 != nil {
-		return err2, ""
-	}
-
+func GetDataDir(env *cmn.Environment, profileDir string) (error, string) { // TODO: (Windows) needs to be verified!
 	subPath := filepath.Join("Mozilla", "Firefox", "Profiles", profileDir)
-	if err, location := locate([]string{appDataRoaming, appDataLocal}, subPath, "DATA"); err != nil {
+	if err, location := locate(env, subPath, "DATA"); err != nil {
 		return err, ""
 	} else {
 		return nil, location
 	}
 }
 
-// attempt to find subPath in any of alternatives.
-func locate(alternatives []string, subPath, prefix string) (error, string) {
+// attempt to find subPath in %AppData% (Roaming) or %LocalAppData%
+func locate(env *cmn.Environment, subPath, prefix string) (error, string) {
+	alternatives := []string{env.ConfigHome, env.CacheHome}
 	for _, lpath := range alternatives {
 		name := filepath.Join(lpath, subPath)
-		if cmn.IsDirectory(name) {
+		if env.IsDirectory(name) {
 			return nil, name
 		}
 	}
@@ -75,7 +75,11 @@ func AddThousands(nr int64, sep rune) string {
 			}
 		} else {
 			group := nrS[start:]
-			result = result + string(sep) + group
+			if start != 0 {
+				result = result + string(sep) + group
+			} else {
+				result = group
+			}
 			break
 		}
 	}
@@ -0,0 +1,160 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Categories of browser data (cache, cookies, extensions, etc.)
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	CategoryOther          Category = iota // anything we don't recognize
+	CategoryCache                          // HTTP, code, GPU & shader caches
+	CategorySiteStorage                    // Local/Session storage, IndexedDB...
+	CategoryCookies                        // cookie jars
+	CategoryHistory                        // history, visited links, favicons
+	CategorySessions                       // open tabs & windows
+	CategoryExtensions                     // extension code & their data
+	CategoryServiceWorkers                 // service worker scripts & caches
+	CategoryCredentials                    // saved logins, autofill, keys
+	CategorySettings                       // preferences & browser state
+	CategoryBookmarks                      // bookmarks & their backups
+	CategoryWebApps                        // installed PWAs & their file systems
+	CategoryCrashDumps                     // crash reports & minidumps
+	CategoryLogs                           // LOG files of the various databases
+)
+
+const (
+	// a profile's data/settings directory
+	RootProfile RootKind = iota
+	// a profile's cache directory
+	RootCache
+)
+
+var (
+	categoryNames = []string{
+		"other",
+		"cache",
+		"site-storage",
+		"cookies",
+		"history",
+		"sessions",
+		"extensions",
+		"service-workers",
+		"credentials",
+		"settings",
+		"bookmarks",
+		"web-apps",
+		"crash-dumps",
+		"logs",
+	}
+
+	ErrUnknownCategory = errors.New("Unknown data category")
+)
+
+/* ----------------------------------------------------------------
+ *						I n t e r f a c e s
+ *-----------------------------------------------------------------*/
+
+// Each browser knows what its files are for and what the cleaner would
+// do with them.
+type IClassifier interface {
+	// Classify a file or directory given its path relative to a root
+	// of the given kind. Returns its category, whether the current
+	// rules would delete or keep it and the rule that decides it.
+	Classify(kind RootKind, relPath string, isDir bool) (Category, ItemAction, string)
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+type Category uint
+
+// What sort of directory a path is relative to
+type RootKind uint
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Stringer interface
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+	return ""
+}
+
+// Categories are (un)marshalled by name, also as JSON map keys
+func (c Category) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Category) UnmarshalText(text []byte) error {
+	parsed, err := ParseCategory(string(text))
+	*c = parsed
+	return err
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// All known categories
+func Categories() []Category {
+	result := make([]Category, 0, len(categoryNames))
+	for i := range categoryNames {
+		result = append(result, Category(i))
+	}
+	return result
+}
+
+// The names in a table of top-level profile items that are in one of
+// the given categories, sorted. Added to a cleaner's exceptions they keep
+// that data, i.e. the cookies.
+func NamesOf(table map[string]Category, categories ...Category) []string {
+	names := make([]string, 0)
+	for name, category := range table {
+		if slices.Contains(categories, category) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse a list of category names
+func ParseCategories(names []string) ([]Category, error) {
+	categories := make([]Category, 0, len(names))
+	for _, name := range names {
+		category, err := ParseCategory(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", err, name)
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// Parse a category name (case-insensitive)
+func ParseCategory(name string) (Category, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, n := range categoryNames {
+		if n == name {
+			return Category(i), nil
+		}
+	}
+	return CategoryOther, ErrUnknownCategory
+}
@@ -0,0 +1,226 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * --all-users: the browsers of every user of the machine, as root
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+	"github.com/lordofscripts/wipechromium/browsers/firefox"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_ALLUSERS string = "Every user with a login (as root), a report per user"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A profile of a user to wipe with its own settings & policy
+type userTarget struct {
+	browser browsers.Browser
+	profile string
+	cfg     *cmn.Config
+	pol     *cmn.ProfilePolicy
+	// bad settings or against the policy, not wiped
+	refused bool
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The login users of the machine for --all-users, which only root can
+// do & which has no single home, report or plan
+func allUsers(cmd *cobra.Command, o *wipeOptions) []*cmn.LoginUser {
+	for _, name := range []string{"home", "report", "save"} {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			die(cmn.ExitUsage, "--%s is per user, it can't go with --all-users", name)
+		}
+	}
+	// the metrics are of one profile's sizes after its wipe
+	if f := cmd.Flags().Lookup("metrics-file"); f != nil && f.Changed {
+		die(cmn.ExitUsage, "--metrics-file is per profile, it can't go with --all-users")
+	}
+	if os.Geteuid() != 0 {
+		die(cmn.ExitNotRoot, cmn.ErrNotRoot.Error())
+	}
+	users, err := cmn.LoginUsers(cmn.PasswdFile)
+	if err != nil {
+		die(cmn.ExitUsage, "%s: %s", cmn.PasswdFile, err)
+	}
+	return users
+}
+
+// The browsers of an --all-users run: the one given with -b or all
+func allUsersBrowsers(cmd *cobra.Command, browser browsers.Browser) []browsers.Browser {
+	if f := cmd.Flags().Lookup("browser"); f != nil && f.Changed {
+		return []browsers.Browser{browser}
+	}
+	return browsers.SupportedBrowsers
+}
+
+// Have an --all-users wipe confirmed on the terminal: there is no plan
+// of it all, the users & profiles it is about are listed instead.
+func confirmAllUsers(users []*cmn.LoginUser, targets []browsers.Browser, profile string) {
+	if !isTerminal(os.Stdin) {
+		die(cmn.ExitNotConfirmed, "%s, not run from a terminal: give --yes to wipe anyway", cmn.ErrNotConfirmed)
+	}
+	names := make([]string, 0, len(targets))
+	for _, browser := range targets {
+		names = append(names, browser.String())
+	}
+	which := "every profile"
+	if len(profile) != 0 {
+		which = fmt.Sprintf("the %q profile", profile)
+	}
+	fmt.Fprintf(os.Stderr, "About to wipe %s of %s of these %d users:\n", which, strings.Join(names, " & "), len(users))
+	for _, u := range users {
+		fmt.Fprintf(os.Stderr, "  %s\n", u)
+	}
+	fmt.Fprint(os.Stderr, `Type "yes" to wipe them: `)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if !strings.EqualFold(strings.TrimSpace(answer), CONFIRM_YES) {
+		die(cmn.ExitNotConfirmed, "%s, nothing was removed", cmn.ErrNotConfirmed)
+	}
+}
+
+// Scan or wipe the browsers of every user, each as that user: the disk
+// work is done with the user's uid & gid, the config, policy & history
+// (root's files) are read & written as root in between.
+func runAllUsers(ctx context.Context, cmd *cobra.Command, o *wipeOptions, users []*cmn.LoginUser,
+	which []browsers.Browser, sizeMode cmn.SizeMode, dryRun, history bool) *cmn.UsersReport {
+	report := &cmn.UsersReport{Users: make([]*cmn.UserRun, 0, len(users))}
+	quiet := cmn.NewRenderer(cmn.OutputHuman, io.Discard, sizeMode)
+	for _, u := range users {
+		run := &cmn.UserRun{LoginUser: u}
+		report.Users = append(report.Users, run)
+		if err := ctx.Err(); err != nil {
+			run.Fail(cmn.ExitInterrupted, err)
+			continue
+		}
+		env, err := cmn.NewEnvironment(u.Home)
+		if err != nil {
+			run.Fail(cmn.ExitCleanerFailure, err)
+			continue
+		}
+		runner := &BrowserWipe{Env: env, SizeMode: sizeMode, out: quiet}
+
+		// (a) as the user: what there is
+		var targets []*userTarget
+		err = cmn.AsUser(u, func() error {
+			if o.scanOnly {
+				run.Scan = runner.ScanReport()
+				return nil
+			}
+			for _, browser := range which {
+				profiles := []string{o.profile}
+				if len(o.profile) == 0 {
+					if !hasBrowser(env, browser) {
+						continue // not a user of that browser
+					}
+					names, err := runner.ProfileNames(browser)
+					if errors.Is(err, browsers.ErrNoProfilesFound) {
+						continue
+					} else if err != nil {
+						run.Fail(cmn.ExitCleanerFailure, fmt.Errorf("%s: %w", browser, err))
+						continue
+					}
+					profiles = names
+				}
+				for _, profile := range profiles {
+					targets = append(targets, &userTarget{browser: browser, profile: profile})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			run.Fail(cmn.ExitCleanerFailure, err)
+			continue
+		}
+
+		// (b) as root: the settings & policy of each profile
+		for _, target := range targets {
+			if target.cfg, err = readConfig(u.Home, o.configFile, target.browser, target.profile, cmd.Flags()); err == nil {
+				target.pol, err = readPolicy(u.Name, target.browser, target.profile)
+			}
+			if err != nil {
+				run.Fail(cmn.ExitBadConfig, fmt.Errorf("%s %q: %w", target.browser, target.profile, err))
+				target.refused = true
+			} else if conflicts := target.pol.Conflicts(target.cfg); len(conflicts) != 0 {
+				run.Fail(cmn.ExitPolicy, cmn.PolicyError(conflicts))
+				target.refused = true
+			}
+		}
+
+		// (c) as the user: the wipes
+		codes := make([]int, 0, len(targets))
+		err = cmn.AsUser(u, func() error {
+			for _, target := range targets {
+				if target.refused {
+					continue
+				}
+				if err := runner.GetCleaner(target.browser, target.profile, false, sizeMode, dryRun); err != nil {
+					run.Fail(cmn.ExitCode(err, cmn.ExitCleanerFailure), fmt.Errorf("%s %q: %w", target.browser, target.profile, err))
+					continue
+				}
+				runner.Configure(target.cfg, target.pol)
+				code, err := runner.Run(ctx, target.cfg.Bool("cache"), target.cfg.Bool("profile"))
+				if report := runner.cleaner.Report(); report != nil {
+					run.Wipes = append(run.Wipes, report)
+					codes = append(codes, code)
+				}
+				if err != nil {
+					run.Fail(code, fmt.Errorf("%s %q: %w", target.browser, target.profile, err))
+					if cmn.IsInterruption(err) {
+						return nil
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			run.Fail(cmn.ExitCleanerFailure, err)
+		}
+
+		// (d) as root: the history, root's as it is root's run
+		if history {
+			recorder := &BrowserWipe{HistoryFile: cmn.DefaultHistoryFile()}
+			for i, wipe := range run.Wipes {
+				recorder.RecordRun(wipe, codes[i])
+			}
+		}
+	}
+	return report
+}
+
+// Whether the user has the browser at all: its data root
+func hasBrowser(env *cmn.Environment, browser browsers.Browser) bool {
+	switch browser {
+	case browsers.ChromiumBrowser:
+		return env.IsDirectory(chromium.GetDataDir(env))
+	case browsers.FirefoxBrowser:
+		err, root := firefox.GetRootDataDir(env)
+		return err == nil && env.IsDirectory(root)
+	}
+	return false
+}
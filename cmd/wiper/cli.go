@@ -0,0 +1,201 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The command tree, shell completion & man pages
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"github.com/spf13/pflag"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	RECIPIENT = "lostinwriting"
+
+	FLAG_HELP_MANDIR string = "Directory of the generated man pages"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The whole wiper command tree. Without a subcommand it is 'wipe', as
+// it was before there were subcommands.
+func newRootCmd() *cobra.Command {
+	o := newWipeOptions()
+	root := &cobra.Command{
+		Use:   "wiper",
+		Short: cmn.DESC,
+		Long: "Wipes the cache & the private data (cookies, history, sessions...) of\n" +
+			"Chromium & Firefox profiles, keeping the settings, bookmarks & extensions.",
+		Example: "  wiper scan\n" +
+			"  wiper wipe -b Chromium -n 'Profile 1'\n" +
+			"  wiper plan -b Firefox -n default-release --save wipe.plan && wiper apply wipe.plan\n" +
+			"  wiper du -b Chromium -n 'Profile 1'",
+		Version:       cmn.Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().NFlag() == 0 {
+				cmd.Help()
+				exit(cmn.ExitUsage)
+			}
+			exit(wipe(cmd, o))
+		},
+	}
+	// the old flags of the wipe, they are in 'wiper wipe --help'
+	addWipeFlags(root, o, true)
+	root.Flags().BoolVarP(&o.scanOnly, "scan", "s", false, FLAG_HELP_SCAN)
+	root.Flags().VisitAll(func(f *pflag.Flag) { f.Hidden = true })
+	root.PersistentFlags().StringVar(&homeDir, "home", "", FLAG_HELP_HOME)
+	root.MarkPersistentFlagDirname("home")
+
+	root.AddCommand(newScanCmd(), newWipeCmd(), newPlanCmd(), newApplyCmd(), newRestoreCmd(),
+		newDiskUsageCmd(), newConfigCmd(), newStatsCmd(), newPolicyCmd(), newScheduleCmd(),
+		newDaemonCmd(), newTuiCmd(), newVersionCmd(), newManCmd(), newExitCodesTopic())
+
+	root.SetVersionTemplate("{{.Version}}\n")
+
+	defaultHelp := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		cmn.Copyright(cmn.CO1, true)
+		defaultHelp(cmd, args)
+		if cmd == root {
+			cmn.BuyMeCoffee(RECIPIENT)
+		}
+	})
+	return root
+}
+
+// The -b & -n flags of a command, completing the browser names & the
+// profiles of the chosen browser
+func addTargetFlags(cmd *cobra.Command, browserName, profile *string, browserDefault, profileHelp string) {
+	cmd.Flags().StringVarP(browserName, "browser", "b", browserDefault, FLAG_HELP_BROWSER)
+	cmd.Flags().StringVarP(profile, "name", "n", "", profileHelp)
+	cmd.RegisterFlagCompletionFunc("browser", completeBrowsers)
+	cmd.RegisterFlagCompletionFunc("name", completeProfiles)
+}
+
+func completeBrowsers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := make([]string, 0, len(browsers.SupportedBrowsers))
+	for _, browser := range browsers.SupportedBrowsers {
+		names = append(names, browser.String())
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// the live profiles of the browser given with -b (or its default)
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	browserName, _ := cmd.Flags().GetString("browser")
+	if len(browserName) == 0 {
+		browserName = browsers.ChromiumBrowser.String()
+	}
+	browser, ok := parseBrowser(browserName)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	runner := &BrowserWipe{SizeMode: cmn.SizeModeStd, out: out}
+	names, err := runner.ProfileNames(browser)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show the version of wiper",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(cmn.Version)
+		},
+	}
+}
+
+// Hidden: the man pages are generated at packaging time
+func newManCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "man [DIR]",
+		Short:  "Generate the man pages of wiper & its subcommands in DIR",
+		Hidden: true,
+		Args:   cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := "."
+			if len(args) != 0 {
+				dir = args[0]
+			}
+			root := cmd.Root()
+			root.DisableAutoGenTag = true
+			date := time.Date(2024, time.September, 18, 0, 0, 0, 0, time.UTC)
+			header := &doc.GenManHeader{Title: "WIPER", Section: "1", Date: &date, Source: cmn.Version}
+			if err := doc.GenManTree(root, header, dir); err != nil {
+				die(cmn.ExitUsage, "%s: %s", FLAG_HELP_MANDIR, err)
+			}
+		},
+	}
+}
+
+// 'wiper help exit-codes' is a help topic: a command without Run
+func newExitCodesTopic() *cobra.Command {
+	var buf bytes.Buffer
+	cmn.WriteExitCodes(&buf)
+	return &cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes & what to do about them",
+		Long:  "Exit codes of wiper (stable, safe to script against):\n" + buf.String(),
+	}
+}
+
+// Before there were subcommands the long options had a single dash,
+// i.e. -progress=false. The installed units & scripts still use them.
+func legacyArgs(root *cobra.Command, args []string) []string {
+	long := map[string]bool{"help": true, "version": true, "dry": true}
+	var collect func(cmd *cobra.Command)
+	collect = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) { long[f.Name] = true })
+		cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { long[f.Name] = true })
+		for _, sub := range cmd.Commands() {
+			collect(sub)
+		}
+	}
+	collect(root)
+
+	result := make([]string, len(args))
+	for i, arg := range args {
+		result[i] = arg
+		if arg == "--" {
+			copy(result[i:], args[i:])
+			break
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(name) > 1 && long[name] {
+			result[i] = "-" + arg
+		}
+	}
+	return result
+}
+
+// Exit unless it all went well
+func exit(code int) {
+	if code != cmn.ExitOK {
+		os.Exit(code)
+	}
+}
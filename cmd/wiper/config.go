@@ -0,0 +1,161 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper config: the settings as per the config files, env & flags
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_UCONFIG string = "User config file"
+	FLAG_HELP_SETS    string = "Preset of settings (quick, standard, profile, paranoid or a [preset.NAME])"
+	FLAG_HELP_KEEP_C  string = "Data categories to keep, i.e. cookies,history"
+	FLAG_HELP_BACKUP  string = "Move what is removed from the profile to DIR instead of deleting it"
+)
+
+// The config setting of each flag that has one. -c & -p are the
+// cache & profile settings together.
+var flagSettings = map[string]string{
+	"preset":       "preset",
+	"keep":         "keep",
+	"backup-dir":   "backup_dir",
+	"shred":        "shred",
+	"dry-run":      "dry_run",
+	"keep-going":   "keep_going",
+	"size":         "size",
+	"output":       "output",
+	"jobs":         "jobs",
+	"timeout":      "timeout",
+	"progress":     "progress",
+	"history":      "history",
+	"metrics-file": "metrics_file",
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newConfigCmd() *cobra.Command {
+	o := newWipeOptions()
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the settings of a profile & where each one comes from",
+	}
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "The effective settings of a profile & where each one comes from",
+		Long: "Precedence: flags > WIPER_* env > user config > system config (" + cmn.SystemConfigFile + ")\n" +
+			"and in each file: [profile.\"BROWSER/PROFILE\"] > [browser.BROWSER] > [defaults].\n" +
+			"It takes the flags of wiper wipe, to see what they would change.",
+		Example: "  wiper config show -b Chromium -n Work\n" +
+			"  wiper config show -b Chromium -n Work --preset paranoid --keep cookies",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(configShow(o.browserName, o.profile, o.configFile, cmd.Flags()))
+		},
+	}
+	addWipeFlags(show, o, true)
+	cmd.AddCommand(show)
+	return cmd
+}
+
+// 'wiper config show'. The flags given in fs override the rest, as they
+// would on a wipe. Returns the exit code.
+func configShow(browserName, profile, configFile string, fs *pflag.FlagSet) int {
+	browser, ok := parseBrowser(browserName)
+	if !ok {
+		die(cmn.ExitBadBrowser, "Not a supported browser %q", browserName)
+	}
+	cfg := loadConfig(configFile, browser, profile, fs)
+	cmn.WriteConfig(os.Stdout, cfg)
+
+	// the policy may overrule some of it
+	pol := profilePolicy(browser, profile)
+	if pol == nil {
+		return cmn.ExitOK
+	}
+	fmt.Println()
+	cmn.WriteProfilePolicy(os.Stdout, pol)
+	for _, c := range pol.Conflicts(cfg) {
+		fmt.Printf("  conflict     : %s\n", c)
+	}
+	return cmn.ExitOK
+}
+
+// The configuration of a profile: the system & user config files, the
+// WIPER_* variables and the flags explicitly given in the flag set (if
+// any), in that order. ~/ is the --home, if given. Dies if any of them
+// is wrong.
+func loadConfig(configFile string, browser browsers.Browser, profile string, fs *pflag.FlagSet) *cmn.Config {
+	cfg, err := readConfig(homeDir, configFile, browser, profile, fs)
+	if err != nil {
+		die(cmn.ExitBadConfig, err.Error())
+	}
+	return cfg
+}
+
+// The settings of a profile in a home (see loadConfig) or why they are
+// no good
+func readConfig(home, configFile string, browser browsers.Browser, profile string, fs *pflag.FlagSet) (*cmn.Config, error) {
+	cfg, err := cmn.LoadConfigAt(home, browser.String(), profile, configFile)
+	if err == nil {
+		err = cfg.ApplyEnv(os.LookupEnv)
+	}
+	if err == nil && fs != nil {
+		err = applyFlags(cfg, fs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// the flags given on the command line override everything else, the
+// preset comes first so that the other flags override its settings
+func applyFlags(cfg *cmn.Config, fs *pflag.FlagSet) error {
+	given := make(map[string]*pflag.Flag)
+	fs.Visit(func(f *pflag.Flag) { given[f.Name] = f })
+
+	if f, ok := given["preset"]; ok {
+		if err := cfg.Set("preset", f.Value.String(), "flag --preset"); err != nil {
+			return err
+		}
+	}
+	for name, f := range given {
+		if setting, ok := flagSettings[name]; ok && setting != "preset" {
+			if err := cfg.Set(setting, f.Value.String(), "flag --"+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	// -c is cache only, -p profile only, both (or neither) is everything
+	cacheOnly, profileOnly := flagBool(given, "cache"), flagBool(given, "profile")
+	if cacheOnly || profileOnly {
+		cfg.Set("cache", strconv.FormatBool(cacheOnly || !profileOnly), "flag -c/-p")
+		cfg.Set("profile", strconv.FormatBool(profileOnly || !cacheOnly), "flag -c/-p")
+	}
+	return nil
+}
+
+// whether the bool flag was given as true
+func flagBool(given map[string]*pflag.Flag, name string) bool {
+	f, ok := given[name]
+	return ok && f.Value.String() == "true"
+}
@@ -0,0 +1,85 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Asking before a wipe: what it would remove & a typed confirmation
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_YES string = "Wipe without asking (required when not run from a terminal)"
+
+	// what the "confirm" setting asks to be typed
+	CONFIRM_YES  = "yes"
+	CONFIRM_NAME = "name"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Show what the wipe would remove and have it confirmed on the terminal.
+// It is the plan being applied, if any, else a dry run with the same
+// settings. Returns the confirmed plan, the wipe removes nothing else.
+// Dies if it isn't confirmed or there is no terminal to ask on.
+func confirmWipe(browser browsers.Browser, profile string, cfg *cmn.Config, pol *cmn.ProfilePolicy,
+	plan *cmn.WipeReport, sizeMode cmn.SizeMode, cacheOnly, profileOnly bool) *cmn.WipeReport {
+	// (a) the challenge: the name when nothing can be undone
+	challenge := cfg.Value("confirm")
+	if challenge != CONFIRM_YES && challenge != CONFIRM_NAME {
+		die(cmn.ExitBadConfig, "%s: confirm is %q, not %s or %s", cmn.ErrBadConfig, challenge, CONFIRM_YES, CONFIRM_NAME)
+	}
+	backupDir, err := pol.BackupTarget(cfg.Value("backup_dir"))
+	if err != nil {
+		die(cmn.ExitPolicy, err.Error())
+	}
+	if cfg.NameChallenge(pol) {
+		challenge = CONFIRM_NAME
+	}
+	if !isTerminal(os.Stdin) {
+		die(cmn.ExitNotConfirmed, "%s, not run from a terminal: give --yes to wipe anyway", cmn.ErrNotConfirmed)
+	}
+
+	// (b) what it would remove
+	if plan == nil {
+		runner := &BrowserWipe{SizeMode: sizeMode, out: cmn.NewRenderer(cmn.OutputHuman, io.Discard, sizeMode)}
+		if err := runner.GetCleaner(browser, profile, false, sizeMode, true); err != nil {
+			die(cmn.ExitCode(err, cmn.ExitCleanerFailure), err.Error())
+		}
+		runner.Configure(cfg, pol)
+		if code, err := runner.Run(context.Background(), cacheOnly, profileOnly); err != nil {
+			die(code, err.Error())
+		}
+		plan = runner.cleaner.Report()
+	}
+	cmn.WritePlanSummary(os.Stderr, plan, backupDir, sizeMode)
+
+	// (c) typed, not just y
+	expected, prompt := CONFIRM_YES, `Type "yes" to wipe it: `
+	if challenge == CONFIRM_NAME {
+		expected, prompt = profile, fmt.Sprintf("Type the profile name (%s) to wipe it: ", profile)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer != expected && !(challenge == CONFIRM_YES && strings.EqualFold(answer, expected)) {
+		die(cmn.ExitNotConfirmed, "%s, nothing was removed", cmn.ErrNotConfirmed)
+	}
+	return plan
+}
@@ -0,0 +1,180 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper daemon: the jobs of the config file on their own schedule
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_CONFIG string = "Config file with the settings & the [[job]] entries"
+	FLAG_HELP_STATE  string = "State file of the daemon"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newDaemonCmd() *cobra.Command {
+	var configFile, stateFile, metricsFile string
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the jobs of the config file on their own schedules",
+		Long: "Runs the jobs of the config file on their schedules (SIGHUP reloads it).\n" +
+			"A job of the config file:\n\n" +
+			"  [[job]]\n" +
+			"  browser  = \"Chromium\"\n" +
+			"  profile  = \"Profile 1\"\n" +
+			"  schedule = \"0 */4 * * *\"   # cron, @daily or @every 6h\n" +
+			"  preset   = \"quick\"         # quick, standard, profile",
+	}
+	run := &cobra.Command{
+		Use:   "run",
+		Short: "Run the jobs of the config file",
+		Example: "  wiper daemon run --config ~/.config/wiper/config.toml\n" +
+			"  wiper daemon run --metrics-file /var/lib/node_exporter/textfile/wiper.prom",
+		Args: cobra.NoArgs,
+	}
+	run.Flags().StringVar(&configFile, "config", cmn.DefaultConfigFile(), FLAG_HELP_CONFIG)
+	run.Flags().StringVar(&stateFile, "state", cmn.DefaultDaemonStateFile(), FLAG_HELP_STATE)
+	run.Flags().StringVar(&metricsFile, "metrics-file", "", FLAG_HELP_METRICS)
+	logFlags := NewLogFlags(run.Flags(), true)
+	run.Run = func(cmd *cobra.Command, args []string) {
+		exit(daemonRun(configFile, stateFile, metricsFile, logFlags))
+	}
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "How are the jobs doing?",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(daemonStatus(stateFile))
+		},
+	}
+	status.Flags().StringVar(&stateFile, "state", cmn.DefaultDaemonStateFile(), FLAG_HELP_STATE)
+	cmd.AddCommand(run, status)
+	return cmd
+}
+
+func daemonRun(configFile, stateFile, metricsFile string, logFlags *LogFlags) int {
+	var logFile io.Closer
+	logx, logFile = logFlags.Setup("Main")
+	defer logFile.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	d := cmn.NewDaemon(configFile, stateFile, jobRunner(configFile, metricsFile), logx)
+	if err := d.Serve(ctx, reload); err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	return cmn.ExitOK
+}
+
+func daemonStatus(stateFile string) int {
+	state, err := cmn.ReadDaemonState(stateFile)
+	if cmn.IsNoState(err) {
+		fmt.Printf("The daemon never ran (no %s)\n", stateFile)
+		return cmn.ExitOK
+	} else if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	cmn.WriteDaemonStatus(os.Stdout, state)
+	return cmn.ExitOK
+}
+
+// A daemon job is a plain wipe with the settings of the job's profile
+// in the config file & the options of the job's preset. Its report is
+// not shown, the log, the run history & the metrics (if a file is
+// given) have it.
+func jobRunner(configFile, metricsFile string) cmn.JobRunner {
+	return func(ctx context.Context, job *cmn.DaemonJob) *cmn.JobResult {
+		return runJob(ctx, job, configFile, metricsFile)
+	}
+}
+
+func runJob(ctx context.Context, job *cmn.DaemonJob, configFile, metricsFile string) *cmn.JobResult {
+	browser, ok := parseBrowser(job.Browser)
+	if !ok {
+		return &cmn.JobResult{Code: cmn.ExitBadBrowser, Err: cmn.ErrUnsupportedBrowser}
+	}
+	cfg, err := jobConfig(job, configFile)
+	if err != nil {
+		return &cmn.JobResult{Code: cmn.ExitBadConfig, Err: err}
+	}
+	runner := &BrowserWipe{SizeMode: cmn.SizeModeStd, HistoryFile: cmn.DefaultHistoryFile(), MetricsFile: metricsFile,
+		out: cmn.NewRenderer(cmn.OutputHuman, io.Discard, cmn.SizeModeStd)}
+	if !cfg.Bool("history") {
+		runner.HistoryFile = ""
+	}
+	if len(metricsFile) == 0 {
+		runner.MetricsFile = cfg.Value("metrics_file")
+	}
+	if err := runner.GetCleaner(browser, job.Profile, false, runner.SizeMode, cfg.Bool("dry_run")); err != nil {
+		return &cmn.JobResult{Code: cmn.ExitCode(err, cmn.ExitCleanerFailure), Err: err}
+	}
+	// a job that goes against the policy fails, one that runs less often
+	// than it wants is only worth a warning: running it is still good
+	pol, err := cmn.LoadPolicy(cmn.SystemPolicyFile)
+	if err != nil {
+		return &cmn.JobResult{Code: cmn.ExitBadConfig, Err: err}
+	}
+	profilePol := pol.For(currentUser(), job.Browser, job.Profile)
+	if conflicts := profilePol.Conflicts(cfg); len(conflicts) != 0 {
+		return &cmn.JobResult{Code: cmn.ExitPolicy, Err: cmn.PolicyError(conflicts)}
+	}
+	if cron, err := cmn.ParseCron(job.Schedule); err == nil {
+		if c := profilePol.CheckSchedule("job "+job.Name, cron, time.Now()); c != nil {
+			logx.Warn("job schedule against the policy", "job", job.Name, cmn.LogKeyErr, c.String())
+		}
+	}
+	runner.Configure(cfg, profilePol)
+
+	result := &cmn.JobResult{}
+	result.Code, result.Err = runner.Run(ctx, cfg.Bool("cache"), cfg.Bool("profile"))
+	if report := runner.cleaner.Report(); report != nil {
+		result.Bytes, result.Items = report.TotalBytes, report.TotalItems
+	}
+	return result
+}
+
+// The configuration of the job's profile, with the job's own options on
+// top. The config file is read at each run so that it can be edited
+// without reloading the daemon.
+func jobConfig(job *cmn.DaemonJob, configFile string) (*cmn.Config, error) {
+	cfg, err := cmn.LoadConfig(job.Browser, job.Profile, configFile)
+	if err == nil {
+		err = cfg.ApplyEnv(os.LookupEnv)
+	}
+	source := "job " + job.Name
+	if err == nil && len(job.Preset) != 0 {
+		err = cfg.Set("preset", job.Preset, source)
+	}
+	if err == nil && job.DryRun {
+		err = cfg.Set("dry_run", "true", source)
+	}
+	if err == nil {
+		err = cfg.Set("keep_going", strconv.FormatBool(!job.StopOnError), source)
+	}
+	return cfg, err
+}
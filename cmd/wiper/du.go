@@ -0,0 +1,100 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper du: where did all that profile space go?
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_DEPTH string = "Directory levels to expand (0 is unlimited)"
+	FLAG_HELP_TOP   string = "Show only the N largest entries per directory (0 is all)"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newDiskUsageCmd() *cobra.Command {
+	var profile, browserName, szmodeS, outputS string
+	var depth, top, jobs int
+	cmd := &cobra.Command{
+		Use:   "du",
+		Short: "Disk usage of a browser profile by category & verdict",
+		Long:  "Where did the space go? A du-style tree labeled with what a wipe would do.",
+		Example: "  wiper du -b Chromium -n 'Profile 1' --depth 3 --top 5\n" +
+			"  wiper du -b Firefox",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(diskUsage(browserName, profile, szmodeS, outputS, depth, top, jobs))
+		},
+	}
+	addTargetFlags(cmd, &browserName, &profile, browsers.ChromiumBrowser.String(), FLAG_HELP_NAME+" (all if not given)")
+	cmd.Flags().IntVar(&depth, "depth", 2, FLAG_HELP_DEPTH)
+	cmd.Flags().IntVar(&top, "top", 10, FLAG_HELP_TOP)
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, FLAG_HELP_JOBS)
+	cmd.Flags().StringVarP(&szmodeS, "size", "z", "Std", FLAG_HELP_SIZE)
+	cmd.Flags().StringVarP(&outputS, "output", "o", "human", FLAG_HELP_OUTPUT)
+	return cmd
+}
+
+// The 'du' subcommand. Returns the exit code.
+func diskUsage(browserName, profile, szmodeS, outputS string, depth, top, jobs int) int {
+	browser, ok := parseBrowser(browserName)
+	if !ok {
+		die(cmn.ExitBadBrowser, "Not a supported browser %q", browserName)
+	}
+	sizeMode, ok := parseSizeMode(szmodeS)
+	if !ok {
+		die(cmn.ExitBadSizeMode, "%s: %q", cmn.ErrBadSizeMode, szmodeS)
+	}
+	outFormat, err := cmn.ParseOutputFormat(outputS)
+	if err != nil {
+		die(cmn.ExitBadOutput, "%s: %q", err, outputS)
+	}
+	out = cmn.NewRenderer(outFormat, os.Stdout, sizeMode)
+	if depth < 0 || top < 0 || jobs < 0 {
+		die(cmn.ExitUsage, "Depth, top & jobs must not be negative")
+	}
+	cmn.WalkerJobs = jobs
+
+	logx = cmn.NewConditionalLogger(false, "DiskUsage")
+
+	runner := &BrowserWipe{SizeMode: sizeMode, out: out}
+
+	// (a) which profiles?
+	profiles := []string{profile}
+	if len(profile) == 0 {
+		if profiles, err = runner.ProfileNames(browser); err != nil {
+			die(cmn.ExitCleanerFailure, err.Error())
+		}
+	}
+
+	// (b) analyze each of them
+	for _, name := range profiles {
+		if err := runner.GetCleaner(browser, name, false, sizeMode, true); err != nil {
+			die(cmn.ExitCode(err, cmn.ExitCleanerFailure), "%s: %q", err, name)
+		}
+		runner.cleaner.SetPolicy(profilePolicy(browser, name))
+		report, err := runner.cleaner.AnalyzeDiskUsage(depth, top)
+		if err != nil {
+			die(cmn.ExitCode(err, cmn.ExitCleanerFailure), "%s: %q", err, name)
+		}
+		out.DiskUsage(report)
+	}
+
+	return 0
+}
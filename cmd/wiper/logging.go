@@ -0,0 +1,72 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The logging options shared by the wipe & the subcommands
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/pflag"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+type LogFlags struct {
+	enabled bool
+	level   string
+	opts    *cmn.LogOptions
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// Add --log, --log-level, --log-format, --log-file, --log-sink,
+// --log-max-size and --log-backups to the flag set. Logging is on by
+// default if enabled.
+func NewLogFlags(fs *pflag.FlagSet, enabled bool) *LogFlags {
+	l := &LogFlags{opts: cmn.NewLogOptions()}
+	fs.BoolVar(&l.enabled, "log", enabled, FLAG_HELP_LOG)
+	fs.StringVar(&l.level, "log-level", "info", FLAG_HELP_LLEVEL)
+	fs.StringVar(&l.opts.Format, "log-format", cmn.LogFormatText, FLAG_HELP_LFORMAT)
+	fs.StringVar(&l.opts.File, "log-file", "", FLAG_HELP_LFILE)
+	fs.StringVar(&l.opts.Sink, "log-sink", cmn.LogSinkStderr, FLAG_HELP_LSINK)
+	fs.IntVar(&l.opts.MaxSize, "log-max-size", cmn.DefaultLogMaxSize, FLAG_HELP_LSIZE)
+	fs.IntVar(&l.opts.MaxBackups, "log-backups", cmn.DefaultLogMaxBackups, FLAG_HELP_LBACKUP)
+	return l
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Whether logging is on: --log, or a log file or sink was given
+func (l *LogFlags) Enabled() bool {
+	return l.enabled || l.opts.File != "" || l.opts.Sink != cmn.LogSinkStderr
+}
+
+// Set up the default logger as per the flags & return the component's
+// logger. The closer must be closed when done. Dies on bad options.
+func (l *LogFlags) Setup(component string) (*cmn.ConditionalLogger, io.Closer) {
+	var err error
+	if l.opts.Level, err = cmn.ParseLogLevel(l.level); err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	if l.opts.MaxSize < 0 || l.opts.MaxBackups < 0 {
+		die(cmn.ExitUsage, "Log size & backups must not be negative")
+	}
+	var closer io.Closer = io.NopCloser(nil)
+	if l.Enabled() {
+		if closer, err = cmn.SetupLogging(l.opts); err != nil {
+			die(cmn.ExitUsage, "Logging: %s", err)
+		}
+	}
+	return cmn.NewConditionalLogger(l.Enabled(), component), closer
+}
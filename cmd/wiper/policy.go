@@ -0,0 +1,185 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper policy: the system policy & what goes against it
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_PFILE string = "Policy file to check"
+	FLAG_HELP_USER  string = "User whose policy to check"
+	FLAG_HELP_SHRED string = "Overwrite what is removed with zeros before deleting it"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newPolicyCmd() *cobra.Command {
+	var profile, browserName, policyFile, configFile, userName string
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "The system policy & what goes against it",
+	}
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "Check the system policy, and your settings & schedules against it",
+		Long: "Checks the system policy (" + cmn.SystemPolicyFile + "), what it demands of a profile\n" +
+			"and which of its settings, daemon jobs & installed schedules conflict with it.",
+		Example: "  wiper policy check\n" +
+			"  wiper policy check -b Chromium -n Work\n" +
+			"  wiper policy check --file policy.toml",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(policyCheck(browserName, profile, policyFile, configFile, userName, cmd.Flags().Changed("file")))
+		},
+	}
+	addTargetFlags(check, &browserName, &profile, browsers.ChromiumBrowser.String(), FLAG_HELP_NAME)
+	check.Flags().StringVar(&policyFile, "file", cmn.SystemPolicyFile, FLAG_HELP_PFILE)
+	check.Flags().StringVar(&configFile, "config", cmn.DefaultConfigFile(), FLAG_HELP_UCONFIG)
+	check.Flags().StringVar(&userName, "user", currentUser(), FLAG_HELP_USER)
+	cmd.AddCommand(check)
+	return cmd
+}
+
+// 'wiper policy check'. Returns the exit code.
+func policyCheck(browserName, profile, policyFile, configFile, userName string, fileGiven bool) int {
+	browser, ok := parseBrowser(browserName)
+	if !ok {
+		die(cmn.ExitBadBrowser, "Not a supported browser %q", browserName)
+	}
+	pol, err := cmn.LoadPolicy(policyFile)
+	if err != nil {
+		die(cmn.ExitBadConfig, err.Error())
+	}
+	if pol == nil {
+		if fileGiven {
+			die(cmn.ExitBadConfig, "No policy file %s", policyFile)
+		}
+		fmt.Printf("No system policy (%s), anything goes\n", policyFile)
+		return cmn.ExitOK
+	}
+	fmt.Printf("%s: %d rules, OK\n", policyFile, len(pol.Rules))
+	for _, warning := range pol.Overlaps() {
+		fmt.Printf("\tWarning: %s (forbidding wins)\n", warning)
+	}
+
+	// what it means for the user's browser profile (any profile if none)
+	fmt.Printf("\n%s/%s of %s\n", browser, nonEmpty(profile, "*"), userName)
+	profilePol := pol.For(userName, browser.String(), profile)
+	cmn.WriteProfilePolicy(os.Stdout, profilePol)
+
+	var conflicts []*cmn.PolicyConflict
+	if len(profile) != 0 {
+		cfg := loadConfig(configFile, browser, profile, nil)
+		conflicts = profilePol.Conflicts(cfg)
+	}
+	conflicts = append(conflicts, scheduleConflicts(pol, userName, configFile)...)
+	if len(conflicts) == 0 {
+		fmt.Println("\nNo conflicts")
+		return cmn.ExitOK
+	}
+	fmt.Println("\nConflicts:")
+	for _, c := range conflicts {
+		fmt.Printf("\t%s\n", c)
+	}
+	return cmn.ExitPolicy
+}
+
+// The policy of one of the current user's profiles. Dies if the policy
+// file is broken: without it we can't tell what is allowed.
+func profilePolicy(browser browsers.Browser, profile string) *cmn.ProfilePolicy {
+	return userPolicy(currentUser(), browser, profile)
+}
+
+// The policy of a profile of the given user (see profilePolicy)
+func userPolicy(userName string, browser browsers.Browser, profile string) *cmn.ProfilePolicy {
+	pol, err := readPolicy(userName, browser, profile)
+	if err != nil {
+		die(cmn.ExitBadConfig, err.Error())
+	}
+	return pol
+}
+
+// The policy of a profile of the given user or why it is no good
+func readPolicy(userName string, browser browsers.Browser, profile string) (*cmn.ProfilePolicy, error) {
+	pol, err := cmn.LoadPolicy(cmn.SystemPolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	return pol.For(userName, browser.String(), profile), nil
+}
+
+// The daemon jobs & installed schedules that don't run as often as the
+// policy wants
+func scheduleConflicts(pol *cmn.Policy, userName, configFile string) []*cmn.PolicyConflict {
+	var result []*cmn.PolicyConflict
+	now := time.Now()
+	if daemon, err := cmn.LoadDaemonConfig(configFile); err == nil {
+		for _, job := range daemon.Jobs {
+			cron, _ := cmn.ParseCron(job.Schedule)
+			if c := pol.For(userName, job.Browser, job.Profile).CheckSchedule("job "+job.Name, cron, now); c != nil {
+				result = append(result, c)
+			}
+		}
+	}
+	if dir, err := cmn.UserUnitDir(); err == nil {
+		scheds, _ := cmn.ListUnits(dir)
+		for _, sched := range scheds {
+			if c := calendarConflict(pol.For(userName, sched.Browser, ""), "unit "+sched.Name, sched.Schedule, now); c != nil {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+// Whether a systemd calendar event (or "logout") runs as often as the
+// policy wants, nil if it does
+func calendarConflict(pol *cmn.ProfilePolicy, name, calendar string, now time.Time) *cmn.PolicyConflict {
+	if pol == nil || pol.MaxInterval == 0 {
+		return nil
+	}
+	cron, err := cmn.CalendarToCron(calendar)
+	if err != nil {
+		return &cmn.PolicyConflict{Setting: "schedule", Value: calendar, Source: name,
+			Reason: fmt.Sprintf("the policy wants a wipe at least every %s & there is no telling how often it runs", pol.MaxInterval)}
+	}
+	c := pol.CheckSchedule(name, cron, now)
+	if c != nil {
+		c.Value = calendar
+	}
+	return c
+}
+
+// The name of the user running wiper
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func nonEmpty(s, otherwise string) string {
+	if len(s) == 0 {
+		return otherwise
+	}
+	return s
+}
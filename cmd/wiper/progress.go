@@ -0,0 +1,186 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Live progress of a wipe: a bar on a terminal, plain lines otherwise
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	BAR_WIDTH   = 24
+	CLEAR_LINE  = "\r\033[K"
+	MAX_NAMELEN = 32
+)
+
+var _ cmn.IRenderer = (*ProgressRenderer)(nil)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Decorates the human renderer with live progress. On a terminal a
+// single status line (items, bytes & ETA) is redrawn in place, else
+// every item gets a plain line of its own.
+type ProgressRenderer struct {
+	cmn.IRenderer
+	mu       sync.Mutex
+	w        io.Writer
+	tty      bool
+	sizeMode cmn.SizeMode
+	// state of the current phase
+	phase    string
+	started  time.Time
+	current  string
+	done     int
+	total    int
+	bytes    int64 // of the finished items
+	inflight int64 // of the item being removed
+	drawn    bool  // the bar is on screen
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// Show the progress of the events out receives on w. The bar is only
+// drawn if w is a terminal.
+func NewProgressRenderer(out cmn.IRenderer, w *os.File, sizing cmn.SizeMode) *ProgressRenderer {
+	return &ProgressRenderer{IRenderer: out, w: w, tty: isTerminal(w), sizeMode: sizing}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Regular output first removes the bar, the next event redraws it
+func (p *ProgressRenderer) Printf(format string, v ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.IRenderer.Printf(format, v...)
+}
+
+// DryRun notices also go around the bar
+func (p *ProgressRenderer) Writer() io.Writer {
+	return p
+}
+
+// Implements io.Writer
+func (p *ProgressRenderer) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	return p.IRenderer.Writer().Write(b)
+}
+
+// Implements cmn.IObserver
+func (p *ProgressRenderer) Event(ev *cmn.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch ev.Kind {
+	case cmn.EventPhaseStarted:
+		p.clear()
+		p.phase, p.started, p.current = ev.Phase, ev.Time, ""
+		p.done, p.total, p.bytes, p.inflight = 0, 0, 0, 0
+		return
+	case cmn.EventPhaseFinished, cmn.EventRunFinished:
+		p.clear()
+		return
+	case cmn.EventItemStarted:
+		p.current, p.inflight = ev.Path, 0
+		p.total = max(p.total, ev.Total)
+	case cmn.EventProgress:
+		p.inflight = ev.Bytes
+	case cmn.EventItemDeleted, cmn.EventItemSkipped, cmn.EventError:
+		p.current = ev.Path
+		p.done = max(p.done+1, ev.Items)
+		p.total = max(p.total, ev.Total)
+		if ev.Kind == cmn.EventItemDeleted {
+			p.bytes += ev.Bytes
+		}
+		p.inflight = 0
+		if !p.tty {
+			p.line(ev)
+			return
+		}
+	default:
+		return
+	}
+
+	if p.tty {
+		p.draw()
+	}
+}
+
+// redraw the status line: phase [####....] 3/12 items  1.2 MB  ETA 4s  name
+func (p *ProgressRenderer) draw() {
+	var bar, count, eta string
+	if p.total > 0 {
+		filled := BAR_WIDTH * p.done / p.total
+		bar = "[" + strings.Repeat("#", filled) + strings.Repeat(".", BAR_WIDTH-filled) + "] "
+		count = fmt.Sprintf("%d/%d", p.done, p.total)
+		if p.done > 0 && p.done < p.total {
+			left := time.Since(p.started) * time.Duration(p.total-p.done) / time.Duration(p.done)
+			eta = "  ETA " + left.Round(time.Second).String()
+		}
+	} else {
+		count = fmt.Sprintf("%d", p.done)
+	}
+
+	name := filepath.Base(p.current)
+	if len(name) > MAX_NAMELEN {
+		name = name[:MAX_NAMELEN-1] + "…"
+	}
+	fmt.Fprintf(p.w, "%s\t%-10s %s%s items  %s%s  %s", CLEAR_LINE, p.phase, bar, count,
+		cmn.ReportByteCount(p.bytes+p.inflight, p.sizeMode), eta, name)
+	p.drawn = true
+}
+
+// one line per finished item when there is no terminal to draw on
+func (p *ProgressRenderer) line(ev *cmn.Event) {
+	switch ev.Kind {
+	case cmn.EventItemDeleted:
+		fmt.Fprintf(p.w, "\t✘ %s %s\n", ev.Path, cmn.ReportByteCount(ev.Bytes, p.sizeMode))
+	case cmn.EventItemSkipped:
+		fmt.Fprintf(p.w, "\t✔ %s (%s)\n", ev.Path, ev.Rule)
+	case cmn.EventError:
+		fmt.Fprintf(p.w, "\t⚠ %s: %s\n", ev.Path, ev.Message)
+	}
+}
+
+// remove the status line (if any)
+func (p *ProgressRenderer) clear() {
+	if p.drawn {
+		fmt.Fprint(p.w, CLEAR_LINE)
+		p.drawn = false
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Whether the file is a terminal (and not a pipe, regular file nor
+// another character device such as /dev/null)
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
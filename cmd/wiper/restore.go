@@ -0,0 +1,118 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper restore: put a profile backup back in place
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_FROM  string = "Backup to restore, by its date (20240918-093000), the latest if not given"
+	FLAG_HELP_LIST  string = "List the backups of the profile instead of restoring one"
+	FLAG_HELP_OVERW string = "Replace the items the profile has again with those of the backup"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newRestoreCmd() *cobra.Command {
+	var profile, browserName, configFile, backupDir, from string
+	var list, overwrite bool
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Put back what a wipe moved to the backup directory",
+		Long: "Moves the items of a backup (made with --backup-dir or backup_dir in the\n" +
+			"config) back into the profile. The browser must not be running.",
+		Example: "  wiper restore -b Chromium -n 'Profile 1' --list\n" +
+			"  wiper restore -b Chromium -n 'Profile 1' --from 20240918-093000",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			browser, ok := parseBrowser(browserName)
+			if !ok {
+				die(cmn.ExitBadBrowser, "Not a supported browser %q", browserName)
+			}
+			if len(profile) == 0 {
+				die(cmn.ExitNoProfile, "Need profile directory base name")
+			}
+			cfg := loadConfig(configFile, browser, profile, cmd.Flags())
+			exit(restore(browser, profile, cfg, from, list, overwrite))
+		},
+	}
+	addTargetFlags(cmd, &browserName, &profile, browsers.ChromiumBrowser.String(), FLAG_HELP_NAME)
+	cmd.Flags().StringVar(&configFile, "config", cmn.DefaultConfigFile(), FLAG_HELP_UCONFIG)
+	cmd.Flags().StringVar(&backupDir, "backup-dir", "", FLAG_HELP_BACKUP)
+	cmd.Flags().StringVar(&from, "from", "", FLAG_HELP_FROM)
+	cmd.Flags().BoolVar(&list, "list", false, FLAG_HELP_LIST)
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, FLAG_HELP_OVERW)
+	cmd.MarkFlagsMutuallyExclusive("list", "from")
+	return cmd
+}
+
+// The 'restore' subcommand. Returns the exit code.
+func restore(browser browsers.Browser, profile string, cfg *cmn.Config, from string, list, overwrite bool) int {
+	// (a) the backups of the profile, where the wipe put them
+	dir, err := profilePolicy(browser, profile).BackupTarget(cfg.Value("backup_dir"))
+	if err == nil && len(dir) == 0 {
+		err = fmt.Errorf("no backup directory, give --backup-dir or set backup_dir in the config")
+	}
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	backups, err := cmn.ListBackups(dir, browser.String(), profile)
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	if list {
+		for _, backup := range backups {
+			fmt.Println(backup)
+		}
+		return cmn.ExitOK
+	}
+
+	// (b) which one
+	backup := backups[len(backups)-1]
+	if len(from) != 0 {
+		backup = filepath.Join(filepath.Dir(backup), from)
+		if !cmn.IsDirectory(backup) {
+			die(cmn.ExitUsage, "%s: %s (see --list)", cmn.ErrNoBackup, backup)
+		}
+	}
+
+	// (c) not under the feet of a running browser
+	runner := &BrowserWipe{SizeMode: cmn.SizeModeStd, out: out}
+	if err := runner.GetCleaner(browser, profile, false, cmn.SizeModeStd, false); err != nil {
+		die(cmn.ExitCode(err, cmn.ExitCleanerFailure), err.Error())
+	}
+	if lock := runner.cleaner.HeldLock(); len(lock) != 0 {
+		die(cmn.ExitProfileInUse, "%s (%s)", cmn.ErrProfileInUse, lock)
+	}
+
+	restored, err := cmn.RestoreBackup(backup, runner.cleaner.ProfileDir(), overwrite)
+	for _, name := range restored {
+		fmt.Printf("Restored %s\n", name)
+	}
+	if err != nil {
+		code := cmn.ExitUsage
+		if len(restored) != 0 {
+			code = cmn.ExitPartial
+		}
+		die(code, "%s: %s", backup, err)
+	}
+	fmt.Printf("Restored %d items from %s\n", len(restored), backup)
+	return cmn.ExitOK
+}
@@ -0,0 +1,291 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper schedule: systemd user units for scheduled wiping
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_PRESET   string = "What to wipe (quick, standard, profile)"
+	FLAG_HELP_CALENDAR string = "When, as a systemd calendar event (daily, weekly, Mon..Fri 18:00)"
+	FLAG_HELP_PERSIST  string = "Catch up on runs missed while the machine was off"
+	FLAG_HELP_LOGOUT   string = "Wipe when you log out instead of on a calendar"
+	FLAG_HELP_ALLPROF  string = "Wipe all the profiles of the browser"
+	FLAG_HELP_UNIT     string = "Name of the units (NAME.service & NAME.timer)"
+	FLAG_HELP_UNITDIR  string = "Unit directory (~/.config/systemd/user)"
+	FLAG_HELP_PRINT    string = "Print the units instead of installing them"
+	FLAG_HELP_BINARY   string = "Path of wiper in the units (this one)"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// The options of 'wiper schedule install'
+type scheduleOptions struct {
+	browserName, profile, presetName, calendar, unitName, unitDir, binary string
+	allProfiles, persistent, atLogout, printOnly                          bool
+	calendarGiven                                                         bool
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newScheduleCmd() *cobra.Command {
+	var browserName, profile, presetName, calendar, unitName, unitDir, binary string
+	var allProfiles, persistent, atLogout, printOnly bool
+	presets := ""
+	for _, preset := range cmn.SchedulePresets {
+		presets += fmt.Sprintf("\n  %-10s %s", preset.Name, preset.Description)
+	}
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Systemd user units for scheduled wiping",
+		Long:  "Installs, lists & removes the systemd user units of scheduled wipes.\nPresets:" + presets,
+	}
+	install := &cobra.Command{
+		Use:   "install",
+		Short: "Install the units of a scheduled wipe",
+		Example: "  wiper schedule install --preset standard --on-calendar daily --all-profiles\n" +
+			"  wiper schedule install -b Firefox -n default-release --preset quick --at-logout --unit wiper-firefox",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			sched := &scheduleOptions{browserName, profile, presetName, calendar, unitName, unitDir, binary,
+				allProfiles, persistent, atLogout, printOnly, cmd.Flags().Changed("on-calendar")}
+			exit(scheduleInstall(sched))
+		},
+	}
+	addTargetFlags(install, &browserName, &profile, browsers.ChromiumBrowser.String(), FLAG_HELP_NAME)
+	install.Flags().BoolVar(&allProfiles, "all-profiles", false, FLAG_HELP_ALLPROF)
+	install.Flags().StringVar(&presetName, "preset", "standard", FLAG_HELP_PRESET)
+	install.Flags().StringVar(&calendar, "on-calendar", "daily", FLAG_HELP_CALENDAR)
+	install.Flags().BoolVar(&persistent, "persistent", true, FLAG_HELP_PERSIST)
+	install.Flags().BoolVar(&atLogout, "at-logout", false, FLAG_HELP_LOGOUT)
+	install.Flags().StringVar(&unitName, "unit", cmn.DefaultUnitName, FLAG_HELP_UNIT)
+	install.Flags().StringVar(&unitDir, "dir", "", FLAG_HELP_UNITDIR)
+	install.Flags().StringVar(&binary, "binary", "", FLAG_HELP_BINARY)
+	install.Flags().BoolVar(&printOnly, "print", false, FLAG_HELP_PRINT)
+	install.MarkFlagsMutuallyExclusive("name", "all-profiles")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "What is scheduled?",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(scheduleList(unitDir))
+		},
+	}
+	list.Flags().StringVar(&unitDir, "dir", "", FLAG_HELP_UNITDIR)
+
+	remove := &cobra.Command{
+		Use:     "remove",
+		Short:   "Remove the units of a scheduled wipe",
+		Example: "  wiper schedule remove --unit wiper",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(scheduleRemove(unitName, unitDir))
+		},
+	}
+	remove.Flags().StringVar(&unitName, "unit", cmn.DefaultUnitName, FLAG_HELP_UNIT)
+	remove.Flags().StringVar(&unitDir, "dir", "", FLAG_HELP_UNITDIR)
+
+	cmd.AddCommand(install, list, remove)
+	return cmd
+}
+
+func scheduleInstall(o *scheduleOptions) int {
+	// (a) what & which profiles
+	browser, ok := parseBrowser(o.browserName)
+	if !ok {
+		die(cmn.ExitBadBrowser, "Not a supported browser %q", o.browserName)
+	}
+	if (o.profile == "") == !o.allProfiles {
+		die(cmn.ExitNoProfile, "Give either a profile (--name) or --all-profiles")
+	}
+	profiles := []string{o.profile}
+	if o.allProfiles {
+		var err error
+		logx = cmn.NewConditionalLogger(false, "Schedule")
+		runner := &BrowserWipe{SizeMode: cmn.SizeModeStd, out: out}
+		if profiles, err = runner.ProfileNames(browser); err != nil {
+			die(cmn.ExitCleanerFailure, err.Error())
+		}
+	}
+	if o.binary == "" {
+		o.binary = thisExecutable()
+	}
+
+	// (b) when
+	sched := cmn.NewUnitSchedule(o.binary, browser.String(), profiles...)
+	sched.Name = o.unitName
+	if sched.Preset = cmn.LookupPreset(o.presetName); sched.Preset == nil {
+		die(cmn.ExitUsage, "Unknown preset %q (quick, standard, profile)", o.presetName)
+	}
+	sched.OnCalendar, sched.Persistent, sched.AtLogout = o.calendar, o.persistent, o.atLogout
+	if o.atLogout && !o.calendarGiven {
+		sched.OnCalendar = ""
+	}
+	units, err := sched.Units()
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	if !o.atLogout {
+		if err := analyzeCalendar(sched.OnCalendar); err != nil {
+			die(cmn.ExitUsage, "%s: %s", cmn.ErrBadSchedule, err)
+		}
+	}
+
+	// (c) the policy must allow it: what each run wipes & how often
+	var conflicts []*cmn.PolicyConflict
+	for _, profile := range profiles {
+		pol := profilePolicy(browser, profile)
+		cfg := loadConfig(cmn.DefaultConfigFile(), browser, profile, nil)
+		if err := cfg.Set("preset", sched.Preset.Name, "flag --preset"); err != nil {
+			die(cmn.ExitBadConfig, err.Error())
+		}
+		conflicts = append(conflicts, pol.Conflicts(cfg)...)
+		when := sched.OnCalendar
+		if o.atLogout {
+			when = "logout"
+		}
+		if c := calendarConflict(pol, "unit "+o.unitName, when, time.Now()); c != nil {
+			conflicts = append(conflicts, c)
+		}
+	}
+	if len(conflicts) != 0 {
+		die(cmn.ExitPolicy, cmn.PolicyError(conflicts).Error())
+	}
+
+	// (d) write them
+	if o.printOnly {
+		for _, unit := range units {
+			fmt.Printf("# %s\n%s\n", unit.Name, unit.Content)
+		}
+		return cmn.ExitOK
+	}
+	if o.unitDir == "" {
+		if o.unitDir, err = cmn.UserUnitDir(); err != nil {
+			die(cmn.ExitUsage, err.Error())
+		}
+	}
+	if err := cmn.InstallUnits(o.unitDir, units); err != nil {
+		die(cmn.ExitUsage, "Could not install the units: %s", err)
+	}
+	for _, unit := range units {
+		fmt.Printf("Wrote %s\n", filepath.Join(o.unitDir, unit.Name))
+	}
+	upgradeUnits(o.unitDir)
+	enable := o.unitName + ".timer"
+	if o.atLogout {
+		enable = o.unitName + ".service"
+	}
+	fmt.Println("Enable it with:")
+	fmt.Printf("\tsystemctl --user daemon-reload && systemctl --user enable --now %s\n", enable)
+	return cmn.ExitOK
+}
+
+func scheduleList(unitDir string) int {
+	unitDir = userUnitDir(unitDir)
+	upgradeUnits(unitDir)
+	scheds, err := cmn.ListUnits(unitDir)
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	if len(scheds) == 0 {
+		fmt.Printf("No schedules in %s\n", unitDir)
+		return cmn.ExitOK
+	}
+	for _, sched := range scheds {
+		fmt.Printf("%s (%s) %s preset, %s\n", sched.Name, strings.Join(sched.Files, ", "),
+			sched.Preset, sched.Schedule)
+		for _, command := range sched.Commands {
+			fmt.Printf("\t%s\n", command)
+		}
+	}
+	return cmn.ExitOK
+}
+
+func scheduleRemove(unitName, unitDir string) int {
+	unitDir = userUnitDir(unitDir)
+	removed, err := cmn.RemoveUnits(unitDir, unitName)
+	for _, path := range removed {
+		fmt.Printf("Removed %s\n", path)
+	}
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	fmt.Println("If it was enabled, also run:")
+	fmt.Println("\tsystemctl --user daemon-reload && systemctl --user reset-failed")
+	return cmn.ExitOK
+}
+
+// Rewrite the services installed before a wipe without a terminal had
+// to say -yes: each of their runs would be refused (exit code 9)
+func upgradeUnits(unitDir string) {
+	upgraded, err := cmn.UpgradeUnits(unitDir)
+	for _, name := range upgraded {
+		fmt.Printf("Updated %s: its wipes now say -yes, without it they are refused\n", filepath.Join(unitDir, name))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not update the units: %s\n", err)
+	} else if len(upgraded) != 0 {
+		fmt.Println("Reload them with:\n\tsystemctl --user daemon-reload")
+	}
+}
+
+// the given unit directory or the default one
+func userUnitDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	dir, err := cmn.UserUnitDir()
+	if err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	return dir
+}
+
+// absolute path of the running wiper
+func thisExecutable() string {
+	path, err := os.Executable()
+	if err != nil {
+		die(cmn.ExitUsage, "Where am I? %s (use --binary)", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
+// Let systemd-analyze (if installed) check the calendar event
+func analyzeCalendar(spec string) error {
+	analyze, err := exec.LookPath("systemd-analyze")
+	if err != nil {
+		return nil
+	}
+	output, err := exec.Command(analyze, "calendar", spec).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper stats: what the recorded runs freed & how the caches grow
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_SINCE string = "Only the runs since DATE (2024-05-01) or in the last DURATION (7d, 12h)"
+	FLAG_HELP_HFILE string = "History file of the runs"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newStatsCmd() *cobra.Command {
+	var profile, browserName, sinceS, historyFile, szmodeS, outputS string
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "What the recorded runs freed & how the caches grow",
+		Long:  "Totals, cache growth, biggest offenders & failed runs of the profiles.",
+		Example: "  wiper stats\n" +
+			"  wiper stats -b Chromium -n 'Profile 1' --since 30d",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(stats(browserName, profile, sinceS, historyFile, szmodeS, outputS))
+		},
+	}
+	addTargetFlags(cmd, &browserName, &profile, "", FLAG_HELP_NAME+" (all if not given)")
+	cmd.Flags().Lookup("browser").Usage = FLAG_HELP_BROWSER + " (all if not given)"
+	cmd.Flags().StringVar(&sinceS, "since", "", FLAG_HELP_SINCE)
+	cmd.Flags().StringVar(&historyFile, "file", cmn.DefaultHistoryFile(), FLAG_HELP_HFILE)
+	cmd.Flags().StringVarP(&szmodeS, "size", "z", "Std", FLAG_HELP_SIZE)
+	cmd.Flags().StringVarP(&outputS, "output", "o", "human", FLAG_HELP_OUTPUT)
+	return cmd
+}
+
+// The 'stats' subcommand. Returns the exit code.
+func stats(browserName, profile, sinceS, historyFile, szmodeS, outputS string) int {
+	if len(browserName) != 0 {
+		browser, ok := parseBrowser(browserName)
+		if !ok {
+			die(cmn.ExitBadBrowser, "Not a supported browser %q", browserName)
+		}
+		browserName = browser.String()
+	}
+	sizeMode, ok := parseSizeMode(szmodeS)
+	if !ok {
+		die(cmn.ExitBadSizeMode, "%s: %q", cmn.ErrBadSizeMode, szmodeS)
+	}
+	outFormat, err := cmn.ParseOutputFormat(outputS)
+	if err != nil {
+		die(cmn.ExitBadOutput, "%s: %q", err, outputS)
+	}
+	out = cmn.NewRenderer(outFormat, os.Stdout, sizeMode)
+	since, err := parseSince(sinceS, time.Now())
+	if err != nil {
+		die(cmn.ExitUsage, "Bad --since %q: %s", sinceS, err)
+	}
+
+	records, err := cmn.ReadHistory(historyFile)
+	if err != nil {
+		die(cmn.ExitUsage, "Run history %s: %s", historyFile, err)
+	}
+	filter := func(rec *cmn.RunRecord) bool {
+		return (len(browserName) == 0 || strings.EqualFold(rec.Browser, browserName)) &&
+			(len(profile) == 0 || rec.Profile == profile)
+	}
+	out.Stats(cmn.ComputeStats(records, since, filter))
+	return cmn.ExitOK
+}
+
+// --since is a date, a duration or a number of days (7d) back from now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("not a number of days")
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("not a date nor a duration")
+	}
+	return now.Add(-d), nil
+}
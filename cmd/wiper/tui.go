@@ -0,0 +1,697 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper tui: pick, review & wipe a profile full-screen
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	tuiProfiles tuiScreen = iota // the detected browsers & their profiles
+	tuiOptions                   // what to wipe & what to keep
+	tuiPreview                   // the plan (a dry run) & the confirmation
+	tuiWiping                    // progress of the wipe
+	tuiSummary                   // what the wipe did
+
+	TUI_SOURCE = "wiper tui"
+	// lines taken by the title & the key help of every screen
+	TUI_CHROME = 4
+)
+
+var (
+	tuiTitle  = lipgloss.NewStyle().Bold(true)
+	tuiCursor = lipgloss.NewStyle().Reverse(true)
+	tuiError  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9"))
+	tuiHelp   = lipgloss.NewStyle().Faint(true)
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+type tuiScreen int
+
+// A profile of a detected browser & how much it takes
+type tuiTarget struct {
+	browser browsers.Browser
+	profile string
+	dir     string
+	size    cmn.DiskSize
+}
+
+// A checkbox of the options screen: a config setting (cache, profile &
+// shred) or a category kept on top of the usual exceptions
+type tuiToggle struct {
+	label    string
+	setting  string
+	category cmn.Category
+	on       bool
+}
+
+// The state of the whole interface. The cleaners do the work in
+// commands (goroutines), the model only follows their messages.
+type tuiModel struct {
+	configFile string
+	sizeMode   cmn.SizeMode
+	screen     tuiScreen
+	cursor     int
+	height     int
+	message    string // the last error, shown until the next key
+	// profiles screen
+	scanning bool
+	scans    []*cmn.BrowserScan
+	targets  []*tuiTarget
+	// options screen
+	target  *tuiTarget
+	cfg     *cmn.Config
+	pol     *cmn.ProfilePolicy
+	toggles []*tuiToggle
+	// preview screen
+	plan       *cmn.WipeReport
+	lock       string
+	confirming bool
+	// wiping & summary screens
+	events   chan *cmn.Event
+	cancel   context.CancelFunc
+	phase    string
+	current  string
+	done     int
+	bytes    int64
+	stopping bool
+	report   *cmn.WipeReport
+	code     int
+	err      error
+}
+
+// The browsers & profiles found
+type tuiScanMsg struct {
+	scans   []*cmn.BrowserScan
+	targets []*tuiTarget
+}
+
+// The dry run of the preview
+type tuiPlanMsg struct {
+	plan *cmn.WipeReport
+	lock string
+	err  error
+}
+
+// An event of the running wipe
+type tuiEventMsg struct {
+	ev *cmn.Event
+}
+
+// The wipe is over
+type tuiDoneMsg struct {
+	report *cmn.WipeReport
+	code   int
+	err    error
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func newTuiModel(configFile string, sizeMode cmn.SizeMode) *tuiModel {
+	return &tuiModel{configFile: configFile, sizeMode: sizeMode, screen: tuiProfiles, height: 24, scanning: true}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements tea.Model
+func (m *tuiModel) Init() tea.Cmd {
+	return m.scan()
+}
+
+// Implements tea.Model
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+	case tuiScanMsg:
+		m.scanning, m.scans, m.targets = false, msg.scans, msg.targets
+		m.cursor = min(m.cursor, max(len(m.targets)-1, 0))
+		return m, nil
+	case tuiPlanMsg:
+		if msg.err != nil {
+			m.screen, m.message = tuiOptions, msg.err.Error()
+			return m, nil
+		}
+		m.plan, m.lock = msg.plan, msg.lock
+		return m, nil
+	case tuiEventMsg:
+		m.follow(msg.ev)
+		return m, m.nextEvent()
+	case tuiDoneMsg:
+		m.screen, m.report, m.code, m.err = tuiSummary, msg.report, msg.code, msg.err
+		m.cancel()
+		return m, nil
+	case tea.KeyMsg:
+		return m.key(msg)
+	}
+	return m, nil
+}
+
+// Implements tea.Model
+func (m *tuiModel) View() string {
+	var lines []string
+	at := 0
+	title, help := "", ""
+	switch m.screen {
+	case tuiProfiles:
+		title = fmt.Sprintf("wiper %s · browsers & profiles", cmn.Version)
+		lines, at = m.viewProfiles()
+		help = "↑/↓ move · enter choose · r rescan · q quit"
+	case tuiOptions:
+		title = m.targetName() + " · what to wipe"
+		lines, at = m.viewOptions()
+		help = "↑/↓ move · space toggle · enter preview · esc back · q quit"
+	case tuiPreview:
+		title = m.targetName() + " · plan"
+		lines, at = m.viewPreview()
+		help = "↑/↓ scroll · w wipe · esc back · q quit"
+		if m.confirming {
+			help = fmt.Sprintf("Wipe %d items (%s) of %s? y/n", len(m.planned()),
+				cmn.ReportByteCount(m.plan.TotalBytes, m.sizeMode), m.target.profile)
+		}
+	case tuiWiping:
+		title = m.targetName() + " · wiping"
+		lines = m.viewWiping()
+		help = "ctrl+c stop after the current item"
+	case tuiSummary:
+		title = m.targetName() + " · done"
+		lines, at = m.viewSummary()
+		help = "↑/↓ scroll · enter back to the profiles · q quit"
+	}
+
+	var b strings.Builder
+	b.WriteString(tuiTitle.Render(title) + "\n\n")
+	for _, line := range tuiWindow(lines, at, m.height-TUI_CHROME) {
+		b.WriteString(line + "\n")
+	}
+	if len(m.message) != 0 {
+		b.WriteString(tuiError.Render(m.message) + "\n")
+	}
+	b.WriteString("\n" + tuiHelp.Render(help))
+	return b.String()
+}
+
+// the keys of the current screen
+func (m *tuiModel) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	m.message = ""
+	if m.screen == tuiWiping {
+		// the wipe stops after the current item, tuiDoneMsg follows
+		if key == "ctrl+c" || key == "q" {
+			m.stopping = true
+			m.cancel()
+		}
+		return m, nil
+	}
+	if key == "ctrl+c" || key == "q" {
+		return m, tea.Quit
+	}
+	if m.confirming {
+		m.confirming = false
+		if key == "y" || key == "Y" {
+			return m, m.wipe()
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, m.lastLine())
+	case "pgup":
+		m.cursor = max(m.cursor-(m.height-TUI_CHROME), 0)
+	case "pgdown":
+		m.cursor = min(m.cursor+(m.height-TUI_CHROME), m.lastLine())
+	case "home":
+		m.cursor = 0
+	case "end":
+		m.cursor = m.lastLine()
+	case "esc", "backspace":
+		switch m.screen {
+		case tuiOptions:
+			m.screen, m.cursor = tuiProfiles, m.targetIndex()
+		case tuiPreview:
+			m.screen, m.cursor, m.plan = tuiOptions, 0, nil
+		}
+	case "r":
+		if m.screen == tuiProfiles && !m.scanning {
+			m.scanning = true
+			return m, m.scan()
+		}
+	case " ", "x":
+		if m.screen == tuiOptions && m.cursor < len(m.toggles) {
+			m.toggles[m.cursor].on = !m.toggles[m.cursor].on
+		}
+	case "w":
+		if m.screen == tuiPreview {
+			m.confirm()
+		}
+	case "enter":
+		switch m.screen {
+		case tuiProfiles:
+			if m.cursor < len(m.targets) {
+				m.choose(m.targets[m.cursor])
+			}
+		case tuiOptions:
+			return m, m.preview()
+		case tuiPreview:
+			m.confirm()
+		case tuiSummary:
+			m.screen, m.cursor, m.scanning = tuiProfiles, m.targetIndex(), true
+			return m, m.scan()
+		}
+	}
+	return m, nil
+}
+
+// the options of a profile start from its config (files & WIPER_*)
+func (m *tuiModel) choose(target *tuiTarget) {
+	cfg, err := cmn.LoadConfigAt(homeDir, target.browser.String(), target.profile, m.configFile)
+	if err == nil {
+		err = cfg.ApplyEnv(os.LookupEnv)
+	}
+	var pol *cmn.Policy
+	if err == nil {
+		pol, err = cmn.LoadPolicy(cmn.SystemPolicyFile)
+	}
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	m.target, m.cfg, m.pol = target, cfg, pol.For(currentUser(), target.browser.String(), target.profile)
+	m.toggles = []*tuiToggle{
+		{"Wipe the cache", "cache", 0, cfg.Bool("cache")},
+		{"Wipe the profile junk", "profile", 0, cfg.Bool("profile")},
+		{"Shred (overwrite before deleting)", "shred", 0, cfg.Bool("shred")},
+	}
+	kept := cfg.KeptCategories()
+	for _, category := range cmn.Categories() {
+		if category == cmn.CategoryOther || category == cmn.CategoryCache {
+			continue
+		}
+		label := "Keep " + category.String()
+		m.toggles = append(m.toggles, &tuiToggle{label, "", category, slices.Contains(kept, category)})
+	}
+	m.screen, m.cursor = tuiOptions, 0
+}
+
+// the toggles into the config, refused if the policy says otherwise
+func (m *tuiModel) settle() error {
+	var keep []string
+	for _, toggle := range m.toggles {
+		if len(toggle.setting) != 0 {
+			m.cfg.Set(toggle.setting, fmt.Sprint(toggle.on), TUI_SOURCE)
+		} else if toggle.on {
+			keep = append(keep, toggle.category.String())
+		}
+	}
+	if err := m.cfg.Set("keep", strings.Join(keep, ","), TUI_SOURCE); err != nil {
+		return err
+	}
+	if !m.cfg.Bool("cache") && !m.cfg.Bool("profile") {
+		return fmt.Errorf("Nothing to wipe, both cache & profile are off")
+	}
+	if conflicts := m.pol.Conflicts(m.cfg); len(conflicts) != 0 {
+		return cmn.PolicyError(conflicts)
+	}
+	return nil
+}
+
+// a dry run of the wipe with the chosen options
+func (m *tuiModel) preview() tea.Cmd {
+	if err := m.settle(); err != nil {
+		m.message = err.Error()
+		return nil
+	}
+	m.screen, m.cursor, m.plan = tuiPreview, 0, nil
+	target, cfg, pol, sizeMode := m.target, m.cfg, m.pol, m.sizeMode
+	return func() tea.Msg {
+		runner := &BrowserWipe{SizeMode: sizeMode, out: cmn.NewRenderer(cmn.OutputHuman, io.Discard, sizeMode)}
+		if err := runner.GetCleaner(target.browser, target.profile, false, sizeMode, true); err != nil {
+			return tuiPlanMsg{err: err}
+		}
+		runner.Configure(cfg, pol)
+		if _, err := runner.Run(context.Background(), cfg.Bool("cache"), cfg.Bool("profile")); err != nil {
+			return tuiPlanMsg{err: err}
+		}
+		return tuiPlanMsg{plan: runner.cleaner.Report(), lock: runner.cleaner.HeldLock()}
+	}
+}
+
+// ask before wiping, unless there is nothing to ask about
+func (m *tuiModel) confirm() {
+	switch {
+	case m.plan == nil:
+	case len(m.lock) != 0:
+		m.message = fmt.Sprintf("%s (%s), close the browser first", cmn.ErrProfileInUse, m.lock)
+	case len(m.planned()) == 0:
+		m.message = "Nothing to wipe"
+	default:
+		m.confirming = true
+	}
+}
+
+// Wipe what the plan lists (& nothing that appeared since), as does
+// 'wiper apply'. Its events come in through m.events.
+func (m *tuiModel) wipe() tea.Cmd {
+	var ctx context.Context
+	ctx, m.cancel = context.WithCancel(context.Background())
+	m.events = make(chan *cmn.Event, 256)
+	m.screen, m.cursor, m.stopping = tuiWiping, 0, false
+	m.phase, m.current, m.done, m.bytes = "", "", 0, 0
+
+	target, cfg, pol, plan, sizeMode, events := m.target, m.cfg, m.pol, m.plan, m.sizeMode, m.events
+	run := func() tea.Msg {
+		runner := &BrowserWipe{SizeMode: sizeMode, out: cmn.NewRenderer(cmn.OutputHuman, io.Discard, sizeMode)}
+		if cfg.Bool("history") {
+			runner.HistoryFile = cmn.DefaultHistoryFile()
+		}
+		runner.MetricsFile = cfg.Value("metrics_file")
+		if err := runner.GetCleaner(target.browser, target.profile, false, sizeMode, cfg.Bool("dry_run")); err != nil {
+			return tuiDoneMsg{code: cmn.ExitCode(err, cmn.ExitCleanerFailure), err: err}
+		}
+		runner.Configure(cfg, pol)
+		runner.cleaner.LimitProfile(cmn.PlannedNames(plan))
+		// a slow screen drops events rather than slowing the wipe down
+		runner.cleaner.Subscribe(cmn.ObserverFunc(func(ev *cmn.Event) {
+			select {
+			case events <- ev:
+			default:
+			}
+		}))
+		code, err := runner.Run(ctx, cmn.PlanHas(plan, cmn.PhaseCache), cmn.PlanHas(plan, cmn.PhaseProfile))
+		return tuiDoneMsg{report: runner.cleaner.Report(), code: code, err: err}
+	}
+	return tea.Batch(run, m.nextEvent())
+}
+
+// wait for the next event of the wipe
+func (m *tuiModel) nextEvent() tea.Cmd {
+	events := m.events
+	return func() tea.Msg {
+		return tuiEventMsg{<-events}
+	}
+}
+
+// the wiping screen follows the events of the wipe
+func (m *tuiModel) follow(ev *cmn.Event) {
+	switch ev.Kind {
+	case cmn.EventPhaseStarted:
+		m.phase = ev.Phase
+	case cmn.EventItemStarted:
+		m.current = ev.Path
+	case cmn.EventItemDeleted:
+		m.current = ev.Path
+		m.done++
+		m.bytes += ev.Bytes
+	}
+}
+
+func (m *tuiModel) viewProfiles() ([]string, int) {
+	if m.scanning {
+		return []string{"Scanning..."}, 0
+	}
+	var lines []string
+	at, index := 0, 0
+	for _, scan := range m.scans {
+		if len(scan.Error) != 0 || !scan.DataExists {
+			lines = append(lines, fmt.Sprintf("%-10s not found", scan.Browser))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %s  data %s  cache %s", scan.Browser, cmn.FromHome(scan.DataDir),
+			cmn.ReportByteCount(scan.DataSize, m.sizeMode), cmn.ReportByteCount(scan.CacheSize, m.sizeMode)))
+		for ; index < len(m.targets) && m.targets[index].browser.String() == scan.Browser; index++ {
+			target := m.targets[index]
+			line := fmt.Sprintf("  %-32s %10s", target.profile, target.size.Format(m.sizeMode))
+			if index == m.cursor {
+				line, at = tuiCursor.Render(line), len(lines)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(m.targets) == 0 {
+		lines = append(lines, "", "No browser profiles found")
+	}
+	return lines, at
+}
+
+func (m *tuiModel) viewOptions() ([]string, int) {
+	lines := []string{"Profile directory: " + m.target.dir}
+	at := 0
+	for i, toggle := range m.toggles {
+		if i == 3 {
+			lines = append(lines, "", "On top of the usual exceptions (settings, bookmarks...):")
+		}
+		check := "[ ]"
+		if toggle.on {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("  %s %s", check, toggle.label)
+		if len(toggle.setting) == 0 && m.pol.Forces(toggle.category) {
+			line += "  (policy: always wiped)"
+		} else if len(toggle.setting) == 0 && m.pol.Forbids(toggle.category) {
+			line += "  (policy: always kept)"
+		}
+		if i == m.cursor {
+			line, at = tuiCursor.Render(line), len(lines)
+		}
+		lines = append(lines, line)
+	}
+	backup := "none"
+	if dir, err := m.pol.BackupTarget(m.cfg.Value("backup_dir")); err == nil && len(dir) != 0 {
+		backup = dir
+	}
+	lines = append(lines, "", "Backup directory: "+backup)
+	if m.cfg.Bool("dry_run") {
+		lines = append(lines, "Dry run (as configured), nothing will be removed")
+	}
+	return lines, at
+}
+
+func (m *tuiModel) viewPreview() ([]string, int) {
+	if m.plan == nil {
+		return []string{"Planning..."}, 0
+	}
+	planned := m.planned()
+	lines := []string{fmt.Sprintf("%d items, %s would be removed (%d kept)", len(planned),
+		cmn.ReportByteCount(m.plan.TotalBytes, m.sizeMode), len(m.plan.Items)-len(planned)), ""}
+	for _, item := range planned {
+		lines = append(lines, fmt.Sprintf("  %-10s %-48s %10s", item.Phase, m.relative(item),
+			cmn.ReportByteCount(item.Size, m.sizeMode)))
+	}
+	if len(m.lock) != 0 {
+		lines = append(lines, "", tuiError.Render("The browser is running: "+m.lock))
+	}
+	at := min(m.cursor, m.lastLine())
+	return lines, at
+}
+
+func (m *tuiModel) viewWiping() []string {
+	planned := len(m.planned())
+	filled := 0
+	if planned > 0 {
+		filled = BAR_WIDTH * min(m.done, planned) / planned
+	}
+	lines := []string{
+		fmt.Sprintf("Phase    %s", m.phase),
+		fmt.Sprintf("Progress [%s%s] %d/%d items  %s", strings.Repeat("#", filled), strings.Repeat(".", BAR_WIDTH-filled),
+			m.done, planned, cmn.ReportByteCount(m.bytes, m.sizeMode)),
+		fmt.Sprintf("Current  %s", filepath.Base(m.current)),
+	}
+	if m.stopping {
+		lines = append(lines, "", "Stopping after the current item...")
+	}
+	return lines
+}
+
+func (m *tuiModel) viewSummary() ([]string, int) {
+	var lines []string
+	if m.err != nil {
+		lines = append(lines, tuiError.Render(fmt.Sprintf("%s (exit code %d)", m.err, m.code)))
+		if entry := cmn.LookupError(m.code); entry != nil && len(entry.Hint) != 0 {
+			lines = append(lines, entry.Hint)
+		}
+		lines = append(lines, "")
+	}
+	if r := m.report; r != nil {
+		verb := "Removed"
+		if r.DryRun {
+			verb = "Would have removed (dry run)"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d items, %s in %s", verb, r.TotalItems,
+			cmn.ReportByteCount(r.TotalBytes, m.sizeMode), r.Duration.Round(1e6)), "")
+		for _, action := range r.Actions {
+			lines = append(lines, fmt.Sprintf("  %-10s %6d items %10s  %s", action.Phase, action.Items,
+				cmn.ReportByteCount(action.Bytes, m.sizeMode), action.Path))
+		}
+		for _, failure := range r.Failures {
+			lines = append(lines, tuiError.Render(fmt.Sprintf("  ⚠ %s: %s", failure.Path, failure.Message)))
+		}
+	}
+	return lines, min(m.cursor, m.lastLine())
+}
+
+// the items of the plan that would be removed
+func (m *tuiModel) planned() []*cmn.ItemRecord {
+	if m.plan == nil {
+		return nil
+	}
+	var result []*cmn.ItemRecord
+	for _, item := range m.plan.Items {
+		if item.Action == cmn.ItemDeleted {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// an item's path within the directory its phase cleaned
+func (m *tuiModel) relative(item *cmn.ItemRecord) string {
+	for _, action := range m.plan.Actions {
+		if action.Phase == item.Phase {
+			if rel, err := filepath.Rel(action.Path, item.Path); err == nil && rel != "." {
+				return rel
+			}
+		}
+	}
+	return cmn.FromHome(item.Path)
+}
+
+// the last line the cursor may go to on the current screen
+func (m *tuiModel) lastLine() int {
+	switch m.screen {
+	case tuiProfiles:
+		return max(len(m.targets)-1, 0)
+	case tuiOptions:
+		return max(len(m.toggles)-1, 0)
+	case tuiPreview:
+		return len(m.planned()) + 1
+	case tuiSummary:
+		if m.report != nil {
+			return len(m.report.Actions) + len(m.report.Failures) + 4
+		}
+	}
+	return 0
+}
+
+// where the chosen profile is in the list (it may be gone after a wipe)
+func (m *tuiModel) targetIndex() int {
+	for i, target := range m.targets {
+		if target == m.target {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *tuiModel) targetName() string {
+	return m.target.browser.String() + " › " + m.target.profile
+}
+
+// look for the browsers & size their profiles
+func (m *tuiModel) scan() tea.Cmd {
+	sizeMode := m.sizeMode
+	return func() tea.Msg {
+		var msg tuiScanMsg
+		runner := &BrowserWipe{SizeMode: sizeMode, out: cmn.NewRenderer(cmn.OutputHuman, io.Discard, sizeMode)}
+		for _, browser := range browsers.SupportedBrowsers {
+			if err := runner.GetCleaner(browser, "", true, sizeMode, true); err != nil {
+				msg.scans = append(msg.scans, &cmn.BrowserScan{Browser: browser.String(), Error: err.Error()})
+				continue
+			}
+			msg.scans = append(msg.scans, runner.cleaner.Inspect())
+			names, _ := runner.ProfileNames(browser)
+			for _, name := range names {
+				if err := runner.GetCleaner(browser, name, false, sizeMode, true); err != nil {
+					continue
+				}
+				target := &tuiTarget{browser: browser, profile: name, dir: runner.cleaner.ProfileDir()}
+				target.size, _ = cmn.GetDirectoryUsage(context.Background(), target.dir)
+				msg.targets = append(msg.targets, target)
+			}
+		}
+		return msg
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func newTuiCmd() *cobra.Command {
+	var configFile, szmodeS string
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Choose, review & wipe a profile in a full-screen terminal interface",
+		Long: "Lists the browsers & profiles found with their sizes. Choose a profile, what\n" +
+			"to wipe & keep, review the plan item by item and confirm the wipe. It is\n" +
+			"keyboard-only and works over SSH.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(tui(configFile, szmodeS))
+		},
+	}
+	cmd.Flags().StringVar(&configFile, "config", cmn.DefaultConfigFile(), FLAG_HELP_UCONFIG)
+	cmd.Flags().StringVarP(&szmodeS, "size", "z", "Std", FLAG_HELP_SIZE)
+	return cmd
+}
+
+// The 'tui' subcommand. Returns the exit code of the last wipe.
+func tui(configFile, szmodeS string) int {
+	sizeMode, ok := parseSizeMode(szmodeS)
+	if !ok {
+		die(cmn.ExitBadSizeMode, "%s: %q", cmn.ErrBadSizeMode, szmodeS)
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		die(cmn.ExitUsage, "wiper tui needs a terminal, use wiper wipe in scripts")
+	}
+
+	model := newTuiModel(configFile, sizeMode)
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		die(cmn.ExitUsage, err.Error())
+	}
+	if model.report != nil && model.err != nil {
+		return model.code
+	}
+	return cmn.ExitOK
+}
+
+// The lines that fit in height, scrolled so that line at is visible
+func tuiWindow(lines []string, at, height int) []string {
+	if height <= 0 || len(lines) <= height {
+		return lines
+	}
+	first := min(max(at-height/2, 0), len(lines)-height)
+	return lines[first : first+height]
+}
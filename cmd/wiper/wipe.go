@@ -0,0 +1,376 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * wiper scan, wipe, plan & apply: the browser wipe itself
+ *-----------------------------------------------------------------*/
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	FLAG_HELP_SAVE   string = "Save the plan to FILE for wiper apply"
+	FLAG_HELP_MAXAGE string = "Refuse plans older than this (0 is any age)"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// The options of a wipe. Most of them only tell the config which
+// settings were given, the config has the final values.
+type wipeOptions struct {
+	browserName, profile, szmodeS, outputS, reportFile, reportFmtS, metricsFile string
+	configFile, preset, keep, backupDir                                         string
+	cacheOnly, profileOnly, scanOnly, dryRun, progress, keepGoing, history      bool
+	shred, yes, allUsers                                                        bool
+	jobs                                                                        int
+	timeout                                                                     time.Duration
+	logFlags                                                                    *LogFlags
+	// plan: where to save it
+	planFile string
+	// apply: the plan being carried out & how old it may be
+	plan   *cmn.WipeReport
+	maxAge time.Duration
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func newWipeOptions() *wipeOptions {
+	return &wipeOptions{}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The options of the wipe, plan & apply commands. Apply gets the browser
+// & profile from the plan.
+func addWipeFlags(cmd *cobra.Command, o *wipeOptions, target bool) {
+	fs := cmd.Flags()
+	if target {
+		addTargetFlags(cmd, &o.browserName, &o.profile, browsers.ChromiumBrowser.String(), FLAG_HELP_NAME)
+		fs.BoolVarP(&o.cacheOnly, "cache", "c", false, FLAG_HELP_CACHE)
+		fs.BoolVarP(&o.profileOnly, "profile", "p", false, FLAG_HELP_PROFILE)
+	}
+	fs.StringVarP(&o.szmodeS, "size", "z", "Std", FLAG_HELP_SIZE)
+	fs.StringVarP(&o.outputS, "output", "o", "human", FLAG_HELP_OUTPUT)
+	fs.StringVarP(&o.reportFile, "report", "r", "", FLAG_HELP_REPORT)
+	fs.StringVar(&o.reportFmtS, "report-format", "", FLAG_HELP_RFMT)
+	fs.IntVarP(&o.jobs, "jobs", "j", 0, FLAG_HELP_JOBS)
+	fs.DurationVar(&o.timeout, "timeout", 0, FLAG_HELP_TIMEOUT)
+	fs.BoolVar(&o.progress, "progress", true, FLAG_HELP_PROGR)
+	fs.BoolVarP(&o.keepGoing, "keep-going", "k", false, FLAG_HELP_KEEP)
+	fs.BoolVar(&o.history, "history", true, FLAG_HELP_HISTORY)
+	fs.StringVar(&o.metricsFile, "metrics-file", "", FLAG_HELP_METRICS)
+	fs.StringVar(&o.configFile, "config", cmn.DefaultConfigFile(), FLAG_HELP_UCONFIG)
+	fs.StringVar(&o.preset, "preset", "", FLAG_HELP_SETS)
+	fs.StringVar(&o.keep, "keep", "", FLAG_HELP_KEEP_C)
+	fs.StringVar(&o.backupDir, "backup-dir", "", FLAG_HELP_BACKUP)
+	fs.BoolVar(&o.shred, "shred", false, FLAG_HELP_SHRED)
+	fs.BoolVar(&o.dryRun, "dry-run", false, FLAG_HELP_DRYRUN)
+	fs.BoolVarP(&o.yes, "yes", "y", false, FLAG_HELP_YES)
+	o.logFlags = NewLogFlags(fs, false)
+	fs.SetNormalizeFunc(legacyFlagNames)
+}
+
+func newScanCmd() *cobra.Command {
+	o := newWipeOptions()
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Scan the system for browser data & caches",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.scanOnly = true
+			exit(wipe(cmd, o))
+		},
+	}
+	cmd.Flags().StringVarP(&o.szmodeS, "size", "z", "Std", FLAG_HELP_SIZE)
+	cmd.Flags().StringVarP(&o.outputS, "output", "o", "human", FLAG_HELP_OUTPUT)
+	cmd.Flags().BoolVar(&o.allUsers, "all-users", false, FLAG_HELP_ALLUSERS)
+	o.logFlags = NewLogFlags(cmd.Flags(), false)
+	o.configFile = cmn.DefaultConfigFile()
+	return cmd
+}
+
+func newWipeCmd() *cobra.Command {
+	o := newWipeOptions()
+	cmd := &cobra.Command{
+		Use:   "wipe",
+		Short: "Wipe the cache & private data of a browser profile",
+		Long: "Wipes the cache & the profile junk of a browser profile. Neither -c nor -p\n" +
+			"is both, unless the config file says otherwise (see wiper config show).\n" +
+			"It shows what it would remove and asks first, --yes skips that (scripts).",
+		Example: "  wiper wipe -b Chromium -n 'Profile 1'\n" +
+			"  wiper wipe -b Chromium -n 'Profile 1' -c\n" +
+			"  wiper wipe -b Firefox -n default-release --keep cookies --backup-dir ~/wiper-backups\n" +
+			"  wiper wipe -b Chromium -n 'Profile 1' --yes --progress=false\n" +
+			"  sudo wiper wipe --all-users --preset kiosk --yes",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exit(wipe(cmd, o))
+		},
+	}
+	addWipeFlags(cmd, o, true)
+	cmd.Flags().BoolVar(&o.allUsers, "all-users", false, FLAG_HELP_ALLUSERS)
+	return cmd
+}
+
+func newPlanCmd() *cobra.Command {
+	o := newWipeOptions()
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show (& save) what a wipe would remove without removing anything",
+		Long: "A dry run of the wipe. The saved plan can be reviewed & then carried out\n" +
+			"with wiper apply, which removes what the plan lists and nothing else.",
+		Example: "  wiper plan -b Chromium -n 'Profile 1' -r - \n" +
+			"  wiper plan -b Chromium -n 'Profile 1' --save wipe.plan",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.dryRun = true
+			exit(wipe(cmd, o))
+		},
+	}
+	addWipeFlags(cmd, o, true)
+	cmd.Flags().StringVar(&o.planFile, "save", "", FLAG_HELP_SAVE)
+	cmd.Flags().MarkHidden("dry-run")
+	cmd.Flags().MarkHidden("yes")
+	return cmd
+}
+
+func newApplyCmd() *cobra.Command {
+	o := newWipeOptions()
+	cmd := &cobra.Command{
+		Use:   "apply PLAN",
+		Short: "Carry out a plan saved by wiper plan",
+		Long: "Wipes the profile of the plan, removing the items the plan lists & keeping\n" +
+			"what appeared since. The settings (backup, shredding...) are the current ones.",
+		Example: "  wiper apply wipe.plan",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			plan, err := cmn.LoadPlan(args[0], o.maxAge)
+			if err != nil {
+				die(cmn.ExitUsage, err.Error())
+			}
+			o.plan, o.browserName, o.profile = plan, plan.Browser, plan.Profile
+			exit(wipe(cmd, o))
+		},
+	}
+	addWipeFlags(cmd, o, false)
+	cmd.Flags().DurationVar(&o.maxAge, "max-age", 24*time.Hour, FLAG_HELP_MAXAGE)
+	cmd.Flags().MarkHidden("dry-run")
+	return cmd
+}
+
+// -dry is the old name of -dry-run
+func legacyFlagNames(fs *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "dry" {
+		name = "dry-run"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// Scan, wipe, plan or apply as per the options, the config files & the
+// system policy. Returns the exit code.
+func wipe(cmd *cobra.Command, o *wipeOptions) int {
+	// (b.1) Target Profile name (-name), every profile of every user
+	// with --all-users unless given
+	scanOnly := o.scanOnly
+	var users []*cmn.LoginUser
+	if o.allUsers {
+		users = allUsers(cmd, o)
+	} else if !scanOnly && len(o.profile) == 0 {
+		cmd.Usage()
+		die(cmn.ExitUsage, "Need profile directory base name")
+	}
+
+	// (b.2) Browser capabilities
+	browser, ok := parseBrowser(nonEmpty(o.browserName, browsers.ChromiumBrowser.String()))
+	if !ok {
+		die(cmn.ExitBadBrowser, "Not a supported browser %q", o.browserName)
+	}
+
+	// (b.3) Config files & WIPER_* variables under the flags that were
+	// given. No -cache nor -profile is same as ALL (unless configured).
+	// A plan is a dry run, applying one does what it says.
+	cfg := loadConfig(o.configFile, browser, o.profile, cmd.Flags())
+	if cmd.Name() == "plan" {
+		cfg.Set("dry_run", "true", "wiper plan")
+	}
+	if o.plan != nil {
+		source := "plan " + cmd.Flags().Arg(0)
+		cfg.Set("dry_run", "false", source)
+		cfg.Set("cache", strconv.FormatBool(cmn.PlanHas(o.plan, cmn.PhaseCache)), source)
+		cfg.Set("profile", strconv.FormatBool(cmn.PlanHas(o.plan, cmn.PhaseProfile)), source)
+	}
+	cacheOnly, profileOnly := cfg.Bool("cache"), cfg.Bool("profile")
+	if !cacheOnly && !profileOnly && !scanOnly {
+		die(cmn.ExitBadConfig, "Nothing to wipe, both cache & profile are off (see wiper config show)")
+	}
+	szmodeS, outputS, metricsFile := cfg.Value("size"), cfg.Value("output"), cfg.Value("metrics_file")
+	dryRun, progress, history := cfg.Bool("dry_run"), cfg.Bool("progress"), cfg.Bool("history")
+	jobs, timeout := cfg.Int("jobs"), cfg.Duration("timeout")
+
+	// (b.4) The system policy has the last word, settings that go
+	// against it are an error rather than silently overruled. Each user
+	// has their own with --all-users.
+	pol := profilePolicy(browser, o.profile)
+	if conflicts := pol.Conflicts(cfg); len(conflicts) != 0 && !scanOnly && !o.allUsers {
+		die(cmn.ExitPolicy, cmn.PolicyError(conflicts).Error())
+	}
+
+	// (b.5) Size reporting mode
+	sizeMode, ok := parseSizeMode(szmodeS)
+	if !ok {
+		die(cmn.ExitBadSizeMode, "%s: %q", cmn.ErrBadSizeMode, szmodeS)
+	}
+
+	// (b.6) Output format
+	outFormat, err := cmn.ParseOutputFormat(outputS)
+	if err != nil {
+		die(cmn.ExitBadOutput, "%s: %q", err, outputS)
+	}
+	out = cmn.NewRenderer(outFormat, os.Stdout, sizeMode)
+	if progress && !outFormat.IsMachine() && !scanOnly {
+		out = NewProgressRenderer(out, os.Stdout, sizeMode)
+	}
+
+	// (b.7) Per-item report format (guessed from the filename if not given)
+	reportFormat, err := cmn.ParseReportFormat(o.reportFmtS, o.reportFile)
+	if err != nil {
+		die(cmn.ExitBadReportFormat, "%s: %q", err, o.reportFmtS)
+	}
+
+	// (b.8) Concurrency of the directory walker
+	if jobs < 0 || timeout < 0 {
+		die(cmn.ExitUsage, "Jobs & timeout must not be negative")
+	}
+	cmn.WalkerJobs = jobs
+
+	// (b.9) Conditional Logging
+	var logFile io.Closer
+	logx, logFile = o.logFlags.Setup("Main")
+	defer logFile.Close()
+	logging := o.logFlags.Enabled()
+
+	// (b.10) Prologue
+	which := allUsersBrowsers(cmd, browser)
+	if !scanOnly {
+		if o.allUsers {
+			out.Printf("All the users : %d\n", len(users))
+			out.Printf("Browser names : %s\n", which)
+		} else {
+			out.Printf("Browser name  : %s\n", browser)
+		}
+		out.Printf("Profile name  : %s\n", nonEmpty(o.profile, "(all)"))
+		out.Printf("Erase cache   : %t\n", cacheOnly)
+		out.Printf("Erase profile : %t\n", profileOnly)
+		out.Printf("Size mode     : %s\n", sizeMode)
+		out.Printf("Logging enable: %t\n", logging)
+		if dryRun {
+			out.Printf("Dry Run enable: %t\n", dryRun)
+		}
+	}
+	// (b.11) A real wipe is confirmed on the terminal unless --yes, and
+	// then removes what was confirmed & nothing else
+	limit := o.plan
+	if !dryRun && !o.yes && !scanOnly && o.allUsers {
+		confirmAllUsers(users, which, o.profile)
+	} else if !dryRun && !o.yes && !scanOnly {
+		limit = confirmWipe(browser, o.profile, cfg, pol, limit, sizeMode, cacheOnly, profileOnly)
+	}
+
+	// C. Execute
+	runner := &BrowserWipe{}
+	runner.SizeMode = sizeMode
+	runner.ReportFile = o.reportFile
+	runner.ReportFormat = reportFormat
+	if history {
+		runner.HistoryFile = cmn.DefaultHistoryFile()
+	}
+	runner.MetricsFile = metricsFile
+	runner.out = out
+
+	// SIGINT/SIGTERM (or the timeout) stop the wipe after the current item.
+	// A second signal kills the program right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if o.allUsers {
+		report := runAllUsers(ctx, cmd, o, users, which, sizeMode, dryRun, history)
+		out.Users(report)
+		return report.ExitCode()
+	}
+	if scanOnly {
+		if err := runner.Scan(); err != nil {
+			die(cmn.ExitBadOutput, "Could not show the scan: %s", err)
+		}
+		return cmn.ExitOK
+	}
+	if err := runner.GetCleaner(browser, o.profile, scanOnly, sizeMode, dryRun); err != nil {
+		logx.Error("no cleaner", cmn.LogKeyBrowser, browser.String(), cmn.LogKeyProfile, o.profile, cmn.LogKeyErr, err)
+		die(cmn.ExitCode(err, cmn.ExitCleanerFailure), err.Error())
+	}
+	runner.Configure(cfg, pol)
+	if limit != nil {
+		runner.cleaner.LimitProfile(cmn.PlannedNames(limit))
+	}
+	code, err := runner.Run(ctx, cacheOnly, profileOnly)
+	if err != nil {
+		logx.Error("wipe failed", cmn.LogKeyBrowser, browser.String(), cmn.LogKeyProfile, o.profile,
+			"code", code, cmn.LogKeyErr, err)
+		if outFormat.IsMachine() {
+			// the rendered report already carries the error
+			return code
+		}
+		switch code {
+		case cmn.ExitInterrupted:
+			die(code, "Interrupted! Stopped after the current item")
+		case cmn.ExitTimeout:
+			die(code, "Timed out after %s", timeout)
+		case cmn.ExitPartial:
+			die(code, "Partial success, some items could not be removed (see above)")
+		}
+		die(code, err.Error())
+	}
+	logx.Info("wipe done", cmn.LogKeyBrowser, browser.String(), cmn.LogKeyProfile, o.profile, "dry", dryRun)
+
+	// D. Report
+	if len(o.planFile) != 0 {
+		if err := cmn.SavePlan(o.planFile, runner.cleaner.Report()); err != nil {
+			die(cmn.ExitUsage, "Could not save the plan: %s", err)
+		}
+		out.Printf("Plan saved to %s, carry it out with: wiper apply %s\n", o.planFile, o.planFile)
+	}
+	out.Printf("DONE!!!\n")
+	return cmn.ExitOK
+}
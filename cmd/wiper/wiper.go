@@ -11,9 +11,10 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	cmn "github.com/lordofscripts/wipechromium"
@@ -30,17 +31,36 @@ const (
 	FLAG_HELP_BROWSER string = "Browser name"
 	FLAG_HELP_SCAN    string = "Scan for browsers"
 	FLAG_HELP_NAME    string = "Profile name"
-	FLAG_HELP_ME      string = "This help"
 	FLAG_HELP_CACHE   string = "Erase cache only"
 	FLAG_HELP_PROFILE string = "Erase profile junk only"
 	FLAG_HELP_SIZE    string = "Select size reporting mode (Std, SI, IEC)"
 	FLAG_HELP_LOG     string = "Enable log output"
 	FLAG_HELP_DRYRUN  string = "Enable dry-run"
+	FLAG_HELP_OUTPUT  string = "Output format (human, json, ndjson)"
+	FLAG_HELP_REPORT  string = "Write per-item report to FILE (- is stdout)"
+	FLAG_HELP_RFMT    string = "Per-item report format (table, csv, html)"
+	FLAG_HELP_JOBS    string = "Concurrent directory walkers (0 is one per CPU)"
+	FLAG_HELP_TIMEOUT string = "Stop the wipe after this long, i.e. 5m (0 is no limit)"
+	FLAG_HELP_PROGR   string = "Show live progress (a bar on a terminal, lines otherwise)"
+	FLAG_HELP_KEEP    string = "Keep going after a failure, list all failures at the end"
+	FLAG_HELP_LLEVEL  string = "Log level (debug, info, warn, error)"
+	FLAG_HELP_LFORMAT string = "Log format (text, json)"
+	FLAG_HELP_LFILE   string = "Log to FILE instead of stderr (implies --log)"
+	FLAG_HELP_LSINK   string = "Log to stderr, journal or syslog (implies --log)"
+	FLAG_HELP_LSIZE   string = "Rotate the log file at this many megabytes"
+	FLAG_HELP_LBACKUP string = "Rotated log files to keep"
+	FLAG_HELP_HISTORY string = "Record the run in the history (see wiper stats)"
+	FLAG_HELP_METRICS string = "Update the Prometheus metrics in FILE (textfile collector)"
+	FLAG_HELP_HOME    string = "Home directory of the browsers (default is yours)"
 )
 
 var (
-	// A superbly simple conditional logger
-	logx cmn.ILogger = nil
+	// A superbly simple conditional logger (off until set up)
+	logx = cmn.NewConditionalLogger(false, "Main")
+	// All user-facing output goes through here
+	out cmn.IRenderer = cmn.DefaultRenderer()
+	// --home: whose browsers, the current user's if empty
+	homeDir string
 )
 
 /* ----------------------------------------------------------------
@@ -56,8 +76,17 @@ var (
  *-----------------------------------------------------------------*/
 
 type BrowserWipe struct {
-	cleaner  browsers.IBrowsers
-	SizeMode cmn.SizeMode
+	cleaner browsers.IBrowsers
+	// whose browsers (the --home or current user's if nil)
+	Env          *cmn.Environment
+	SizeMode     cmn.SizeMode
+	ReportFile   string
+	ReportFormat cmn.ReportFormat
+	// where completed runs are recorded (none if empty)
+	HistoryFile string
+	// Prometheus textfile-collector file updated after each run (if any)
+	MetricsFile string
+	out         cmn.IRenderer
 }
 
 /* ----------------------------------------------------------------
@@ -70,211 +99,187 @@ type BrowserWipe struct {
 
 // Scan the system for all supported browsers and indicate whether the
 // data/cache directories exist
-func (b *BrowserWipe) Scan() {
+func (b *BrowserWipe) Scan() error {
+	return b.out.Scan(b.ScanReport())
+}
+
+// What Scan() shows: whether each supported browser has data & caches
+func (b *BrowserWipe) ScanReport() *cmn.ScanReport {
+	report := &cmn.ScanReport{Browsers: make([]*cmn.BrowserScan, 0)}
 	for _, br := range browsers.SupportedBrowsers {
 		err := b.GetCleaner(br, "", true, b.SizeMode, false)
 		if err != nil {
-			fmt.Printf("\tBad Thing: %s %s\n", br, err)
+			report.Browsers = append(report.Browsers, &cmn.BrowserScan{Browser: br.String(), Error: err.Error()})
 		} else {
-			b.cleaner.Tell()
-			installed := b.cleaner.IdentifyAppDataRoot()
-			fmt.Printf("\tInstalled: %t\n", installed)
-			if profiles, err := b.cleaner.FindProfileNames(); err == nil {
-				fmt.Println("\tProfiles :")
-				for _, p := range profiles {
-					fmt.Println("\t\t- ", p)
-				}
-			}
+			report.Browsers = append(report.Browsers, b.cleaner.Inspect())
 		}
 	}
+	return report
 }
 
 // Browser Cleaner factory method
 func (b *BrowserWipe) GetCleaner(which browsers.Browser, profile string, scanning bool, mode cmn.SizeMode, dryRun bool) error {
+	// NOTE: a nil *XxxCleaner stored in the interface is NOT a nil interface
+	b.cleaner = nil
+	if b.Env == nil {
+		env, err := cmn.NewEnvironment(homeDir)
+		if err != nil {
+			return fmt.Errorf("%w: %v", cmn.ErrCleanerFailure, err)
+		}
+		b.Env = env
+	}
 	if which == browsers.ChromiumBrowser {
-		b.cleaner = chromium.NewChromiumCleaner(profile, mode, dryRun, logx)
+		cleaner, err := chromium.NewChromiumCleaner(b.Env, profile, mode, dryRun, logx)
+		if err != nil {
+			return err
+		}
+		b.cleaner = cleaner
 	} else if which == browsers.FirefoxBrowser {
-		b.cleaner = firefox.NewFirefoxCleaner(profile, scanning, mode, dryRun, logx)
+		cleaner, err := firefox.NewFirefoxCleaner(b.Env, profile, scanning, mode, dryRun, logx)
+		if err != nil {
+			return err
+		}
+		b.cleaner = cleaner
 	} else {
 		return cmn.ErrUnsupportedBrowser
 	}
+	b.cleaner.SetRenderer(b.out)
+	return nil
+}
 
-	if b.cleaner == nil {
-		return cmn.ErrCleanerFailure
+// Set up the cleaner as per the configuration (keep going, kept data,
+// backup directory & shredding) and the system policy
+func (b *BrowserWipe) Configure(cfg *cmn.Config, policy *cmn.ProfilePolicy) {
+	b.cleaner.SetKeepGoing(cfg.Bool("keep_going"))
+	if keep := cfg.KeptCategories(); len(keep) != 0 {
+		b.cleaner.KeepCategories(keep...)
 	}
-	return nil
+	b.cleaner.SetBackupDir(cfg.Value("backup_dir"))
+	b.cleaner.SetShred(cfg.Bool("shred"))
+	b.cleaner.SetPolicy(policy)
 }
 
-func (b *BrowserWipe) Run(cacheOnly, profileOnly bool) (int, error) {
-	if err, code := b.cleaner.ClearProfile(cacheOnly, profileOnly); err != nil {
+// The names of all the profiles of a browser, sorted
+func (b *BrowserWipe) ProfileNames(which browsers.Browser) ([]string, error) {
+	if err := b.GetCleaner(which, "", true, b.SizeMode, true); err != nil {
+		return nil, err
+	}
+	names, err := b.cleaner.FindProfileNames()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, browsers.ErrNoProfilesFound
+	}
+	profiles := make([]string, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, strings.TrimSuffix(name, " (default)"))
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// Wipe the selected profile. The detailed report is rendered even when
+// the wipe fails (or is interrupted) so that its errors (and their codes)
+// and what was or wasn't removed are visible.
+func (b *BrowserWipe) Run(ctx context.Context, cacheOnly, profileOnly bool) (int, error) {
+	err, code := b.cleaner.ClearProfile(ctx, cacheOnly, profileOnly)
+	if report := b.cleaner.Report(); report != nil {
+		b.RecordRun(report, code)
+		b.out.Wipe(report)
+		if len(b.ReportFile) != 0 {
+			if rerr := b.WriteItemReport(report); rerr != nil {
+				b.out.Printf("\tCould not write report %q: %s\n", b.ReportFile, rerr)
+			}
+		}
+	}
+	if err != nil {
 		return code, err
 	}
 
-	fmt.Println(b.cleaner.String())
+	b.out.Printf("%s\n", b.cleaner.String())
 	return 0, nil
 }
 
-/* ----------------------------------------------------------------
- *							F u n c t i o n s
- *-----------------------------------------------------------------*/
-
-func help() {
-	const (
-		RECIPIENT = "lostinwriting"
-		PACKAGE   = "WipeChromium"
-	)
-
-	cmn.Copyright(cmn.CO1, true)
-	fmt.Println("Usage:")
-	fmt.Println("\tScan the system for browser data presence.")
-	fmt.Println("\t\twipechromium -scan")
-
-	fmt.Println("\tErase both profile junk & cache")
-	fmt.Println("\t\twipechromium -b Chromium  -n 'Profile 1'")
-
-	fmt.Println("\tErase profile junk only")
-	fmt.Println("\t\twipechromium -b Chromium -n 'Profile 1' -p")
-
-	fmt.Println("\tErase profile cache only")
-	fmt.Println("\t\twipechromium -b Chromium -n 'Profile 1' -c")
-
-	fmt.Println("Options:")
-	const HELP_TEMPLATE string = "\t%2s %-8s %10s %s\n"
-	fmt.Printf(HELP_TEMPLATE, "Op", "Long", "Parameter", "Description")
-	fmt.Printf(HELP_TEMPLATE, "-n", "-name", "PROFILE", FLAG_HELP_NAME)
-	fmt.Printf(HELP_TEMPLATE, "-c", "-cache", "", FLAG_HELP_CACHE)
-	fmt.Printf(HELP_TEMPLATE, "-p", "-profile", "", FLAG_HELP_PROFILE)
-	fmt.Printf(HELP_TEMPLATE, "-b", "-browser", "BROWSER", FLAG_HELP_BROWSER)
-	fmt.Printf(HELP_TEMPLATE, "-z", "-size", "Std", FLAG_HELP_SIZE)
-	fmt.Printf(HELP_TEMPLATE, "-s", "-scan", "", FLAG_HELP_SCAN)
-	//fmt.Printf(HELP_TEMPLATE, "", "-log", "", FLAG_HELP_LOG)
-	fmt.Printf(HELP_TEMPLATE, "", "-dry", "", FLAG_HELP_DRYRUN) // hidden option
-
-	cmn.BuyMeCoffee(RECIPIENT)
+// Add a finished wipe to the run history & the metrics. Dry runs
+// removed nothing, they are not recorded.
+func (b *BrowserWipe) RecordRun(report *cmn.WipeReport, code int) {
+	if report.DryRun {
+		return
+	}
+	if len(b.HistoryFile) != 0 {
+		if err := cmn.AppendHistory(b.HistoryFile, cmn.NewRunRecord(report, code)); err != nil {
+			logx.Warn("could not record the run", cmn.LogKeyPath, b.HistoryFile, cmn.LogKeyErr, err)
+		}
+	}
+	if len(b.MetricsFile) != 0 {
+		// the sizes that Tell() shows, after the wipe
+		if err := cmn.UpdateMetricsFile(b.MetricsFile, report, code, b.cleaner.Inspect()); err != nil {
+			logx.Warn("could not write the metrics", cmn.LogKeyPath, b.MetricsFile, cmn.LogKeyErr, err)
+		}
+	}
 }
 
-// Show a message and die with exit code
-func die(exitCode int, msgformat string, v ...any) {
-	const CR = "\n"
-	if !strings.HasSuffix(msgformat, CR) {
-		msgformat += CR
+// Write the per-item report (what was deleted/kept & why) to ReportFile
+func (b *BrowserWipe) WriteItemReport(report *cmn.WipeReport) error {
+	if b.ReportFile == "-" {
+		return cmn.WriteItemReport(os.Stdout, report, b.ReportFormat, b.SizeMode)
 	}
 
-	fmt.Printf("⚓ Bad Thing Happened: exit code %d\n", exitCode)
-	fmt.Println("⚓ Message:")
-	fmt.Printf("⚓ \t"+msgformat, v...)
+	fd, err := os.Create(b.ReportFile)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
 
-	os.Exit(exitCode)
+	return cmn.WriteItemReport(fd, report, b.ReportFormat, b.SizeMode)
 }
 
 /* ----------------------------------------------------------------
- *						M A I N | E X A M P L E
+ *							F u n c t i o n s
  *-----------------------------------------------------------------*/
 
-// Usage: wipechromium -p 'Profile 1'
-func main() {
-	// A. Command-line options
-	var profile, browserName, szmodeS string
-	var cacheOnly, profileOnly, logging, scanOnly, dryRun, helpme bool
-	flag.StringVar(&browserName, "b", browsers.ChromiumBrowser.String(), FLAG_HELP_BROWSER)
-	flag.StringVar(&browserName, "browser", browsers.ChromiumBrowser.String(), FLAG_HELP_BROWSER)
-	flag.BoolVar(&scanOnly, "s", false, FLAG_HELP_SCAN)
-	flag.BoolVar(&scanOnly, "scan", false, FLAG_HELP_SCAN)
-	flag.StringVar(&profile, "n", "", FLAG_HELP_NAME)
-	flag.StringVar(&profile, "name", "", FLAG_HELP_NAME)
-	flag.BoolVar(&helpme, "h", false, FLAG_HELP_ME)
-	flag.BoolVar(&helpme, "help", false, FLAG_HELP_ME)
-	flag.BoolVar(&cacheOnly, "c", false, FLAG_HELP_CACHE)
-	flag.BoolVar(&cacheOnly, "cache", false, FLAG_HELP_CACHE)
-	flag.BoolVar(&profileOnly, "p", false, FLAG_HELP_PROFILE)
-	flag.BoolVar(&profileOnly, "profile", false, FLAG_HELP_PROFILE)
-	flag.StringVar(&szmodeS, "size", "Std", FLAG_HELP_SIZE)
-	flag.StringVar(&szmodeS, "z", "Std", FLAG_HELP_SIZE)
-	flag.BoolVar(&logging, "log", false, FLAG_HELP_LOG)
-	flag.BoolVar(&dryRun, "dry", false, FLAG_HELP_DRYRUN)
-	flag.Parse()
-
-	// B. Validation
-
-	// (b.1) Help!
-	if helpme {
-		help()
-		os.Exit(0)
-	}
-
-	// (b.2) Target Profile name (-name)
-	if !scanOnly && len(profile) == 0 {
-		help()
-		die(1, "Need profile directory base name")
-	}
-
-	// (b.3) No -cache nor -profile is same as ALL
-	if !cacheOnly && !profileOnly {
-		cacheOnly = true
-		profileOnly = true
-	}
-
-	// (b.4) Browser capabilities
-	var browser browsers.Browser
-	switch strings.ToLower(browserName) {
+// Browser by (case-insensitive) name
+func parseBrowser(name string) (browsers.Browser, bool) {
+	switch strings.ToLower(name) {
 	case "chromium": // default
-		browser = browsers.ChromiumBrowser
-		break
+		return browsers.ChromiumBrowser, true
 	case "firefox":
-		browser = browsers.FirefoxBrowser
-		break
+		return browsers.FirefoxBrowser, true
 	default:
-		die(2, "Not a supported browser %q", browserName)
+		return browsers.ChromiumBrowser, false
 	}
+}
 
-	// (b.5) Size reporting mode
-	var sizeMode cmn.SizeMode
-	switch strings.ToLower(szmodeS) {
+// Size reporting mode by (case-insensitive) name
+func parseSizeMode(name string) (cmn.SizeMode, bool) {
+	switch strings.ToLower(name) {
 	case "si":
-		sizeMode = cmn.SizeModeSI
-		break
+		return cmn.SizeModeSI, true
 	case "iec":
-		sizeMode = cmn.SizeModeIEC
-		break
+		return cmn.SizeModeIEC, true
 	case "std":
-		sizeMode = cmn.SizeModeStd
-		break
+		return cmn.SizeModeStd, true
 	default:
-		die(3, "Invalid size mode (SI|IEC|STD) %q", szmodeS)
+		return cmn.SizeModeStd, false
 	}
+}
 
-	// (b.6) Conditional Logging
-	logx = cmn.NewConditionalLogger(logging, "Main")
+// Show a message and die with exit code
+func die(exitCode int, msgformat string, v ...any) {
+	out.Error(exitCode, fmt.Errorf(msgformat, v...))
+	os.Exit(exitCode)
+}
 
-	// (b.7) Prologue
-	if !scanOnly {
-		fmt.Printf("Browser name  : %s\n", browser)
-		fmt.Printf("Profile name  : %s\n", profile)
-		fmt.Printf("Erase cache   : %t\n", cacheOnly)
-		fmt.Printf("Erase profile : %t\n", profileOnly)
-		fmt.Printf("Size mode     : %s\n", sizeMode)
-		fmt.Printf("Logging enable: %t\n", logging)
-		if dryRun {
-			fmt.Printf("Dry Run enable: %t\n", dryRun)
-		}
-	}
-	//os.Exit(100)
-	// C. Execute
-	runner := &BrowserWipe{}
-	runner.SizeMode = sizeMode
+/* ----------------------------------------------------------------
+ *						M A I N | E X A M P L E
+ *-----------------------------------------------------------------*/
 
-	if scanOnly {
-		runner.Scan()
-	} else {
-		if err := runner.GetCleaner(browser, profile, scanOnly, sizeMode, dryRun); err == nil {
-			if code, err := runner.Run(cacheOnly, profileOnly); err != nil {
-				die(code, err.Error())
-			}
-		} else {
-			die(4, err.Error())
-		}
+// Usage: wiper wipe -b Chromium -n 'Profile 1'
+func main() {
+	root := newRootCmd()
+	root.SetArgs(legacyArgs(root, os.Args[1:]))
+	if err := root.Execute(); err != nil {
+		die(cmn.ExitUsage, err.Error())
 	}
-
-	// D. Report
-	fmt.Println("DONE!!!")
 }
@@ -2,12 +2,14 @@
  *					L o r d  O f   S c r i p t s (tm)
  *				  Copyright (C)2024 Dídimo Grimaldo T.
  * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
- *
+ * Conditional logger: a thin adapter on top of log/slog
  *-----------------------------------------------------------------*/
 package wipechromium
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 )
 
 /* ----------------------------------------------------------------
@@ -32,17 +34,29 @@ type ILogger interface {
  *							T y p e s
  *-----------------------------------------------------------------*/
 
+// The leveled & structured logging (Debug...Error with key/value pairs,
+// With) is that of the *ConditionalLogger which InheritAs() gives.
 type ConditionalLogger struct {
 	enabled bool
-	prefix  string
+	prefix  string       // the component attribute
+	base    *slog.Logger // without the component
+	logger  *slog.Logger
 }
 
 /* ----------------------------------------------------------------
  *							C o n s t r u c t o r s
  *-----------------------------------------------------------------*/
 
+// A logger for the given component on top of the default slog logger
+// (see SetupLogging). A disabled logger logs nothing at all.
 func NewConditionalLogger(enabled bool, prefix string) *ConditionalLogger {
-	return &ConditionalLogger{enabled, prefix}
+	return NewLoggerAdapter(slog.Default(), enabled, prefix)
+}
+
+// Adapt a slog logger of your own. Every record gets a component
+// attribute with the given name.
+func NewLoggerAdapter(base *slog.Logger, enabled bool, component string) *ConditionalLogger {
+	return &ConditionalLogger{enabled, component, base, base.With(LogKeyComponent, component)}
 }
 
 /* ----------------------------------------------------------------
@@ -54,22 +68,51 @@ func (l *ConditionalLogger) IsEnabled() bool {
 	return l.enabled
 }
 
-// A new logger inheriting configuration from the parent but different prefix.
+// A new logger inheriting configuration from the parent but a different
+// component.
 func (l *ConditionalLogger) InheritAs(prefix string) *ConditionalLogger {
-	return NewConditionalLogger(l.IsEnabled(), prefix)
+	return NewLoggerAdapter(l.base, l.enabled, prefix)
+}
+
+// A logger adding these attributes to every record. Loggers inherited
+// from it keep them.
+func (l *ConditionalLogger) With(args ...any) *ConditionalLogger {
+	return &ConditionalLogger{l.enabled, l.prefix, l.base.With(args...), l.logger.With(args...)}
+}
+
+// the slog logger behind it (tagged with the component)
+func (l *ConditionalLogger) Logger() *slog.Logger {
+	return l.logger
 }
 
 // Formatted logging
 func (l *ConditionalLogger) Printf(template string, v ...any) {
-	if l.enabled {
-		log.Printf(l.prefix+" "+template, v...)
-	}
+	l.log(slog.LevelInfo, fmt.Sprintf(template, v...))
 }
 
 // Print items on log stream
 func (l *ConditionalLogger) Print(v ...any) {
+	l.log(slog.LevelInfo, fmt.Sprint(v...))
+}
+
+func (l *ConditionalLogger) Debug(msg string, args ...any) {
+	l.log(slog.LevelDebug, msg, args...)
+}
+
+func (l *ConditionalLogger) Info(msg string, args ...any) {
+	l.log(slog.LevelInfo, msg, args...)
+}
+
+func (l *ConditionalLogger) Warn(msg string, args ...any) {
+	l.log(slog.LevelWarn, msg, args...)
+}
+
+func (l *ConditionalLogger) Error(msg string, args ...any) {
+	l.log(slog.LevelError, msg, args...)
+}
+
+func (l *ConditionalLogger) log(level slog.Level, msg string, args ...any) {
 	if l.enabled {
-		v = append([]any{l.prefix + " "}, v...)
-		log.Print(v...)
+		l.logger.Log(context.Background(), level, msg, args...)
 	}
 }
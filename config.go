@@ -0,0 +1,513 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Layered configuration: defaults < system file < user file < env < flags
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	KindString ConfigKind = iota
+	KindBool
+	KindInt
+	KindDuration
+	KindList // of strings, comma-separated in env & flags
+	KindPath // ~/ is the home directory
+)
+
+const (
+	SourceDefault = "default"
+	// the built-in preset that can't be undone (see NameChallenge)
+	PresetParanoid = "paranoid"
+	// settings in the environment are WIPER_ and the key in uppercase
+	ConfigEnvPrefix = "WIPER_"
+)
+
+var (
+	// The administrator's config file, under the user's one
+	SystemConfigFile = "/etc/wiper/config.toml"
+
+	// Every setting there is, in the order "wiper config show" lists them
+	ConfigKeys = []*ConfigKey{
+		{"preset", KindString, "standard", "Named set of settings (quick, standard, profile, paranoid or a [preset.NAME])"},
+		{"cache", KindBool, "true", "Wipe the profile's cache"},
+		{"profile", KindBool, "true", "Wipe the profile's junk"},
+		{"keep", KindList, "", "Data categories to keep on top of the usual exceptions, i.e. cookies"},
+		{"backup_dir", KindPath, "", "Move what is removed from the profile there instead of deleting it"},
+		{"shred", KindBool, "false", "Overwrite what is removed with zeros before deleting it"},
+		{"dry_run", KindBool, "false", "Only show what would be removed"},
+		{"confirm", KindString, "yes", "What a wipe from a terminal asks to be typed: yes or the profile's name (name)"},
+		{"keep_going", KindBool, "false", "Keep going after a failure"},
+		{"size", KindString, "Std", "Size reporting mode (Std, SI, IEC)"},
+		{"output", KindString, "human", "Output format (human, json, ndjson)"},
+		{"jobs", KindInt, "0", "Concurrent directory walkers (0 is one per CPU)"},
+		{"timeout", KindDuration, "0s", "Stop the wipe after this long (0 is no limit)"},
+		{"progress", KindBool, "true", "Show live progress"},
+		{"history", KindBool, "true", "Record the runs in the history"},
+		{"metrics_file", KindPath, "", "Prometheus textfile-collector file to update"},
+	}
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// string|bool|int|duration|list|path
+type ConfigKind uint
+
+// A setting of the configuration
+type ConfigKey struct {
+	Name    string
+	Kind    ConfigKind
+	Default string
+	Help    string
+}
+
+// The value of a setting & where it came from, i.e. "env WIPER_SIZE"
+type ConfigSetting struct {
+	Key    *ConfigKey
+	Value  string
+	Source string
+}
+
+// A named set of settings. The built-in ones only pick the phases.
+type ConfigPreset struct {
+	Name        string
+	Description string
+	Source      string
+	settings    map[string]any
+}
+
+// The effective configuration of a browser profile. Each layer (Load*,
+// ApplyEnv, Set) overrides the settings of the previous ones.
+type Config struct {
+	Browser string
+	Profile string
+	// what ~/ in a path setting stands for, the user's home if empty
+	Home string
+	// the config files that were found, in the order they were applied
+	Files    []string
+	settings map[string]*ConfigSetting
+	presets  map[string]*ConfigPreset
+}
+
+// What a config file may have (the [daemon] & [[job]] are the daemon's)
+//
+//	[defaults]
+//	size = "SI"
+//
+//	[preset.paranoid]
+//	description = "Everything but the bookmarks"
+//	keep = []
+//	confirm = "name"
+//
+//	[browser.Chromium]
+//	backup_dir = "~/.local/share/wiper/backup"
+//
+//	[profile."Chromium/Work"]
+//	preset = "quick"
+//	keep = ["cookies"]
+type configFile struct {
+	Defaults map[string]any            `toml:"defaults"`
+	Presets  map[string]map[string]any `toml:"preset"`
+	Browsers map[string]map[string]any `toml:"browser"`
+	Profiles map[string]map[string]any `toml:"profile"`
+}
+
+type configSection struct {
+	name     string
+	settings map[string]any
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// The default configuration of a browser profile (no files yet)
+func NewConfig(browser, profile string) *Config {
+	c := &Config{browser, profile, "", make([]string, 0), make(map[string]*ConfigSetting), make(map[string]*ConfigPreset)}
+	for _, key := range ConfigKeys {
+		c.settings[key.Name] = &ConfigSetting{key, key.Default, SourceDefault}
+	}
+	for _, preset := range SchedulePresets {
+		c.presets[preset.Name] = &ConfigPreset{preset.Name, preset.Description, SourceDefault,
+			map[string]any{"cache": !preset.ProfileOnly(), "profile": !preset.CacheOnly()}}
+	}
+	c.presets[PresetParanoid] = &ConfigPreset{PresetParanoid, "Everything, shredded, the profile name typed to confirm", SourceDefault,
+		map[string]any{"cache": true, "profile": true, "keep": []any{}, "shred": true, "confirm": "name"}}
+	return c
+}
+
+// The configuration of a browser profile as per the system & user
+// config files (the latter wins). Missing files are fine.
+func LoadConfig(browser, profile, userFile string) (*Config, error) {
+	return LoadConfigAt("", browser, profile, userFile)
+}
+
+// The configuration (see LoadConfig) of a browser profile in another
+// home directory (i.e. --home), which ~/ in the paths stands for
+func LoadConfigAt(home, browser, profile, userFile string) (*Config, error) {
+	c := NewConfig(browser, profile)
+	c.Home = home
+	if err := c.LoadFiles(SystemConfigFile, userFile); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Apply the config files in order. Each one's [defaults] come first,
+// then its [browser.NAME] and [profile."BROWSER/PROFILE"] sections. The
+// presets of all of them are known before any setting is applied.
+func (c *Config) LoadFiles(paths ...string) error {
+	files := make([]*configFile, len(paths))
+	for i, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+		file := &configFile{}
+		if _, err := toml.DecodeFile(path, file); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%w: %s", ErrBadConfig, err)
+		}
+		files[i] = file
+		for name, settings := range file.Presets {
+			preset := &ConfigPreset{name, "", path + " [preset." + name + "]", settings}
+			if desc, ok := settings["description"].(string); ok {
+				preset.Description = desc
+			}
+			delete(settings, "description")
+			if err := checkSettings(settings, preset.Source, false); err != nil {
+				return err
+			}
+			c.presets[name] = preset
+		}
+		// the sections of other browsers & profiles must be right too
+		for name, settings := range file.Browsers {
+			if err := checkSettings(settings, fmt.Sprintf("%s [browser.%s]", path, name), true); err != nil {
+				return err
+			}
+		}
+		for name, settings := range file.Profiles {
+			if !strings.Contains(name, "/") {
+				return fmt.Errorf("%w: %s [profile.%q]: not BROWSER/PROFILE", ErrBadConfig, path, name)
+			}
+			if err := checkSettings(settings, fmt.Sprintf("%s [profile.%q]", path, name), true); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, file := range files {
+		if file == nil {
+			continue
+		}
+		c.Files = append(c.Files, paths[i])
+		sections := []configSection{{"defaults", file.Defaults}}
+		for name, settings := range file.Browsers {
+			if strings.EqualFold(name, c.Browser) {
+				sections = append(sections, configSection{"browser." + name, settings})
+			}
+		}
+		for name, settings := range file.Profiles {
+			if browser, profile, _ := strings.Cut(name, "/"); strings.EqualFold(browser, c.Browser) && profile == c.Profile {
+				sections = append(sections, configSection{fmt.Sprintf("profile.%q", name), settings})
+			}
+		}
+		for _, section := range sections {
+			if err := c.apply(section.settings, fmt.Sprintf("%s [%s]", paths[i], section.name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Apply the WIPER_* environment variables (i.e. WIPER_DRY_RUN=1)
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	// the preset is the first key, its settings come before the others
+	for _, key := range ConfigKeys {
+		if value, ok := lookup(key.EnvName()); ok {
+			if err := c.Set(key.Name, value, "env "+key.EnvName()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Set a setting, i.e. from a flag. A preset brings its settings along.
+func (c *Config) Set(name, value, source string) error {
+	return c.apply(map[string]any{name: value}, source)
+}
+
+// The setting of a key, nil if there is no such key
+func (c *Config) Lookup(name string) *ConfigSetting {
+	return c.settings[name]
+}
+
+// All the settings in the order of ConfigKeys
+func (c *Config) Settings() []*ConfigSetting {
+	result := make([]*ConfigSetting, 0, len(ConfigKeys))
+	for _, key := range ConfigKeys {
+		result = append(result, c.settings[key.Name])
+	}
+	return result
+}
+
+// All the presets, by name
+func (c *Config) Presets() []*ConfigPreset {
+	result := make([]*ConfigPreset, 0, len(c.presets))
+	for _, preset := range c.presets {
+		result = append(result, preset)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (c *Config) Value(name string) string {
+	return c.settings[name].Value
+}
+
+func (c *Config) Bool(name string) bool {
+	value, _ := strconv.ParseBool(c.settings[name].Value)
+	return value
+}
+
+func (c *Config) Int(name string) int {
+	value, _ := strconv.Atoi(c.settings[name].Value)
+	return value
+}
+
+func (c *Config) Duration(name string) time.Duration {
+	value, _ := time.ParseDuration(c.settings[name].Value)
+	return value
+}
+
+func (c *Config) List(name string) []string {
+	return splitList(c.settings[name].Value)
+}
+
+// Whether a wipe asks for the profile's name rather than "yes": when
+// confirm is "name" & when it can't be undone, that is when it shreds
+// (or the policy has it shred) or keeps nothing (keep = [] was given).
+func (c *Config) NameChallenge(pol *ProfilePolicy) bool {
+	if c.Value("confirm") == "name" || c.Bool("shred") || pol.MustShred() {
+		return true
+	}
+	keep := c.settings["keep"]
+	return keep.Source != SourceDefault && len(c.List("keep")) == 0
+}
+
+// The categories of the "keep" setting
+func (c *Config) KeptCategories() []Category {
+	categories, _ := ParseCategories(c.List("keep"))
+	return categories
+}
+
+// apply a section of settings: its preset first (if any), then the rest
+func (c *Config) apply(settings map[string]any, source string) error {
+	bad := func(format string, v ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrBadConfig, source, fmt.Sprintf(format, v...))
+	}
+	if raw, ok := settings["preset"]; ok {
+		name, ok := raw.(string)
+		preset := c.presets[name]
+		if !ok || preset == nil {
+			return bad("unknown preset %v", raw)
+		}
+		if err := c.apply(preset.settings, fmt.Sprintf("%s (preset %s)", source, name)); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		setting, ok := c.settings[name]
+		if !ok {
+			return bad("unknown setting %q", name)
+		}
+		value, err := setting.Key.NormalizeAt(settings[name], c.Home)
+		if err != nil {
+			return bad("%s: %s", name, err)
+		}
+		setting.Value, setting.Source = value, source
+	}
+	return nil
+}
+
+// WIPER_ & the name in uppercase
+func (k *ConfigKey) EnvName() string {
+	return ConfigEnvPrefix + strings.ToUpper(k.Name)
+}
+
+// A value from a config file (any TOML type) or the environment & flags
+// (a string) in its canonical string form, checked as per the kind.
+func (k *ConfigKey) Normalize(value any) (string, error) {
+	return k.NormalizeAt(value, "")
+}
+
+// Normalize a value with ~/ standing for home (the user's if empty)
+func (k *ConfigKey) NormalizeAt(value any, home string) (string, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case bool:
+		s = strconv.FormatBool(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("expected a list of strings")
+			}
+			items = append(items, str)
+		}
+		s = strings.Join(items, ",")
+	default:
+		return "", fmt.Errorf("unexpected %T", value)
+	}
+
+	switch k.Kind {
+	case KindBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return "", fmt.Errorf("%q is not true or false", s)
+		}
+		return strconv.FormatBool(b), nil
+	case KindInt:
+		if _, err := strconv.Atoi(s); err != nil {
+			return "", fmt.Errorf("%q is not a number", s)
+		}
+	case KindDuration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a duration", s)
+		}
+		return d.String(), nil
+	case KindList:
+		items := splitList(s)
+		if k.Name == "keep" {
+			if _, err := ParseCategories(items); err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(items, ","), nil
+	case KindPath:
+		if rest, found := strings.CutPrefix(s, "~/"); found {
+			if len(home) == 0 {
+				home, _ = os.UserHomeDir()
+			}
+			if len(home) != 0 {
+				s = filepath.Join(home, rest)
+			}
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return "", fmt.Errorf("expected a string")
+		}
+	}
+	return s, nil
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The effective configuration as shown by "wiper config show": every
+// setting with its value & source, then the presets.
+func WriteConfig(w io.Writer, c *Config) error {
+	fmt.Fprintf(w, "Effective configuration of %s/%s\n", c.Browser, c.Profile)
+	if len(c.Files) == 0 {
+		fmt.Fprintln(w, "No config file (looked for the system & user ones)")
+	} else {
+		fmt.Fprintf(w, "Config files: %s\n", strings.Join(c.Files, ", "))
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE")
+	for _, setting := range c.Settings() {
+		value := setting.Value
+		if len(value) == 0 {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", setting.Key.Name, value, setting.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nPresets:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, preset := range c.Presets() {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", preset.Name, preset.Description, preset.Source)
+	}
+	return tw.Flush()
+}
+
+// The setting of that name, nil if there is none
+func LookupConfigKey(name string) *ConfigKey {
+	for _, key := range ConfigKeys {
+		if key.Name == name {
+			return key
+		}
+	}
+	return nil
+}
+
+// the settings of a section exist & have values that make sense. A
+// preset may not name another preset.
+func checkSettings(settings map[string]any, source string, presetOK bool) error {
+	for name, value := range settings {
+		key := LookupConfigKey(name)
+		if key == nil || (key.Name == "preset" && !presetOK) {
+			return fmt.Errorf("%w: %s: unknown setting %q", ErrBadConfig, source, name)
+		}
+		if _, err := key.Normalize(value); err != nil {
+			return fmt.Errorf("%w: %s: %s: %s", ErrBadConfig, source, name, err)
+		}
+	}
+	return nil
+}
+
+// a comma-separated list without the blanks
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,215 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Cron-like expressions for the jobs of the daemon
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A parsed cron expression: "minute hour day-of-month month day-of-week"
+// with *, lists, ranges, steps and month/day names, one of the @daily
+// style shorthands, or "@every DURATION".
+type CronSchedule struct {
+	spec   string
+	every  time.Duration
+	minute uint64 // bit sets
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// day of month & day of week both restricted: either one matches
+	eitherDay bool
+}
+
+type cronField struct {
+	min, max int
+	names    []string
+	offset   int // of the first name
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	bad := func(format string, v ...any) error {
+		return fmt.Errorf("%w %q: %s", ErrBadCron, spec, fmt.Sprintf(format, v...))
+	}
+	if rest, found := strings.CutPrefix(spec, "@every "); found {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Minute {
+			return nil, bad("@every needs a duration of at least 1m")
+		}
+		return &CronSchedule{spec: spec, every: every}, nil
+	}
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		if expr = cronShorthands[strings.ToLower(spec)]; expr == "" {
+			return nil, bad("unknown shorthand")
+		}
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, bad("need 5 fields (minute hour day month weekday)")
+	}
+	defs := []cronField{{0, 59, nil, 0}, {0, 23, nil, 0}, {1, 31, nil, 0}, {1, 12, monthNames, 1}, {0, 7, dayNames, 0}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := defs[i].parse(field)
+		if err != nil {
+			return nil, bad("%s", err)
+		}
+		sets[i] = set
+	}
+	// 7 is Sunday too
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	either := fields[2] != "*" && fields[4] != "*"
+	return &CronSchedule{spec, 0, sets[0], sets[1], sets[2], sets[3], sets[4], either}, nil
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+func (c *CronSchedule) String() string {
+	return c.spec
+}
+
+// The first time after t the schedule fires (zero if never, i.e. the
+// 31st of February)
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// The longest wait for the next run from any time in [from, from+span),
+// i.e. 24h for @daily. A schedule that never fires in that span waits
+// longer than the span.
+func (c *CronSchedule) LongestGap(from time.Time, span time.Duration) time.Duration {
+	if c.every > 0 {
+		return c.every
+	}
+	var longest time.Duration
+	end := from.Add(span)
+	for t := from; t.Before(end); {
+		next := c.Next(t)
+		if next.IsZero() || next.After(end.Add(span)) {
+			return span + 1
+		}
+		longest = max(longest, next.Sub(t))
+		t = next
+	}
+	return longest
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.eitherDay {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// a field as a bit set: *, */n, a, a-b, a-b/n and lists of those
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepS, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepS); err != nil || step < 1 {
+				return 0, fmt.Errorf("step %q", stepS)
+			}
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loS, hiS, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loS); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiS); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.offset, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%q not in %d-%d", s, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,457 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The wiper daemon: runs the jobs of the config file on their cron
+ * schedules and keeps its state in a small TOML file.
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// How long to wait before trying again a job whose profile was in use
+const DefaultRetryLocked = 15 * time.Minute
+
+// Job outcomes in the state file
+const (
+	JobOK      = "ok"
+	JobFailed  = "failed"
+	JobPartial = "partial"
+	JobInUse   = "in-use" // skipped, the browser was running
+	JobStopped = "stopped"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// The daemon's part of the config file:
+//
+//	[daemon]
+//	retry_locked = "10m"
+//
+//	[[job]]
+//	browser  = "Chromium"
+//	profile  = "Profile 1"
+//	schedule = "30 12 * * mon-fri"
+//	preset   = "quick"
+type DaemonConfig struct {
+	Daemon struct {
+		// retry a job skipped because the profile was in use after this
+		// long (unless it is due earlier anyway)
+		RetryLocked time.Duration `toml:"retry_locked"`
+	} `toml:"daemon"`
+	Jobs []*DaemonJob `toml:"job"`
+	// the [preset.NAME] of the settings, jobs may use them too
+	Presets map[string]map[string]any `toml:"preset"`
+}
+
+type DaemonJob struct {
+	Name        string `toml:"name"` // browser/profile if not given
+	Browser     string `toml:"browser"`
+	Profile     string `toml:"profile"`
+	Schedule    string `toml:"schedule"` // cron expression
+	Preset      string `toml:"preset"`   // standard if not given
+	DryRun      bool   `toml:"dry_run"`
+	StopOnError bool   `toml:"stop_on_error"` // instead of -keep-going
+	cron        *CronSchedule
+	preset      *SchedulePreset
+}
+
+// What a job run did
+type JobResult struct {
+	Code  int
+	Err   error
+	Bytes int64
+	Items int
+}
+
+// Runs a job, i.e. through BrowserWipe & ClearProfile
+type JobRunner func(ctx context.Context, job *DaemonJob) *JobResult
+
+// The state file: who is running and how did each job do
+type DaemonState struct {
+	PID     int         `toml:"pid"` // zero once stopped
+	Started time.Time   `toml:"started"`
+	Config  string      `toml:"config"`
+	Loaded  time.Time   `toml:"loaded"`
+	Updated time.Time   `toml:"updated"`
+	Jobs    []*JobState `toml:"job"`
+}
+
+type JobState struct {
+	Name     string    `toml:"name"`
+	Schedule string    `toml:"schedule"`
+	NextRun  time.Time `toml:"next_run"`
+	LastRun  time.Time `toml:"last_run"`
+	// seconds, toml has no durations
+	LastSeconds float64 `toml:"last_seconds"`
+	LastStatus  string  `toml:"last_status"`
+	LastCode    int     `toml:"last_code"`
+	LastError   string  `toml:"last_error,omitempty"`
+	LastBytes   int64   `toml:"last_bytes"`
+	LastItems   int     `toml:"last_items"`
+	Runs        int     `toml:"runs"`
+	Failures    int     `toml:"failures"`
+	Skipped     int     `toml:"skipped_in_use"`
+}
+
+type Daemon struct {
+	ConfigFile string
+	StateFile  string
+	run        JobRunner
+	logx       *ConditionalLogger
+	config     *DaemonConfig
+	state      *DaemonState
+	// the clock, tests replace it
+	Now func() time.Time
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func NewDaemon(configFile, stateFile string, run JobRunner, logger ...ILogger) *Daemon {
+	const cName = "Daemon"
+	var logCtx *ConditionalLogger
+	if len(logger) == 0 {
+		logCtx = NewConditionalLogger(false, cName)
+	} else {
+		logCtx = logger[0].InheritAs(cName)
+	}
+	state := &DaemonState{PID: os.Getpid(), Config: configFile}
+	return &Daemon{configFile, stateFile, run, logCtx, nil, state, time.Now}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// (Re)load the config file. On error the jobs loaded before are kept.
+// Jobs that are still there keep their state & next run.
+func (d *Daemon) Load() error {
+	config, err := LoadDaemonConfig(d.ConfigFile)
+	if err != nil {
+		return err
+	}
+	now := d.Now()
+	previous := make(map[string]*JobState)
+	for _, js := range d.state.Jobs {
+		previous[js.Name] = js
+	}
+	jobs := make([]*JobState, 0, len(config.Jobs))
+	for _, job := range config.Jobs {
+		js := previous[job.Name]
+		if js == nil || js.Schedule != job.Schedule || js.NextRun.IsZero() {
+			if js == nil {
+				js = &JobState{Name: job.Name}
+			}
+			js.Schedule = job.Schedule
+			js.NextRun = job.cron.Next(now)
+		}
+		jobs = append(jobs, js)
+	}
+	d.config = config
+	d.state.Jobs, d.state.Loaded = jobs, now
+	d.logx.Info("config loaded", "file", d.ConfigFile, "jobs", len(jobs))
+	return nil
+}
+
+// Run the jobs until ctx is done. A value on reload (i.e. SIGHUP)
+// reloads the config file. The jobs carry on from the state file: their
+// counts & last run, and a run missed while stopped is done first.
+func (d *Daemon) Serve(ctx context.Context, reload <-chan os.Signal) error {
+	prior, err := ReadDaemonState(d.StateFile)
+	if err == nil && prior.Running() && prior.PID != os.Getpid() {
+		return fmt.Errorf("the daemon is already running (pid %d)", prior.PID)
+	}
+	if err == nil && len(d.state.Jobs) == 0 {
+		// Load() keeps the state of the jobs (by name) still configured
+		d.state.Jobs = prior.Jobs
+	}
+	if err := d.Load(); err != nil {
+		return err
+	}
+	d.state.Started = d.Now()
+	d.saveState()
+	defer func() {
+		d.state.PID = 0
+		d.saveState()
+	}()
+
+	for {
+		timer := time.NewTimer(time.Until(d.nextRun()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.logx.Info("stopping")
+			return nil
+		case <-reload:
+			timer.Stop()
+			if err := d.Load(); err != nil {
+				d.logx.Error("reload failed, keeping the previous config", LogKeyErr, err)
+			}
+			d.saveState()
+		case <-timer.C:
+			d.RunDue(ctx)
+		}
+	}
+}
+
+// Run the jobs that are due, one after the other. Returns how many ran.
+func (d *Daemon) RunDue(ctx context.Context) int {
+	ran := 0
+	for i, job := range d.config.Jobs {
+		js := d.state.Jobs[i]
+		// a zero next run never comes (i.e. on February 30th)
+		if ctx.Err() != nil || js.NextRun.IsZero() || d.Now().Before(js.NextRun) {
+			continue
+		}
+		d.runJob(ctx, job, js)
+		ran++
+		d.saveState()
+	}
+	return ran
+}
+
+// The state as of now
+func (d *Daemon) State() *DaemonState {
+	return d.state
+}
+
+func (d *Daemon) runJob(ctx context.Context, job *DaemonJob, js *JobState) {
+	logx := d.logx.With("job", job.Name, LogKeyBrowser, job.Browser, LogKeyProfile, job.Profile)
+	logx.Info("job started", "preset", job.preset.Name, "dry", job.DryRun)
+	started := d.Now()
+	result := d.run(ctx, job)
+	now := d.Now()
+
+	js.LastRun, js.LastSeconds = started, now.Sub(started).Seconds()
+	js.LastCode, js.LastBytes, js.LastItems = result.Code, result.Bytes, result.Items
+	js.LastError = ""
+	if result.Err != nil {
+		js.LastError = result.Err.Error()
+	}
+	js.NextRun = job.cron.Next(now)
+
+	switch {
+	case result.Code == ExitProfileInUse:
+		// not a failure: the browser is running, try again a bit later
+		js.LastStatus = JobInUse
+		js.Skipped++
+		if retry := now.Add(d.config.Daemon.RetryLocked); retry.Before(js.NextRun) {
+			js.NextRun = retry
+		}
+		logx.Info("job skipped, profile in use", "retry", js.NextRun)
+		return
+	case IsInterruption(result.Err):
+		js.LastStatus = JobStopped
+		logx.Info("job stopped", LogKeyBytes, result.Bytes)
+		return
+	case result.Err == nil:
+		js.LastStatus = JobOK
+	case result.Code == ExitPartial:
+		js.LastStatus = JobPartial
+		js.Failures++
+	default:
+		js.LastStatus = JobFailed
+		js.Failures++
+	}
+	js.Runs++
+	if result.Err != nil {
+		logx.Error("job failed", "code", result.Code, LogKeyErr, result.Err, LogKeyBytes, result.Bytes)
+	} else {
+		logx.Info("job done", LogKeyBytes, result.Bytes, "items", result.Items, "next", js.NextRun)
+	}
+}
+
+func (d *Daemon) nextRun() time.Time {
+	next := d.Now().Add(24 * time.Hour)
+	for _, js := range d.state.Jobs {
+		if !js.NextRun.IsZero() && js.NextRun.Before(next) {
+			next = js.NextRun
+		}
+	}
+	return next
+}
+
+func (d *Daemon) saveState() {
+	d.state.Updated = d.Now()
+	if err := WriteDaemonState(d.StateFile, d.state); err != nil {
+		d.logx.Error("could not save the state", "file", d.StateFile, LogKeyErr, err)
+	}
+}
+
+// The preset of a loaded job
+func (j *DaemonJob) PresetOf() *SchedulePreset {
+	return j.preset
+}
+
+// Whether the daemon that wrote the state is still alive
+func (s *DaemonState) Running() bool {
+	return s.PID > 0 && processAlive(s.PID)
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The user's config file: $XDG_CONFIG_HOME/wiper/config.toml
+func DefaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "wiper", "config.toml")
+}
+
+// Where wiper keeps its state: $XDG_STATE_HOME/wiper (by default
+// ~/.local/state/wiper)
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "wiper")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".local", "state", "wiper")
+}
+
+func DefaultDaemonStateFile() string {
+	return filepath.Join(StateDir(), "daemon.toml")
+}
+
+// Read & check the daemon jobs of a config file
+func LoadDaemonConfig(path string) (*DaemonConfig, error) {
+	config := &DaemonConfig{}
+	if _, err := toml.DecodeFile(path, config); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadConfig, err)
+	}
+	if config.Daemon.RetryLocked <= 0 {
+		config.Daemon.RetryLocked = DefaultRetryLocked
+	}
+	if len(config.Jobs) == 0 {
+		return nil, fmt.Errorf("%w: %s has no [[job]]", ErrBadConfig, path)
+	}
+	names := make(map[string]bool)
+	for i, job := range config.Jobs {
+		bad := func(format string, v ...any) error {
+			return fmt.Errorf("%w: job %d: %s", ErrBadConfig, i+1, fmt.Sprintf(format, v...))
+		}
+		if job.Browser == "" || job.Profile == "" {
+			return nil, bad("needs a browser & a profile")
+		}
+		if job.Name == "" {
+			job.Name = job.Browser + "/" + job.Profile
+		}
+		if names[job.Name] {
+			return nil, bad("%q is there twice", job.Name)
+		}
+		names[job.Name] = true
+		var err error
+		if job.cron, err = ParseCron(job.Schedule); err != nil {
+			return nil, bad("%s", err)
+		}
+		if job.Preset == "" {
+			job.Preset = "standard"
+		}
+		if job.preset = LookupPreset(job.Preset); job.preset == nil {
+			if _, ok := config.Presets[job.Preset]; !ok {
+				return nil, bad("unknown preset %q", job.Preset)
+			}
+			job.preset = &SchedulePreset{job.Preset, "[preset." + job.Preset + "] of " + path, nil}
+		}
+	}
+	return config, nil
+}
+
+func ReadDaemonState(path string) (*DaemonState, error) {
+	state := &DaemonState{}
+	if _, err := toml.DecodeFile(path, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Write the state file atomically
+func WriteDaemonState(path string, state *DaemonState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	fd, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	err = toml.NewEncoder(fd).Encode(state)
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// The state as shown by "wiper daemon status"
+func WriteDaemonStatus(w io.Writer, state *DaemonState) error {
+	if state.Running() {
+		fmt.Fprintf(w, "Running (pid %d) since %s\n", state.PID, state.Started.Format(time.DateTime))
+	} else {
+		fmt.Fprintf(w, "Not running, last seen %s\n", state.Updated.Format(time.DateTime))
+	}
+	fmt.Fprintf(w, "Config %s loaded %s\n\n", state.Config, state.Loaded.Format(time.DateTime))
+
+	stamp := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04")
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSCHEDULE\tLAST RUN\tSTATUS\tFREED\tNEXT RUN\tRUNS\tFAILED\tIN USE")
+	for _, js := range state.Jobs {
+		status := js.LastStatus
+		if status == "" {
+			status = "-"
+		} else if js.LastCode != 0 {
+			status = fmt.Sprintf("%s (E-%03d)", status, js.LastCode)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\n", js.Name, js.Schedule, stamp(js.LastRun),
+			status, ByteCountSI(js.LastBytes), stamp(js.NextRun), js.Runs, js.Failures, js.Skipped)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, js := range state.Jobs {
+		if js.LastError != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", js.Name, strings.TrimSpace(js.LastError))
+		}
+	}
+	return nil
+}
+
+// Whether err means there is no state file (the daemon never ran)
+func IsNoState(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
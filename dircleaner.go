@@ -7,7 +7,9 @@
 package wipechromium
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices" // GO v1.18
@@ -25,8 +27,28 @@ var _ IDirCleaner = (*DirCleaner)(nil)
 
 type IDirCleaner interface {
 	String() string
-	CleanUp(exceptions []string) error
+	// Remove everything at the top level of the directory except the
+	// given exceptions. When the context is cancelled it stops after the
+	// current item and records the rest as skipped.
+	CleanUp(ctx context.Context, exceptions []string) error
+	// Follow the progress of CleanUp() item by item
+	Subscribe(observers ...IObserver)
+	// Go on with the other items after a failure. CleanUp() then
+	// returns a *MultiError with all of them.
+	SetKeepGoing(on bool)
+	// Move the items into this directory (created as needed) instead of
+	// deleting them.
+	SetBackupDir(dir string)
+	// Overwrite the files with zeros before deleting them
+	SetShred(on bool)
+	// Where the notices of a dry run go (os.Stdout by default)
+	SetOutput(w io.Writer)
 	CleanedSize() int64
+	// apparent & allocated size of what was removed
+	CleanedUsage() DiskSize
+	RemovedCount() int
+	SkippedCount() int
+	Items() []*ItemRecord
 }
 
 /* ----------------------------------------------------------------
@@ -34,13 +56,19 @@ type IDirCleaner interface {
  *-----------------------------------------------------------------*/
 
 type DirCleaner struct {
-	Root        string
-	cleanedSize int64
-	removedQty  int
-	skippedQty  int
-	sizeMode    SizeMode
-	doDryRun    bool
-	logx        ILogger
+	Root       string
+	cleaned    DiskSize
+	removedQty int
+	skippedQty int
+	sizeMode   SizeMode
+	doDryRun   bool
+	logx       *ConditionalLogger
+	items      []*ItemRecord
+	observers  Observers
+	keepGoing  bool
+	backupDir  string
+	shred      bool
+	out        io.Writer
 }
 
 /* ----------------------------------------------------------------
@@ -49,13 +77,13 @@ type DirCleaner struct {
 
 func NewDirCleaner(root string, sizing SizeMode, dryRun bool, logger ...ILogger) *DirCleaner {
 	const cName = "DirCleaner"
-	var logCtx ILogger
+	var logCtx *ConditionalLogger
 	if len(logger) == 0 {
 		logCtx = NewConditionalLogger(false, cName)
 	} else {
 		logCtx = logger[0].InheritAs(cName)
 	}
-	return &DirCleaner{root, 0, 0, 0, sizing, dryRun, logCtx}
+	return &DirCleaner{root, DiskSize{}, 0, 0, sizing, dryRun, logCtx, nil, Observers{}, false, "", false, os.Stdout}
 }
 
 /* ----------------------------------------------------------------
@@ -67,112 +95,188 @@ func (d *DirCleaner) String() string {
 	return fmt.Sprintf("DirCleaner %q del:%d skip:%d size:%s", d.Root,
 		d.removedQty,
 		d.skippedQty,
-		ReportByteCount(d.cleanedSize, d.sizeMode))
+		d.cleaned.Format(d.sizeMode))
 }
 
-func (d *DirCleaner) CleanUp(exceptions []string) error {
-	d.cleanedSize = 0
+func (d *DirCleaner) CleanUp(ctx context.Context, exceptions []string) error {
+	d.cleaned = DiskSize{}
 	d.removedQty = 0
 	d.skippedQty = 0
+	d.items = make([]*ItemRecord, 0)
 	entries, err := os.ReadDir(d.Root) // always read DIR from underlying OS
 	if err != nil {
-		d.logx.Print(err)
+		d.logx.Error("read dir", LogKeyPath, d.Root, LogKeyErr, err)
 		return err
 	}
 
 	// Support DRY RUNS
 	dry := NewDryRunner() // but only execute file/dir actions when DRY RUN is inactive
+	dry.SetOutput(d.out)
 	if !d.doDryRun {
 		dry.Disable()
 	}
 
-	var executor func(string) error = nil
-
-	execRemoveRecursive := func(path string) error {
-		return dry.RemoveAll(path)
+	// hardlinks are counted once across all the removed items
+	var current string
+	walker := NewWalker(WalkerJobs)
+	if d.observers.Len() != 0 {
+		walker.OnProgress(ProgressInterval, func(done DiskSize, files int) {
+			d.observers.Event(NewProgressEvent(current, done, files))
+		})
 	}
 
-	execRemoveSingle := func(path string) error {
-		return dry.Remove(path)
-	}
+	var failures MultiError
+	total := len(entries)
+	for i, item := range entries {
+		if err := ctx.Err(); err != nil {
+			d.skipRest(d.Root, entries[i:], i, total)
+			return failures.Final(err)
+		}
 
-	for _, item := range entries {
+		fullPath := filepath.Join(d.Root, item.Name())
 		if !slices.Contains(exceptions, item.Name()) {
-			// count up
-			size := int64(0)
-			fullPath := filepath.Join(d.Root, item.Name())
-			// get file/dir size
-			if finfo, err := item.Info(); err == nil {
-				if finfo.IsDir() {
-					executor = execRemoveRecursive
-					size, _ = GetDirectorySize(fullPath)
-					d.logx.Printf("%8d D %s", size, fullPath)
-				} else {
-					executor = execRemoveSingle
-					size = finfo.Size()
-					d.logx.Printf("%8d F %s", size, fullPath)
+			// size & delete in one go (or move it away)
+			current = fullPath
+			d.record(EventItemStarted, &ItemRecord{Path: fullPath, IsDir: item.IsDir()}, i+1, total)
+			rule := RuleWipe + "*"
+			var usage DiskSize
+			if len(d.backupDir) != 0 {
+				rule = RuleBackup + d.backupDir
+				usage, err = d.backup(ctx, dry, walker, item.Name())
+			} else if d.shred {
+				rule = RuleShred + "*"
+				if err = dry.Shred(ctx, fullPath); err == nil {
+					usage, err = dry.RemoveAllSized(ctx, walker, fullPath)
 				}
 			} else {
-				d.logx.Print("DirCleaner WARN:", err)
+				usage, err = dry.RemoveAllSized(ctx, walker, fullPath)
 			}
-
-			// delete
-			if err := executor(fullPath); err != nil {
-				return err
-			} else {
-				d.cleanedSize += size
+			d.cleaned.Add(usage)
+			if err != nil {
+				d.record(EventError, NewFailedItem(fullPath, item.IsDir(), usage.Apparent, rule, err), i+1, total)
+				if ctx.Err() != nil {
+					d.skipRest(d.Root, entries[i+1:], i+1, total)
+				} else if d.keepGoing {
+					failures.Add(NewItemError(fullPath, "remove", 0, err))
+					continue
+				}
+				return failures.Final(err)
 			}
+			d.logx.Debug("removed", LogKeyPath, fullPath, "dir", item.IsDir(), LogKeyBytes, usage.Apparent)
+			d.record(EventItemDeleted, NewDeletedItem(fullPath, item.IsDir(), usage.Apparent, rule), i+1, total)
 			d.removedQty += 1
 		} else {
 			d.skippedQty += 1
-			d.logx.Printf("DirCleaner skipping %s", item.Name())
+			d.logx.Debug("kept", LogKeyPath, fullPath, "rule", RuleException+item.Name())
+			d.record(EventItemSkipped, NewKeptItem(fullPath, item.IsDir(), itemSize(ctx, fullPath, item), RuleException+item.Name()), i+1, total)
 		}
 	}
-	return nil
+	return failures.Err()
 }
 
-func (d *DirCleaner) CleanedSize() int64 {
-	return d.cleanedSize
+// Follow the progress of CleanUp() item by item
+func (d *DirCleaner) Subscribe(observers ...IObserver) {
+	d.observers.Subscribe(observers...)
 }
 
-/* ----------------------------------------------------------------
- *							F u n c t i o n s
- *-----------------------------------------------------------------*/
+// Go on with the other items after a failure
+func (d *DirCleaner) SetKeepGoing(on bool) {
+	d.keepGoing = on
+}
 
-func GetDirectorySize(folder string) (int64, error) {
-	// Step 1: remember subdirectories we must recurse
-	var folders []string
-	// Step 2: read directory and handle errors.
-	dirRead, err := os.Open(folder)
+// Move the items into this directory instead of deleting them
+func (d *DirCleaner) SetBackupDir(dir string) {
+	d.backupDir = dir
+}
+
+// Overwrite the files with zeros before deleting them
+func (d *DirCleaner) SetShred(on bool) {
+	d.shred = on
+}
+
+// Where the notices of a dry run go
+func (d *DirCleaner) SetOutput(w io.Writer) {
+	d.out = w
+}
+
+// move an item of the root into the backup directory, returning its size
+func (d *DirCleaner) backup(ctx context.Context, dry *DryRun, walker *Walker, name string) (DiskSize, error) {
+	usage, err := walker.Usage(ctx, filepath.Join(d.Root, name))
 	if err != nil {
-		return 0, err
+		return DiskSize{}, err
 	}
-	dirFiles, err := dirRead.Readdir(0)
-	if err != nil {
-		return 0, err
+	if err := dry.MkDirAll(d.backupDir, 0o700); err != nil {
+		return DiskSize{}, err
+	}
+	if err := dry.MoveTree(filepath.Join(d.Root, name), filepath.Join(d.backupDir, name)); err != nil {
+		return DiskSize{}, err
 	}
+	return usage, nil
+}
 
-	// Step 3: sum up Size of all files in the directory.
-	sum := int64(0)
-	for _, fileHere := range dirFiles {
-		if fileHere.IsDir() {
-			// Size() returns the size of the directory entry not the actual
-			// sum of file sizes in that directory. Therefore, we skip it for later.
-			folders = append(folders, filepath.Join(folder, fileHere.Name()))
-		} else {
-			csize := fileHere.Size()
-			sum += csize
-			//fmt.Printf("%8d %5t %s\n", csize, fileHere.IsDir(), fileHere.Name())
-		}
+// keep a record of the nth item (of total) and tell the observers. An
+// item event without a record is not kept, i.e. EventItemStarted.
+func (d *DirCleaner) record(kind EventKind, item *ItemRecord, nth, total int) {
+	if kind != EventItemStarted {
+		d.items = append(d.items, item)
 	}
+	ev := NewItemEvent(kind, item)
+	ev.Items, ev.Total = nth, total
+	d.observers.Event(ev)
+}
+
+// record the items an interrupted CleanUp() did not get to. Their size
+// is not computed, the point is to stop as soon as possible.
+func (d *DirCleaner) skipRest(root string, entries []os.DirEntry, done, total int) {
+	for i, item := range entries {
+		d.record(EventItemSkipped, NewSkippedItem(filepath.Join(root, item.Name()), item.IsDir(), 0, RuleInterrupted), done+i+1, total)
+	}
+}
 
-	// Step 4: close directory and return the sum.
-	dirRead.Close()
+func (d *DirCleaner) CleanedSize() int64 {
+	return d.cleaned.Apparent
+}
 
-	// Step: 5: Iterate recursively through the directories we encountered
-	for _, dn := range folders {
-		folderSize, _ := GetDirectorySize(dn)
-		sum += folderSize
+// Apparent & allocated size removed by the last CleanUp()
+func (d *DirCleaner) CleanedUsage() DiskSize {
+	return d.cleaned
+}
+
+// Number of top-level items removed by the last CleanUp()
+func (d *DirCleaner) RemovedCount() int {
+	return d.removedQty
+}
+
+// Number of top-level items kept (exceptions) by the last CleanUp()
+func (d *DirCleaner) SkippedCount() int {
+	return d.skippedQty
+}
+
+// One record per top-level item processed by the last CleanUp()
+func (d *DirCleaner) Items() []*ItemRecord {
+	return d.items
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// size of a directory entry on the real filesystem. For directories that
+// is the sum of all its files.
+func itemSize(ctx context.Context, fullPath string, item os.DirEntry) int64 {
+	if item.IsDir() {
+		size, _ := GetDirectorySize(ctx, fullPath)
+		return size
+	}
+	if finfo, err := item.Info(); err == nil {
+		return finfo.Size()
 	}
-	return sum, nil
+	return 0
+}
+
+// Apparent size of a directory: the sum of the lengths of its files
+// (hardlinks counted once). See GetDirectoryUsage() for the allocated size.
+func GetDirectorySize(ctx context.Context, folder string) (int64, error) {
+	usage, err := GetDirectoryUsage(ctx, folder)
+	return usage.Apparent, err
 }
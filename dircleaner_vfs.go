@@ -7,7 +7,9 @@
 package wipechromium
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices" // GO v1.18
@@ -31,13 +33,19 @@ var _ IDirCleaner = (*DirCleanerVFS)(nil)
  *-----------------------------------------------------------------*/
 
 type DirCleanerVFS struct {
-	Root        string
-	cleanedSize int64
-	removedQty  int
-	skippedQty  int
-	sizeMode    SizeMode
-	logx        ILogger
-	vfs         vfs.Filesystem
+	Root       string
+	cleaned    DiskSize
+	removedQty int
+	skippedQty int
+	sizeMode   SizeMode
+	logx       *ConditionalLogger
+	vfs        vfs.Filesystem
+	items      []*ItemRecord
+	observers  Observers
+	keepGoing  bool
+	backupDir  string
+	// lists & sizes what it would remove but removes nothing
+	dry bool
 }
 
 /* ----------------------------------------------------------------
@@ -48,13 +56,13 @@ type DirCleanerVFS struct {
 // the selected Virtual File System instance.
 func NewDirCleanerVFS(fs vfs.Filesystem, root string, sizing SizeMode, logger ...ILogger) *DirCleanerVFS {
 	const cName = "DirCleanerVFS"
-	var logCtx ILogger
+	var logCtx *ConditionalLogger
 	if len(logger) == 0 {
 		logCtx = NewConditionalLogger(false, cName)
 	} else {
 		logCtx = logger[0].InheritAs(cName)
 	}
-	return &DirCleanerVFS{root, 0, 0, 0, sizing, logCtx, fs}
+	return &DirCleanerVFS{root, DiskSize{}, 0, 0, sizing, logCtx, fs, nil, Observers{}, false, "", false}
 }
 
 // NewDirCleanerDryVFS creates a new (recursive) directory cleaner instance with
@@ -75,16 +83,17 @@ func (d *DirCleanerVFS) String() string {
 	return fmt.Sprintf("DirCleaner %q del:%d skip:%d size:%s", d.Root,
 		d.removedQty,
 		d.skippedQty,
-		ReportByteCount(d.cleanedSize, d.sizeMode))
+		d.cleaned.Format(d.sizeMode))
 }
 
-func (d *DirCleanerVFS) CleanUp(exceptions []string) error {
-	d.cleanedSize = 0
+func (d *DirCleanerVFS) CleanUp(ctx context.Context, exceptions []string) error {
+	d.cleaned = DiskSize{}
 	d.removedQty = 0
 	d.skippedQty = 0
+	d.items = make([]*ItemRecord, 0)
 	entries, err := d.vfs.ReadDir(d.Root)
 	if err != nil {
-		d.logx.Print(err)
+		d.logx.Error("read dir", LogKeyPath, d.Root, LogKeyErr, err)
 		return err
 	}
 
@@ -98,82 +107,152 @@ func (d *DirCleanerVFS) CleanUp(exceptions []string) error {
 		return d.vfs.Remove(path)
 	}
 
-	for _, item := range entries {
+	execNothing := func(path string) error {
+		return nil
+	}
+
+	execBackup := func(path string) error {
+		if err := vfs.MkdirAll(d.vfs, d.backupDir, 0o700); err != nil {
+			return err
+		}
+		return d.vfs.Rename(path, filepath.Join(d.backupDir, filepath.Base(path)))
+	}
+
+	// file size or, for directories, the sum of its files
+	sizer := NewDiskSizer()
+	usageOf := func(fullPath string, item os.FileInfo) DiskSize {
+		usage := sizer.Of(item)
+		if item.IsDir() {
+			folderSize, _ := sizer.DirectoryVFS(d.vfs, fullPath)
+			usage.Add(folderSize)
+		}
+		return usage
+	}
+	sizeOf := func(fullPath string, item os.FileInfo) int64 {
+		if item.IsDir() {
+			folderSize, _ := GetDirectorySizeVFS(d.vfs, fullPath)
+			return folderSize
+		}
+		return item.Size()
+	}
+
+	var failures MultiError
+	total := len(entries)
+	for i, item := range entries {
+		if err := ctx.Err(); err != nil {
+			for j, rest := range entries[i:] {
+				d.record(EventItemSkipped, NewSkippedItem(filepath.Join(d.Root, rest.Name()), rest.IsDir(), 0, RuleInterrupted), i+j+1, total)
+			}
+			return failures.Final(err)
+		}
+
+		fullPath := filepath.Join(d.Root, item.Name())
 		if !slices.Contains(exceptions, item.Name()) {
-			if item.IsDir() {
+			rule := RuleWipe + "*"
+			if len(d.backupDir) != 0 {
+				rule = RuleBackup + d.backupDir
+			}
+			if d.dry {
+				executor = execNothing
+			} else if len(d.backupDir) != 0 {
+				executor = execBackup
+			} else if item.IsDir() {
 				executor = execRemoveRecursive
 			} else {
 				executor = execRemoveSingle
 			}
 
-			fullPath := filepath.Join(d.Root, item.Name())
+			d.record(EventItemStarted, &ItemRecord{Path: fullPath, IsDir: item.IsDir()}, i+1, total)
+			// sized before it is gone
+			usage := usageOf(fullPath, item)
 			if err := executor(fullPath); err != nil {
-				return err
-			} else {
-				if item.IsDir() {
-					// LIMITATION OF VFS SO FAR
-					folderSize, _ := GetDirectorySizeVFS(d.vfs, fullPath)
-					d.cleanedSize += folderSize
-				} else {
-					// get file size
-					d.cleanedSize += item.Size()
+				d.record(EventError, NewFailedItem(fullPath, item.IsDir(), usage.Apparent, rule, err), i+1, total)
+				if d.keepGoing {
+					failures.Add(NewItemError(fullPath, "remove", 0, err))
+					continue
 				}
+				return failures.Final(err)
+			} else {
+				d.cleaned.Add(usage)
+				d.record(EventItemDeleted, NewDeletedItem(fullPath, item.IsDir(), usage.Apparent, rule), i+1, total)
 			}
 
 			d.removedQty += 1
 		} else {
 			d.skippedQty += 1
-			d.logx.Printf("DirCleaner skipping %s", item.Name())
+			d.logx.Debug("kept", LogKeyPath, fullPath, "rule", RuleException+item.Name())
+			d.record(EventItemSkipped, NewKeptItem(fullPath, item.IsDir(), sizeOf(fullPath, item), RuleException+item.Name()), i+1, total)
 		}
 	}
-	return nil
+	return failures.Err()
+}
+
+// Follow the progress of CleanUp() item by item
+func (d *DirCleanerVFS) Subscribe(observers ...IObserver) {
+	d.observers.Subscribe(observers...)
+}
+
+// Go on with the other items after a failure
+func (d *DirCleanerVFS) SetKeepGoing(on bool) {
+	d.keepGoing = on
+}
+
+// Move the items into this directory (on the same filesystem) instead
+// of deleting them
+func (d *DirCleanerVFS) SetBackupDir(dir string) {
+	d.backupDir = dir
+}
+
+// There is nothing to shred on a virtual file system
+func (d *DirCleanerVFS) SetShred(on bool) {
+}
+
+// It has no notices, a dry run only lists & sizes
+func (d *DirCleanerVFS) SetOutput(w io.Writer) {
+}
+
+// keep a record of the nth item (of total) and tell the observers
+func (d *DirCleanerVFS) record(kind EventKind, item *ItemRecord, nth, total int) {
+	if kind != EventItemStarted {
+		d.items = append(d.items, item)
+	}
+	ev := NewItemEvent(kind, item)
+	ev.Items, ev.Total = nth, total
+	d.observers.Event(ev)
 }
 
 func (d *DirCleanerVFS) CleanedSize() int64 {
-	return d.cleanedSize
+	return d.cleaned.Apparent
+}
+
+// Apparent & allocated size removed by the last CleanUp()
+func (d *DirCleanerVFS) CleanedUsage() DiskSize {
+	return d.cleaned
+}
+
+// Number of top-level items removed by the last CleanUp()
+func (d *DirCleanerVFS) RemovedCount() int {
+	return d.removedQty
+}
+
+// Number of top-level items kept (exceptions) by the last CleanUp()
+func (d *DirCleanerVFS) SkippedCount() int {
+	return d.skippedQty
+}
+
+// One record per top-level item processed by the last CleanUp()
+func (d *DirCleanerVFS) Items() []*ItemRecord {
+	return d.items
 }
 
 /* ----------------------------------------------------------------
  *							F u n c t i o n s
  *-----------------------------------------------------------------*/
 
+// Apparent size of a directory in a Virtual File System
 func GetDirectorySizeVFS(fs vfs.Filesystem, folder string) (int64, error) {
-	// Step 1: remember subdirectories we must recurse
-	var folders []string
-	// Step 2: read directory and handle errors.
-	/*    dirRead, err := vfs.Open(fs, folder)
-	      if err != nil {
-	          panic(err)
-	      }*/
-	//dirFiles, err := dirRead.ReadDir(0)
-	dirFiles, err := fs.ReadDir(folder)
-	if err != nil {
-		return 0, err
-	}
-
-	// Step 3: sum up Size of all files in the directory.
-	sum := int64(0)
-	for _, fileHere := range dirFiles {
-		if fileHere.IsDir() {
-			// Size() returns the size of the directory entry not the actual
-			// sum of file sizes in that directory. Therefore, we skip it for later.
-			folders = append(folders, filepath.Join(folder, fileHere.Name()))
-		} else {
-			var csize int64 = fileHere.Size()
-			sum += csize
-			//fmt.Printf("%8d %5t %s\n", csize, fileHere.IsDir(), fileHere.Name())
-		}
-	}
-
-	// Step 4: close directory and return the sum.
-	//dirRead.Close()
-
-	// Step: 5: Iterate recursively through the directories we encountered
-	for _, dn := range folders {
-		folderSize, _ := GetDirectorySize(dn)
-		sum += folderSize
-	}
-	return sum, nil
+	usage, err := GetDirectoryUsageVFS(fs, folder)
+	return usage.Apparent, err
 }
 
 // Utility to replicate a real filesystem into the selected Virtual File System.
@@ -0,0 +1,163 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * On-disk usage: apparent vs. allocated size, hardlinks & sparse files
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lordofscripts/vfs"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// How much space something takes. Apparent is the sum of the file
+// lengths (what ls shows); Allocated is the sum of the blocks actually
+// allocated on disk (what du & df show) and therefore what is freed when
+// it is deleted. Sparse files are smaller on disk than they appear, small
+// files are larger.
+type DiskSize struct {
+	Apparent  int64 `json:"apparent"`
+	Allocated int64 `json:"allocated"`
+}
+
+// Sizes files & directories counting every device+inode only once, so
+// that hardlinked files are not counted twice. Use one DiskSizer for
+// everything that belongs to the same total. It is safe for concurrent use.
+type DiskSizer struct {
+	mu   sync.Mutex
+	seen map[fileID]bool
+}
+
+// identifies a file on a system: device & inode number
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func NewDiskSizer() *DiskSizer {
+	return &DiskSizer{seen: make(map[fileID]bool)}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Stringer interface
+func (s DiskSize) String() string {
+	return s.Format(SizeModeStd)
+}
+
+// Both sizes as per the size reporting mode, i.e. "1.2 MB (1.3 MB on disk)"
+func (s DiskSize) Format(mode SizeMode) string {
+	return fmt.Sprintf("%s (%s on disk)", ReportByteCount(s.Apparent, mode), ReportByteCount(s.Allocated, mode))
+}
+
+// Add another size to this one
+func (s *DiskSize) Add(other DiskSize) {
+	s.Apparent += other.Apparent
+	s.Allocated += other.Allocated
+}
+
+// Size of a single file. Directories only count the blocks of the
+// directory itself. Returns zero for a hardlink of a file already seen.
+// Filesystems that do not expose block counts (i.e. Windows or a VFS)
+// report the apparent size as allocated size.
+func (z *DiskSizer) Of(finfo os.FileInfo) DiskSize {
+	var size DiskSize
+	if !finfo.IsDir() {
+		size.Apparent = finfo.Size()
+	}
+	size.Allocated = size.Apparent
+
+	id, nlink, allocated, ok := statInfo(finfo)
+	if !ok {
+		return size
+	}
+	// NOTE: the link count drops as the other links are deleted, so
+	// whatever was seen before is always checked.
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.seen[id] {
+		return DiskSize{}
+	}
+	if nlink > 1 && !finfo.IsDir() {
+		z.seen[id] = true
+	}
+	size.Allocated = allocated
+	return size
+}
+
+// Recursive size of a directory including the directory itself.
+// Symbolic links are not followed. This is the sequential version, see
+// Walker for the concurrent one.
+func (z *DiskSizer) Directory(folder string) (DiskSize, error) {
+	finfo, err := os.Lstat(folder)
+	if err != nil {
+		return DiskSize{}, err
+	}
+	sum := z.Of(finfo)
+	if !finfo.IsDir() {
+		return sum, nil
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return sum, err
+	}
+	for _, entry := range entries {
+		fullPath := filepath.Join(folder, entry.Name())
+		if entry.IsDir() {
+			folderSize, _ := z.Directory(fullPath)
+			sum.Add(folderSize)
+		} else if finfo, err := entry.Info(); err == nil {
+			sum.Add(z.Of(finfo))
+		}
+	}
+	return sum, nil
+}
+
+// Recursive size of the contents of a directory in a Virtual File System.
+func (z *DiskSizer) DirectoryVFS(fs vfs.Filesystem, folder string) (DiskSize, error) {
+	var sum DiskSize
+	entries, err := fs.ReadDir(folder)
+	if err != nil {
+		return sum, err
+	}
+	for _, entry := range entries {
+		sum.Add(z.Of(entry))
+		if entry.IsDir() {
+			folderSize, _ := z.DirectoryVFS(fs, filepath.Join(folder, entry.Name()))
+			sum.Add(folderSize)
+		}
+	}
+	return sum, nil
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Apparent & allocated size of a directory (or file) with hardlinks
+// counted once. It is walked concurrently with WalkerJobs goroutines.
+func GetDirectoryUsage(ctx context.Context, folder string) (DiskSize, error) {
+	return NewWalker(WalkerJobs).Usage(ctx, folder)
+}
+
+// Same as GetDirectoryUsage() but on a Virtual File System.
+func GetDirectoryUsageVFS(fs vfs.Filesystem, folder string) (DiskSize, error) {
+	return NewDiskSizer().DirectoryVFS(fs, folder)
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * On-disk usage where the stat blocks are not available
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"os"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// No block counts nor inodes here: sizes are apparent sizes.
+func statInfo(finfo os.FileInfo) (fileID, uint64, int64, bool) {
+	return fileID{}, 0, 0, false
+}
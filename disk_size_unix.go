@@ -0,0 +1,28 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Unix-specific on-disk usage (stat blocks, device & inode)
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"os"
+	"syscall"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Device+inode, number of hardlinks and allocated bytes of a file.
+// st_blocks is always in 512-byte units regardless of the block size.
+func statInfo(finfo os.FileInfo) (fileID, uint64, int64, bool) {
+	st, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return fileID{}, 0, 0, false
+	}
+	return fileID{uint64(st.Dev), uint64(st.Ino)}, uint64(st.Nlink), int64(st.Blocks) * 512, true
+}
@@ -0,0 +1,221 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * A du-style analyzer for browser profile data
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A file or directory in the disk usage tree. A directory's size is the
+// sum of everything underneath it.
+type DUNode struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	IsDir    bool       `json:"is_dir"`
+	Size     int64      `json:"size"`
+	Category Category   `json:"category"`
+	Verdict  ItemAction `json:"verdict"`
+	Rule     string     `json:"rule"`
+	Children []*DUNode  `json:"children,omitempty"`
+	// number of (smaller) children left out by Prune()
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// The disk usage of a browser user profile (data & cache)
+type DiskUsageReport struct {
+	Browser    string               `json:"browser"`
+	Profile    string               `json:"profile"`
+	Depth      int                  `json:"depth"`
+	Top        int                  `json:"top"`
+	Roots      []*DUNode            `json:"roots"`
+	ByCategory map[Category]int64   `json:"by_category"`
+	ByVerdict  map[ItemAction]int64 `json:"by_verdict"`
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// A disk usage report of the given (full) trees. The totals are computed
+// before they are pruned to the top (largest) entries per directory.
+func NewDiskUsageReport(browser, profile string, depth, top int, roots ...*DUNode) *DiskUsageReport {
+	r := &DiskUsageReport{
+		Browser:    browser,
+		Profile:    profile,
+		Depth:      depth,
+		Top:        top,
+		Roots:      make([]*DUNode, 0, len(roots)),
+		ByCategory: make(map[Category]int64),
+		ByVerdict:  make(map[ItemAction]int64),
+	}
+	for _, root := range roots {
+		root.Tally(r.ByCategory, r.ByVerdict)
+		r.Roots = append(r.Roots, root.Prune(top))
+	}
+	return r
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// A copy of the tree with at most top (largest) children per directory.
+// Zero means no limit.
+func (n *DUNode) Prune(top int) *DUNode {
+	clone := *n
+	clone.Children = nil
+	for i, child := range n.Children {
+		if top > 0 && i >= top {
+			clone.Omitted = len(n.Children) - top
+			break
+		}
+		clone.Children = append(clone.Children, child.Prune(top))
+	}
+	return &clone
+}
+
+// Total size of this subtree per category & verdict. Only leaves (files
+// and directories that were not expanded) are counted.
+func (n *DUNode) Tally(byCategory map[Category]int64, byVerdict map[ItemAction]int64) {
+	if len(n.Children) == 0 {
+		byCategory[n.Category] += n.Size
+		byVerdict[n.Verdict] += n.Size
+		return
+	}
+	var sum int64
+	for _, child := range n.Children {
+		child.Tally(byCategory, byVerdict)
+		sum += child.Size
+	}
+	// files directly in a directory that is only partially expanded
+	if rest := n.Size - sum; rest > 0 {
+		byCategory[n.Category] += rest
+		byVerdict[n.Verdict] += rest
+	}
+}
+
+// Render the tree as indented text
+func (n *DUNode) Render(w io.Writer, sizing SizeMode) {
+	n.render(w, sizing, "")
+}
+
+func (n *DUNode) render(w io.Writer, sizing SizeMode, indent string) {
+	mark := "✔" // keep
+	if n.Verdict == ItemDeleted {
+		mark = "✘"
+	}
+	name := n.Name
+	if n.IsDir {
+		name += string(os.PathSeparator)
+	}
+	fmt.Fprintf(w, "%12s %s %-15s %s%s\n", ReportByteCount(n.Size, sizing), mark, n.Category, indent, name)
+	for _, child := range n.Children {
+		child.render(w, sizing, indent+"  ")
+	}
+	if n.Omitted > 0 {
+		fmt.Fprintf(w, "%12s   %-15s %s  … %d more\n", "", "", indent, n.Omitted)
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Build the disk usage tree of root. Directories are expanded down to
+// maxDepth levels (0 is unlimited); below that their size is summed up.
+// Every node is classified and children are sorted largest first.
+// Symbolic links are not followed.
+func AnalyzeDiskUsage(root string, kind RootKind, classifier IClassifier, maxDepth int) (*DUNode, error) {
+	finfo, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &DUNode{Name: filepath.Base(root), Path: root, IsDir: finfo.IsDir()}
+	node.Category, node.Verdict, node.Rule = classifier.Classify(kind, "", node.IsDir)
+	if !node.IsDir {
+		node.Size = finfo.Size()
+		return node, nil
+	}
+
+	err = analyzeDir(node, "", kind, classifier, maxDepth)
+	return node, err
+}
+
+func analyzeDir(parent *DUNode, rel string, kind RootKind, classifier IClassifier, depth int) error {
+	entries, err := os.ReadDir(parent.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childRel := filepath.Join(rel, entry.Name())
+		child := &DUNode{
+			Name:  entry.Name(),
+			Path:  filepath.Join(parent.Path, entry.Name()),
+			IsDir: entry.IsDir(),
+		}
+		child.Category, child.Verdict, child.Rule = classifier.Classify(kind, childRel, child.IsDir)
+
+		if child.IsDir {
+			if depth == 1 {
+				child.Size, _ = GetDirectorySize(context.Background(), child.Path)
+			} else if err := analyzeDir(child, childRel, kind, classifier, depth-1); err != nil {
+				return err
+			}
+		} else if finfo, err := entry.Info(); err == nil {
+			child.Size = finfo.Size()
+		}
+
+		parent.Size += child.Size
+		parent.Children = append(parent.Children, child)
+	}
+
+	sort.SliceStable(parent.Children, func(i, j int) bool {
+		return parent.Children[i].Size > parent.Children[j].Size
+	})
+	return nil
+}
+
+// Render a disk usage report as text: one tree per root followed by
+// the totals per category and per verdict.
+func RenderDiskUsage(w io.Writer, r *DiskUsageReport, sizing SizeMode) {
+	fmt.Fprintf(w, "❋✦ %s %q disk usage:\n", r.Browser, r.Profile)
+	for _, root := range r.Roots {
+		fmt.Fprintf(w, "\n%s\n", root.Path)
+		root.Render(w, sizing)
+	}
+
+	fmt.Fprintln(w, "\nBy category:")
+	for _, cat := range Categories() {
+		if size, ok := r.ByCategory[cat]; ok && size > 0 {
+			fmt.Fprintf(w, "%12s   %s\n", ReportByteCount(size, sizing), cat)
+		}
+	}
+	fmt.Fprintln(w, "By verdict:")
+	fmt.Fprintf(w, "%12s ✘ %s\n", ReportByteCount(r.ByVerdict[ItemDeleted], sizing), "would be deleted")
+	fmt.Fprintf(w, "%12s ✔ %s\n", ReportByteCount(r.ByVerdict[ItemKept], sizing), "would be kept")
+}
+
+// Helper for IClassifier implementations: the top-level component (the
+// first element) of a relative path.
+func TopLevel(relPath string) string {
+	if idx := strings.IndexRune(relPath, os.PathSeparator); idx > -1 {
+		return relPath[:idx]
+	}
+	return relPath
+}
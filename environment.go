@@ -0,0 +1,217 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Whose browsers: a home directory, its base directories & filesystem
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/lordofscripts/vfs"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Where the browsers of a user keep their data: the home directory, the
+// base directories the browsers put their data & caches in and the
+// filesystem it is all on. The cleaners find & probe everything through
+// it, so that they work just as well on another user's home, a fixture in
+// a temporary directory or an in-memory filesystem.
+type Environment struct {
+	Home string
+	// Linux: $XDG_CONFIG_HOME (~/.config) MacOS: ~/Library/Application Support
+	// Windows: %AppData%
+	ConfigHome string
+	// Linux: $XDG_CACHE_HOME (~/.cache) MacOS: ~/Library/Caches
+	// Windows: %LocalAppData%
+	CacheHome string
+	FS        vfs.Filesystem
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// The environment of a home directory on the real filesystem. Without a
+// home it is that of the current user, XDG variables included. Those do
+// not apply to anybody else's home, for it they are the defaults.
+func NewEnvironment(home string) (*Environment, error) {
+	if len(home) != 0 {
+		if finfo, err := os.Stat(home); err != nil {
+			return nil, err
+		} else if !finfo.IsDir() {
+			return nil, fmt.Errorf("Not a directory: %s", home)
+		}
+		return NewEnvironmentVFS(vfs.OS(), home), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	env := NewEnvironmentVFS(vfs.OS(), home)
+	if dir, err := os.UserConfigDir(); err == nil {
+		env.ConfigHome = dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		env.CacheHome = dir
+	}
+	return env, nil
+}
+
+// The environment of a home directory on any filesystem, i.e. an
+// in-memory one, with the default base directories of the platform.
+func NewEnvironmentVFS(fs vfs.Filesystem, home string) *Environment {
+	home = filepath.Clean(home)
+	var configHome, cacheHome string
+	switch runtime.GOOS {
+	case "windows":
+		configHome = filepath.Join(home, "AppData", "Roaming")
+		cacheHome = filepath.Join(home, "AppData", "Local")
+	case "darwin":
+		configHome = filepath.Join(home, "Library", "Application Support")
+		cacheHome = filepath.Join(home, "Library", "Caches")
+	default:
+		configHome = filepath.Join(home, ".config")
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return &Environment{home, configHome, cacheHome, fs}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// The path of something in the home directory, i.e. AtHome(".mozilla", "firefox")
+func (e *Environment) AtHome(parts ...string) string {
+	return filepath.Join(append([]string{e.Home}, parts...)...)
+}
+
+// The path with the home directory replaced by ~ (if it is in it)
+func (e *Environment) FromHome(path string) string {
+	if rel, ok := Within(e.Home, path); ok {
+		if rel == "." {
+			return "~"
+		}
+		return "~" + string(filepath.Separator) + rel
+	}
+	return path
+}
+
+// Whether it is the real filesystem. The OS-only features (shredding,
+// moving to a backup on another disk, lock detection) depend on it.
+func (e *Environment) IsOS() bool {
+	_, ok := e.FS.(*vfs.OsFS)
+	return ok
+}
+
+// Check whether path exists and it is a directory
+func (e *Environment) IsDirectory(path string) bool {
+	if finfo, err := e.FS.Stat(path); err == nil {
+		return finfo.IsDir()
+	}
+	return false
+}
+
+// Yes if path is a file, No if it is a directory, else Undecided
+func (e *Environment) IsFile(path string) TriState {
+	if finfo, err := e.FS.Stat(path); err == nil {
+		if !finfo.IsDir() {
+			return Yes
+		}
+		return No
+	}
+	return Undecided
+}
+
+// The entries of a directory sorted by name
+func (e *Environment) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := e.FS.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b os.FileInfo) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+func (e *Environment) ReadFile(path string) ([]byte, error) {
+	return vfs.ReadFile(e.FS, path)
+}
+
+func (e *Environment) Lstat(path string) (os.FileInfo, error) {
+	return e.FS.Lstat(path)
+}
+
+// The entries of dir matching a shell pattern (see filepath.Match), like
+// filepath.Glob() with a pattern on the last element only.
+func (e *Environment) Glob(dir, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	entries, err := e.ReadDir(dir)
+	if err != nil {
+		// no directory, no matches (as with filepath.Glob)
+		return nil, nil
+	}
+	matches := make([]string, 0)
+	for _, entry := range entries {
+		if ok, _ := filepath.Match(pattern, entry.Name()); ok {
+			matches = append(matches, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return matches, nil
+}
+
+// Apparent & allocated size of a directory tree (see GetDirectoryUsage)
+func (e *Environment) DirectoryUsage(ctx context.Context, path string) (DiskSize, error) {
+	if e.IsOS() {
+		return GetDirectoryUsage(ctx, path)
+	}
+	return GetDirectoryUsageVFS(e.FS, path)
+}
+
+// The lock of a running browser among names in dir, if any. Only the
+// real filesystem has running browsers.
+func (e *Environment) HeldLock(dir string, names ...string) string {
+	if !e.IsOS() {
+		return ""
+	}
+	return HeldLock(dir, names...)
+}
+
+// The file operations of a wipe: printed only on a dry run, else carried
+// out on this filesystem. A dry run still sizes what it would remove here.
+func (e *Environment) DryRunner(dry bool, w io.Writer) *DryRun {
+	runner := NewDryRunner()
+	runner.SetOutput(w)
+	switch {
+	case dry && !e.IsOS():
+		runner.SizeOn(e.FS)
+	case !dry && e.IsOS():
+		runner.Disable()
+	case !dry:
+		runner.EnableOn(e.FS)
+	}
+	return runner
+}
+
+// The cleaner of a profile's top level on this filesystem
+func (e *Environment) DirCleaner(root string, sizing SizeMode, dry bool, logger ...ILogger) IDirCleaner {
+	if e.IsOS() {
+		return NewDirCleaner(root, sizing, dry, logger...)
+	}
+	cleaner := NewDirCleanerVFS(e.FS, root, sizing, logger...)
+	cleaner.dry = dry
+	return cleaner
+}
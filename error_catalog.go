@@ -0,0 +1,139 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The catalog of error & exit codes. These codes are stable, scripts
+ * depend on them: add new ones but never renumber.
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	ExitOK = 0
+	// command-line problems
+	ExitUsage           = 1
+	ExitBadBrowser      = 2
+	ExitBadSizeMode     = 3
+	ExitCleanerFailure  = 4
+	ExitBadOutput       = 5
+	ExitBadReportFormat = 6
+	ExitBadConfig       = 7
+	ExitPolicy          = 8
+	ExitNotConfirmed    = 9
+	ExitNotRoot         = 10
+	// the profile can't be wiped (nothing was touched)
+	ExitNoProfile     = 40
+	ExitProfileInUse  = 42
+	ExitNoSuchProfile = 43
+	ExitNotCache      = 44
+	ExitNotProfile    = 45
+	// removal failures
+	ExitCacheRemove     = 41
+	ExitCachePhase      = 50
+	ExitProfileErase    = 51
+	ExitProfilePhase    = 60
+	ExitExtensionsPhase = 70
+	// exit code when --keep-going removed some (but not all) of the items
+	ExitPartial = 75
+	// exit code when the --timeout expired (same as timeout(1))
+	ExitTimeout = 124
+	// exit code when stopped by SIGINT/SIGTERM (128 + SIGINT)
+	ExitInterrupted = 130
+)
+
+const (
+	ErrorNone        ErrorCategory = ""
+	ErrorUsage       ErrorCategory = "usage"       // fix the command line
+	ErrorEnvironment ErrorCategory = "environment" // browser/profile state
+	ErrorFilesystem  ErrorCategory = "filesystem"  // could not remove
+	ErrorPartial     ErrorCategory = "partial"     // some removed, some not
+	ErrorStopped     ErrorCategory = "stopped"     // signal or timeout
+)
+
+var errorCatalog = []*ErrorInfo{
+	{ExitOK, ErrorNone, nil, "Success", ""},
+	{ExitUsage, ErrorUsage, ErrUsage, "Invalid command-line arguments", "Run wiper --help"},
+	{ExitBadBrowser, ErrorUsage, ErrUnsupportedBrowser, "Unsupported browser", "Use --browser Chromium or Firefox"},
+	{ExitBadSizeMode, ErrorUsage, ErrBadSizeMode, "Invalid size mode", "Use --size Std, SI or IEC"},
+	{ExitCleanerFailure, ErrorEnvironment, ErrCleanerFailure, "Browser cleaner could not be set up", "Check the browser directories with wiper scan"},
+	{ExitBadOutput, ErrorUsage, ErrUnknownOutputFormat, "Unknown output format or the output could not be written", "Use --output human, json or ndjson (and check where stdout goes)"},
+	{ExitBadReportFormat, ErrorUsage, ErrUnknownReportFormat, "Unknown report format", "Use --report-format table, csv or html"},
+	{ExitBadConfig, ErrorUsage, ErrBadConfig, "Invalid config file or WIPER_* variable", "See what is wrong with wiper config show"},
+	{ExitPolicy, ErrorUsage, ErrPolicyViolation, "The settings conflict with the system policy", "See the conflicts with wiper policy check -b BROWSER -n PROFILE"},
+	{ExitNotConfirmed, ErrorUsage, ErrNotConfirmed, "The wipe was not confirmed (nothing was removed)", "Answer the prompt, or give --yes when not run from a terminal (wiper schedule list adds it to old units)"},
+	{ExitNotRoot, ErrorUsage, ErrNotRoot, "--all-users needs root (nothing was touched)", "Run it with sudo, or without --all-users for your own browsers"},
+	{ExitNoProfile, ErrorUsage, ErrNoProfile, "No profile given", "Give one with --name, wiper scan lists them"},
+	{ExitCacheRemove, ErrorFilesystem, ErrCacheRemove, "Could not remove the cache directory", "Check the permissions of the failed items or use --keep-going"},
+	{ExitProfileInUse, ErrorEnvironment, ErrProfileInUse, "The browser is using the profile", "Close the browser and try again"},
+	{ExitNoSuchProfile, ErrorEnvironment, ErrProfileDoesNotExist, "No such profile", "wiper scan lists the profiles"},
+	{ExitNotCache, ErrorEnvironment, ErrNotBrowserCache, "Not a browser cache directory", "Check the cache location with wiper scan"},
+	{ExitNotProfile, ErrorEnvironment, ErrNotBrowserProfile, "Not a browser profile directory", "Check the profile location with wiper scan"},
+	{ExitCachePhase, ErrorFilesystem, nil, "Clearing the cache failed", "Run with --log for details"},
+	{ExitProfileErase, ErrorFilesystem, ErrProfileErase, "Could not erase the profile", "Check the permissions of the failed items or use --keep-going"},
+	{ExitProfilePhase, ErrorFilesystem, nil, "Erasing the profile failed", "Run with --log for details"},
+	{ExitExtensionsPhase, ErrorFilesystem, nil, "Clearing extension junk failed", "Run with --log for details"},
+	{ExitPartial, ErrorPartial, nil, "Some items could not be removed (-keep-going)", "See the failures listed in the report"},
+	{ExitTimeout, ErrorStopped, context.DeadlineExceeded, "Timed out (--timeout)", "Allow more time or use --jobs for huge profiles"},
+	{ExitInterrupted, ErrorStopped, context.Canceled, "Interrupted by SIGINT/SIGTERM", "Run it again, it picks up what is left"},
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// What sort of problem an error code is about
+type ErrorCategory string
+
+// A catalog entry
+type ErrorInfo struct {
+	Code     int           `json:"code"`
+	Category ErrorCategory `json:"category"`
+	// errors.Is(err, Sentinel) for a typed Error with this code
+	Sentinel error  `json:"-"`
+	Summary  string `json:"summary"`
+	Hint     string `json:"hint"`
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// All the error & exit codes in ascending order
+func ErrorCatalog() []*ErrorInfo {
+	result := slices.Clone(errorCatalog)
+	slices.SortFunc(result, func(a, b *ErrorInfo) int {
+		return a.Code - b.Code
+	})
+	return result
+}
+
+// The catalog entry of a code or nil if it is not a known code
+func LookupError(code int) *ErrorInfo {
+	for _, info := range errorCatalog {
+		if info.Code == code {
+			return info
+		}
+	}
+	return nil
+}
+
+// The exit code table as shown by "wiper help exit-codes"
+func WriteExitCodes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CODE\tCATEGORY\tMEANING\tWHAT TO DO")
+	for _, info := range ErrorCatalog() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", info.Code, info.Category, info.Summary, info.Hint)
+	}
+	return tw.Flush()
+}
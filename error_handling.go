@@ -7,6 +7,7 @@
 package wipechromium
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
@@ -17,6 +18,7 @@ import (
 /* ----------------------------------------------------------------
  *							G l o b a l s
  *-----------------------------------------------------------------*/
+
 var (
 	ErrUnsupportedBrowser  = errors.New("Unsupported browser")
 	ErrNotBrowserCache     = errors.New("Not a browser cache directory")
@@ -24,30 +26,124 @@ var (
 	ErrNoProfile           = errors.New("Browser user profile not given")
 	ErrCleanerFailure      = errors.New("Browser cleaner instantiation error")
 	ErrProfileDoesNotExist = errors.New("Profile does not exist!")
+	ErrProfileInUse        = errors.New("Browser is using the profile")
+	ErrUsage               = errors.New("Invalid command-line arguments")
+	ErrBadSizeMode         = errors.New("Invalid size mode (SI|IEC|STD)")
+	ErrCacheRemove         = errors.New("Could not remove cache dir")
+	ErrProfileErase        = errors.New("EraseProfile fault")
+	ErrBadSchedule         = errors.New("Invalid schedule")
+	ErrBadCron             = errors.New("Invalid cron expression")
+	ErrBadConfig           = errors.New("Invalid configuration")
+	ErrPolicyViolation     = errors.New("Not allowed by the system policy")
+	ErrBadPlan             = errors.New("Not a usable wipe plan")
+	ErrNoBackup            = errors.New("No backup of the profile")
+	ErrNotConfirmed        = errors.New("The wipe was not confirmed")
+	ErrNotRoot             = errors.New("Only root can do it for all the users")
+
+	_ error = (*Error)(nil)
 )
 
 /* ----------------------------------------------------------------
  *							T y p e s
  *-----------------------------------------------------------------*/
 
+// An application error with a stable code (see the catalog) telling
+// what went wrong, where and what to do about it. errors.Is() matches
+// it against the sentinel of its code as well as against its cause.
+type Error struct {
+	Code     int
+	Category ErrorCategory
+	Op       string // i.e. remove, identify
+	Path     string
+	Message  string
+	Hint     string
+	Err      error // the cause, if any
+}
+
 /* ----------------------------------------------------------------
  *							C o n s t r u c t o r s
  *-----------------------------------------------------------------*/
 
+// An error with the given code about op on path. Category, message &
+// hint come from the catalog.
+func NewError(code int, op, path string, cause error) *Error {
+	e := &Error{Code: code, Op: op, Path: path, Err: cause}
+	if info := LookupError(code); info != nil {
+		e.Category, e.Message, e.Hint = info.Category, info.Summary, info.Hint
+	}
+	return e
+}
+
 /* ----------------------------------------------------------------
  *							M e t h o d s
  *-----------------------------------------------------------------*/
 
+// Implements error: E-041 remove /path: message: cause
+func (e *Error) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "E-%03d ", e.Code)
+	if len(e.Op) != 0 {
+		sb.WriteString(e.Op + " ")
+	}
+	if len(e.Path) != 0 {
+		sb.WriteString(e.Path + ": ")
+	}
+	sb.WriteString(e.Message)
+	if e.Err != nil && e.Err.Error() != e.Message {
+		sb.WriteString(": " + e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errors.Is(err, ErrProfileInUse) holds for any error with that code
+func (e *Error) Is(target error) bool {
+	info := LookupError(e.Code)
+	return info != nil && info.Sentinel != nil && info.Sentinel == target
+}
+
 /* ----------------------------------------------------------------
  *							F u n c t i o n s
  *-----------------------------------------------------------------*/
 
-// wrap two errors into one (as of GO v1.13)
-// Example: wrapError(err, "Unable to remove file %s", filename)
+// wrap an error into a typed one with the given code & message. Its
+// category & hint come from the catalog.
+// Example: WrapError(err, ExitCacheRemove, "Unable to remove %s", filename)
 func WrapError(err error, code int, msgformat string, v ...any) error {
-	errP := fmt.Errorf(msgformat, v...)
-	errC := fmt.Errorf("Error E-%03d: %w...\n\tInner: %w", code, errP, err)
-	return errC
+	e := NewError(code, "", "", err)
+	e.Message = fmt.Sprintf(msgformat, v...)
+	return e
+}
+
+// The exit code of an error: that of an interruption (cancelled context)
+// or a timeout (deadline exceeded), else that of the first typed Error
+// or known sentinel in it. Any other error keeps the given code.
+func ExitCode(err error, code int) int {
+	var typed *Error
+	switch {
+	case err == nil:
+		return code
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.As(err, &typed):
+		return typed.Code
+	}
+	for _, info := range errorCatalog {
+		if info.Sentinel != nil && errors.Is(err, info.Sentinel) {
+			return info.Code
+		}
+	}
+	return code
+}
+
+// Whether the error is due to an interruption or timeout
+func IsInterruption(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
 }
 
 // ThisLocation() returns a string with source code location information that
@@ -11,7 +11,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"slices"
+	"time"
 )
 
 /* ----------------------------------------------------------------
@@ -28,14 +29,13 @@ import (
 
 // AtHome gets HOME directory and return the path of fileOrDir right underneath.
 // If HOME is /home/toering then AtHome(".config") returns /home/toering/.config
-func AtHome(fileOrDir string) string {
-	atHome := ""
-	if home, err := os.UserHomeDir(); err == nil {
-		atHome = filepath.Join(home, fileOrDir)
-	} else {
-		log.Fatal("Could not get HOME directory")
+// The browsers use their Environment.AtHome() instead.
+func AtHome(fileOrDir string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
 	}
-	return atHome
+	return filepath.Join(home, fileOrDir), nil
 }
 
 // FromHome() takes a path and if it starts with the HOME directory, it is
@@ -43,13 +43,37 @@ func AtHome(fileOrDir string) string {
 func FromHome(fileOrDir string) string {
 	atHome := fileOrDir
 	if home, err := os.UserHomeDir(); err == nil {
-		if strings.HasPrefix(fileOrDir, home) { // replace by ~/ notation
-			atHome = "~" + strings.TrimPrefix(fileOrDir, home)
+		if rel, ok := Within(home, fileOrDir); ok { // replace by ~/ notation
+			atHome = "~"
+			if rel != "." {
+				atHome += string(filepath.Separator) + rel
+			}
 		}
 	}
 	return atHome
 }
 
+// The path relative to root ("." for root itself) if it is root or
+// something under it, i.e. not /home/alice2 for /home/alice.
+func Within(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// Join the parts to root, if what they make is root or something under
+// it: no absolute part nor ".." taking it out. The paths read from the
+// browser's files (i.e. Firefox's profiles.ini) go through it.
+func JoinWithin(root string, parts ...string) (string, bool) {
+	rel := filepath.Join(parts...)
+	if len(rel) != 0 && !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.Join(root, rel), true
+}
+
 // Check whether path exists and it is a directory
 func IsDirectory(path string) bool {
 	if finfo, err := os.Stat(path); err == nil {
@@ -118,6 +142,24 @@ func MoveFileTo(src, dest string, notify bool) error {
 	return MoveFile(src, dest, true)
 }
 
+// Where a wipe started at t backs up what it removes from a profile.
+// Example: BackupPath("/bak", "Chromium", "Work", t) is /bak/Chromium/Work/20240501-120000
+func BackupPath(dir, browser, profile string, t time.Time) string {
+	return filepath.Join(dir, browser, profile, t.Format("20060102-150405"))
+}
+
+// Names of the entries of dir other than the given ones
+func NamesBesides(dir string, names []string) []string {
+	entries, _ := os.ReadDir(dir)
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !slices.Contains(names, entry.Name()) {
+			result = append(result, entry.Name())
+		}
+	}
+	return result
+}
+
 // Example: changePath("/home/pi/test.sh", "/tmp/anydir")
 func ChangePath(src, dest string) string {
 	base := filepath.Base(src)
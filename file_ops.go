@@ -7,8 +7,10 @@
 package wipechromium
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -56,6 +58,9 @@ type DryRun struct {
 	vfs     vfs.Filesystem
 	actions *FileActions
 	queries *FileQueries
+	out     io.Writer
+	// a dry run of a virtual file system sizes what it would remove there
+	sizer vfs.Filesystem
 }
 
 // File/Directory object actions on a filesystem (real or not)
@@ -80,7 +85,7 @@ type FileQueries struct {
 // replaced by NoOps which simply print what would have been done. So, instead
 // of using os.RemoveAll() use dr.RemoveAll() after creating dr := NewDryRunner()
 func NewDryRunner() *DryRun {
-	dr := &DryRun{mode: DryRunTargetNOP, vfs: nil, actions: nil, queries: nil}
+	dr := &DryRun{mode: DryRunTargetNOP, vfs: nil, actions: nil, queries: nil, out: os.Stdout, sizer: nil}
 	dr.Enable()
 	return dr
 }
@@ -160,6 +165,23 @@ func (d *DryRun) Enable() {
 	d.actions, d.queries = d.getNopMapping()
 }
 
+// A DRY run that sizes what it would remove on the selected virtual
+// filesystem (instead of the real one)
+func (d *DryRun) SizeOn(afs vfs.Filesystem) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sizer = afs
+}
+
+// Where the NOP (dry) notices are written to. Defaults to stdout.
+func (d *DryRun) SetOutput(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.out = w
+}
+
 // Delete a directory and all its children action
 func (d *DryRun) RemoveAll(path string) error {
 	return d.actions.ActionRemoveAll(path)
@@ -185,6 +207,47 @@ func (d *DryRun) Rename(oldpath, newpath string) error {
 	return d.actions.ActionRename(oldpath, newpath)
 }
 
+// Delete a file or directory tree and return its size. On the real OS
+// it is sized & deleted in one pass by the (concurrent) walker; in a dry
+// run it is only sized. On error the size is that of what was removed.
+func (d *DryRun) RemoveAllSized(ctx context.Context, w *Walker, path string) (DiskSize, error) {
+	switch d.GetMode() {
+	case DryRunTargetOS:
+		return w.RemoveAll(ctx, path)
+	case DryRunTargetVFS:
+		size, _ := GetDirectoryUsageVFS(d.vfs, path)
+		return size, d.RemoveAll(path)
+	default:
+		if d.sizer != nil {
+			size, _ := GetDirectoryUsageVFS(d.sizer, path)
+			return size, d.RemoveAll(path)
+		}
+		size, _ := w.Usage(ctx, path)
+		if err := ctx.Err(); err != nil {
+			return size, err
+		}
+		return size, d.RemoveAll(path)
+	}
+}
+
+// Move a file or directory tree. On the real OS it also works across
+// filesystems (see MoveTree), elsewhere it is a rename.
+func (d *DryRun) MoveTree(oldpath, newpath string) error {
+	if d.GetMode() == DryRunTargetOS {
+		return MoveTree(oldpath, newpath)
+	}
+	return d.Rename(oldpath, newpath)
+}
+
+// Overwrite the files of a tree before it is removed (see ShredTree).
+// Only the real OS has anything to shred.
+func (d *DryRun) Shred(ctx context.Context, root string) error {
+	if d.GetMode() == DryRunTargetOS {
+		return ShredTree(ctx, root)
+	}
+	return nil
+}
+
 // helper to alias the VFS method to the Action* signature
 func (d *DryRun) RemoveAllVFS(path string) error {
 	d.mu.Lock()
@@ -362,31 +425,31 @@ func (d *DryRun) getNopMapping() (*FileActions, *FileQueries) {
 
 // NOP equivalent of os.RemoveAll()
 func (d *DryRun) dryRemoveAll(path string) error {
-	fmt.Printf("\t%c os.RemoveAll %s\n", CHR_HIGHVOLTAGE, FromHome(path))
+	fmt.Fprintf(d.out, "\t%c os.RemoveAll %s\n", CHR_HIGHVOLTAGE, FromHome(path))
 	return nil
 }
 
 // NOP equivalent of os.Remove()
 func (d *DryRun) dryRemove(path string) error {
-	fmt.Printf("\t%c os.Remove %s\n", CHR_HIGHVOLTAGE, FromHome(path))
+	fmt.Fprintf(d.out, "\t%c os.Remove %s\n", CHR_HIGHVOLTAGE, FromHome(path))
 	return nil
 }
 
 // NOP equivalent of os.MkdirAll()
 func (d *DryRun) dryMkDirAll(name string, perm os.FileMode) error {
-	fmt.Printf("\t%c os.MkdirAll %s %O\n", CHR_HIGHVOLTAGE, FromHome(name), perm)
+	fmt.Fprintf(d.out, "\t%c os.MkdirAll %s %O\n", CHR_HIGHVOLTAGE, FromHome(name), perm)
 	return nil
 }
 
 // NOP equivalent of os.Mkdir()
 func (d *DryRun) dryMkDir(name string, perm os.FileMode) error {
-	fmt.Printf("\t%c os.Mkdir %s %O\n", CHR_HIGHVOLTAGE, FromHome(name), perm)
+	fmt.Fprintf(d.out, "\t%c os.Mkdir %s %O\n", CHR_HIGHVOLTAGE, FromHome(name), perm)
 	return nil
 }
 
 // NOP equivalent of os.Rename()
 func (d *DryRun) dryRename(oldpath, newpath string) error {
-	fmt.Printf("\t%c os.Rename %s -> %s\n", CHR_HIGHVOLTAGE, FromHome(oldpath), FromHome(newpath))
+	fmt.Fprintf(d.out, "\t%c os.Rename %s -> %s\n", CHR_HIGHVOLTAGE, FromHome(oldpath), FromHome(newpath))
 	return nil
 }
 
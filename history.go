@@ -0,0 +1,331 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Run history: every completed wipe, and what it tells us over time
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// The most recent failed runs shown by the statistics
+const MaxFailedRuns = 10
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// One completed wipe as kept in the history (a JSON line)
+type RunRecord struct {
+	Time      time.Time `json:"time"`
+	Browser   string    `json:"browser"`
+	Profile   string    `json:"profile"`
+	Phases    []string  `json:"phases"`
+	Bytes     int64     `json:"bytes"`
+	Allocated int64     `json:"allocated_bytes"`
+	Items     int       `json:"items"`
+	// what the cache phase freed, i.e. how much it grew since the last run
+	CacheBytes int64              `json:"cache_bytes"`
+	Categories map[Category]int64 `json:"categories,omitempty"`
+	Duration   time.Duration      `json:"duration_ns"`
+	Code       int                `json:"code"`
+	Errors     []string           `json:"errors,omitempty"`
+	Failures   int                `json:"failures,omitempty"`
+	// stopped by a signal or timeout
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// The history of one browser profile, summed up
+type ProfileStats struct {
+	Browser string    `json:"browser"`
+	Profile string    `json:"profile"`
+	Runs    int       `json:"runs"`
+	Failed  int       `json:"failed"`
+	Bytes   int64     `json:"bytes"`
+	Items   int       `json:"items"`
+	First   time.Time `json:"first_run"`
+	Last    time.Time `json:"last_run"`
+	// how fast the cache fills up between runs (bytes per day), zero
+	// until there are two runs that cleared it
+	CacheGrowth float64            `json:"cache_growth_per_day"`
+	Categories  map[Category]int64 `json:"categories"`
+	// to compute the growth
+	cacheBytes  int64
+	cacheSpan   time.Duration
+	cacheLatest time.Time
+}
+
+// Bytes freed in a category over all runs
+type CategoryTotal struct {
+	Category Category `json:"category"`
+	Bytes    int64    `json:"bytes"`
+}
+
+// What "wiper stats" shows: totals per profile, the categories that
+// take the most space and the runs that failed.
+type HistoryStats struct {
+	Since     time.Time        `json:"since"`
+	Runs      int              `json:"runs"`
+	Failed    int              `json:"failed"`
+	Bytes     int64            `json:"bytes"`
+	Items     int              `json:"items"`
+	Profiles  []*ProfileStats  `json:"profiles"`
+	Offenders []*CategoryTotal `json:"offenders"`
+	// the most recent ones, newest first
+	FailedRuns []*RunRecord `json:"failed_runs"`
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// The history record of a finished wipe & its exit code
+func NewRunRecord(r *WipeReport, code int) *RunRecord {
+	rec := &RunRecord{
+		Time:        r.Started,
+		Browser:     r.Browser,
+		Profile:     r.Profile,
+		Phases:      make([]string, 0, len(r.Actions)),
+		Bytes:       r.TotalBytes,
+		Allocated:   r.TotalAllocated,
+		Items:       r.TotalItems,
+		Categories:  r.Categories,
+		Duration:    r.Duration,
+		Code:        code,
+		Failures:    len(r.Failures),
+		Interrupted: r.Interrupted,
+	}
+	for _, action := range r.Actions {
+		rec.Phases = append(rec.Phases, action.Phase)
+		if action.Phase == PhaseCache {
+			rec.CacheBytes += action.Bytes
+		}
+	}
+	for _, entry := range r.Errors {
+		rec.Errors = append(rec.Errors, entry.Message)
+	}
+	return rec
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Whether the run failed (a partial wipe did too)
+func (r *RunRecord) Failed() bool {
+	return r.Code != 0
+}
+
+// Whether the whole cache was cleared, so that what it freed is what
+// it grew since the previous such run
+func (r *RunRecord) ClearedCache() bool {
+	return !r.Failed() && slices.Contains(r.Phases, PhaseCache)
+}
+
+func (p *ProfileStats) add(rec *RunRecord) {
+	if p.Runs == 0 {
+		p.First = rec.Time
+	}
+	p.Runs++
+	p.Last = rec.Time
+	p.Bytes += rec.Bytes
+	p.Items += rec.Items
+	for category, size := range rec.Categories {
+		p.Categories[category] += size
+	}
+	if rec.Failed() {
+		p.Failed++
+	}
+	if rec.ClearedCache() {
+		if !p.cacheLatest.IsZero() && rec.Time.After(p.cacheLatest) {
+			p.cacheBytes += rec.CacheBytes
+			p.cacheSpan += rec.Time.Sub(p.cacheLatest)
+			p.CacheGrowth = float64(p.cacheBytes) / p.cacheSpan.Hours() * 24
+		}
+		p.cacheLatest = rec.Time
+	}
+}
+
+// The category that took the most space in this profile
+func (p *ProfileStats) TopCategory() (Category, int64) {
+	top, most := CategoryOther, int64(0)
+	for _, category := range Categories() {
+		if p.Categories[category] > most {
+			top, most = category, p.Categories[category]
+		}
+	}
+	return top, most
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Where the run history is kept: $XDG_STATE_HOME/wiper/history
+func HistoryDir() string {
+	return filepath.Join(StateDir(), "history")
+}
+
+func DefaultHistoryFile() string {
+	return filepath.Join(HistoryDir(), "runs.jsonl")
+}
+
+// Add a run at the end of the history file (created if need be)
+func AppendHistory(path string, rec *RunRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	fd, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(append(line, '\n'))
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// All the runs of the history file, oldest first. A missing file is an
+// empty history; lines that can't be parsed (i.e. a half-written one)
+// are skipped.
+func ReadHistory(path string) ([]*RunRecord, error) {
+	fd, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	records := make([]*RunRecord, 0)
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		rec := &RunRecord{}
+		if json.Unmarshal(scanner.Bytes(), rec) == nil && !rec.Time.IsZero() {
+			records = append(records, rec)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, scanner.Err()
+}
+
+// Sum up the runs since the given time (all if zero). The filter, if
+// any, picks the runs to look at.
+func ComputeStats(records []*RunRecord, since time.Time, filter func(*RunRecord) bool) *HistoryStats {
+	stats := &HistoryStats{Since: since, Profiles: make([]*ProfileStats, 0), Offenders: make([]*CategoryTotal, 0),
+		FailedRuns: make([]*RunRecord, 0)}
+	profiles := make(map[string]*ProfileStats)
+	categories := make(map[Category]int64)
+	for _, rec := range records {
+		if rec.Time.Before(since) || (filter != nil && !filter(rec)) {
+			continue
+		}
+		if stats.Since.IsZero() {
+			stats.Since = rec.Time
+		}
+		key := rec.Browser + "/" + rec.Profile
+		p, ok := profiles[key]
+		if !ok {
+			p = &ProfileStats{Browser: rec.Browser, Profile: rec.Profile, Categories: make(map[Category]int64)}
+			profiles[key] = p
+			stats.Profiles = append(stats.Profiles, p)
+		}
+		p.add(rec)
+
+		stats.Runs++
+		stats.Bytes += rec.Bytes
+		stats.Items += rec.Items
+		for category, size := range rec.Categories {
+			categories[category] += size
+		}
+		if rec.Failed() {
+			stats.Failed++
+			stats.FailedRuns = append(stats.FailedRuns, rec)
+		}
+	}
+
+	sort.Slice(stats.Profiles, func(i, j int) bool {
+		a, b := stats.Profiles[i], stats.Profiles[j]
+		return a.Browser < b.Browser || (a.Browser == b.Browser && a.Profile < b.Profile)
+	})
+	for category, size := range categories {
+		stats.Offenders = append(stats.Offenders, &CategoryTotal{category, size})
+	}
+	sort.Slice(stats.Offenders, func(i, j int) bool {
+		a, b := stats.Offenders[i], stats.Offenders[j]
+		return a.Bytes > b.Bytes || (a.Bytes == b.Bytes && a.Category < b.Category)
+	})
+	slices.Reverse(stats.FailedRuns)
+	if len(stats.FailedRuns) > MaxFailedRuns {
+		stats.FailedRuns = stats.FailedRuns[:MaxFailedRuns]
+	}
+	return stats
+}
+
+// Render the statistics as text: a table of the profiles, the biggest
+// offenders and the most recent failures.
+func RenderStats(w io.Writer, s *HistoryStats, sizing SizeMode) {
+	if s.Runs == 0 {
+		fmt.Fprintln(w, "No runs recorded yet")
+		return
+	}
+	fmt.Fprintf(w, "❋✦ %d runs since %s, %d failed, %s freed in %d items\n\n", s.Runs,
+		s.Since.Format(time.DateOnly), s.Failed, ReportByteCount(s.Bytes, sizing), s.Items)
+
+	stamp := func(t time.Time) string { return t.Format("2006-01-02 15:04") }
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tRUNS\tFAILED\tFREED\tCACHE GROWTH\tLAST RUN\tTOP CATEGORY")
+	for _, p := range s.Profiles {
+		growth := "-"
+		if p.CacheGrowth > 0 {
+			growth = ReportByteCount(int64(p.CacheGrowth), sizing) + "/day"
+		}
+		top := "-"
+		if category, size := p.TopCategory(); size > 0 {
+			top = fmt.Sprintf("%s (%s)", category, ReportByteCount(size, sizing))
+		}
+		fmt.Fprintf(tw, "%s/%s\t%d\t%d\t%s\t%s\t%s\t%s\n", p.Browser, p.Profile, p.Runs, p.Failed,
+			ReportByteCount(p.Bytes, sizing), growth, stamp(p.Last), top)
+	}
+	tw.Flush()
+
+	if len(s.Offenders) != 0 {
+		fmt.Fprintln(w, "\nBiggest offenders:")
+		for _, total := range s.Offenders {
+			fmt.Fprintf(w, "%12s   %s\n", ReportByteCount(total.Bytes, sizing), total.Category)
+		}
+	}
+	if len(s.FailedRuns) != 0 {
+		fmt.Fprintln(w, "\nFailed runs:")
+		for _, rec := range s.FailedRuns {
+			msg := ""
+			if len(rec.Errors) != 0 {
+				msg = strings.TrimSpace(rec.Errors[0])
+			}
+			fmt.Fprintf(w, "  %s  %s/%s  E-%03d  %s\n", stamp(rec.Time), rec.Browser, rec.Profile, rec.Code, msg)
+		}
+	}
+}
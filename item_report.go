@@ -0,0 +1,280 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Per-item deletion report: what was deleted/kept/failed & why
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	ItemDeleted ItemAction = "deleted"
+	ItemKept    ItemAction = "kept"
+	ItemFailed  ItemAction = "failed"
+	// not processed because the run was interrupted
+	ItemSkipped ItemAction = "skipped"
+)
+
+const (
+	ReportFormatTable ReportFormat = iota
+	ReportFormatCSV
+	ReportFormatHTML
+)
+
+const (
+	// rule prefixes explaining why an item was deleted or kept
+	RuleException = "exception:" // kept because it is a listed exception
+	RuleWipe      = "wipe:"      // deleted because it is not an exception
+	RulePattern   = "pattern:"   // deleted because it matched a glob pattern
+	RuleCache     = "cache:"     // deleted as part of the profile cache
+	RuleBackup    = "backup:"    // moved to the backup directory
+	RuleShred     = "shred:"     // overwritten with zeros & deleted
+	// not touched because the run was interrupted (no prefix)
+	RuleInterrupted = "interrupted"
+)
+
+var (
+	ErrUnknownReportFormat = errors.New("Unknown report format (table|csv|html)")
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// deleted|kept|failed|skipped
+type ItemAction string
+
+// table|csv|html
+type ReportFormat uint
+
+// What happened to a single file or directory and why.
+type ItemRecord struct {
+	Phase  string     `json:"phase"`
+	Path   string     `json:"path"`
+	IsDir  bool       `json:"is_dir"`
+	Action ItemAction `json:"action"`
+	Size   int64      `json:"size"`
+	Rule   string     `json:"rule"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// data handed to the HTML template
+type htmlItem struct {
+	*ItemRecord
+	SizeText string
+	Percent  float64
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// An item that was (or in a dry-run would have been) deleted
+func NewDeletedItem(path string, isDir bool, size int64, rule string) *ItemRecord {
+	return &ItemRecord{Path: path, IsDir: isDir, Action: ItemDeleted, Size: size, Rule: rule}
+}
+
+// An item that was kept on purpose
+func NewKeptItem(path string, isDir bool, size int64, rule string) *ItemRecord {
+	return &ItemRecord{Path: path, IsDir: isDir, Action: ItemKept, Size: size, Rule: rule}
+}
+
+// An item we attempted to delete but couldn't
+func NewFailedItem(path string, isDir bool, size int64, rule string, err error) *ItemRecord {
+	return &ItemRecord{Path: path, IsDir: isDir, Action: ItemFailed, Size: size, Rule: rule, Error: err.Error()}
+}
+
+// An item we did not get to
+func NewSkippedItem(path string, isDir bool, size int64, rule string) *ItemRecord {
+	return &ItemRecord{Path: path, IsDir: isDir, Action: ItemSkipped, Size: size, Rule: rule}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Stringer interface
+func (f ReportFormat) String() string {
+	switch f {
+	case ReportFormatTable:
+		return "table"
+	case ReportFormatCSV:
+		return "csv"
+	case ReportFormatHTML:
+		return "html"
+	default:
+		return ""
+	}
+}
+
+// Record the items processed during a wipe phase. They are tagged with
+// the phase they belong to.
+func (r *WipeReport) Record(phase string, items ...*ItemRecord) {
+	for _, item := range items {
+		item.Phase = phase
+		r.Items = append(r.Items, item)
+	}
+}
+
+// Items of the report having the given action
+func (r *WipeReport) ItemsWith(action ItemAction) []*ItemRecord {
+	result := make([]*ItemRecord, 0)
+	for _, item := range r.Items {
+		if item.Action == action {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Parse a report format name. An empty name is guessed from the
+// extension of filename (.csv, .html/.htm) and defaults to table.
+func ParseReportFormat(name, filename string) (ReportFormat, error) {
+	if len(name) == 0 {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			return ReportFormatCSV, nil
+		case ".html", ".htm":
+			return ReportFormatHTML, nil
+		default:
+			return ReportFormatTable, nil
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case "table", "text":
+		return ReportFormatTable, nil
+	case "csv":
+		return ReportFormatCSV, nil
+	case "html":
+		return ReportFormatHTML, nil
+	default:
+		return ReportFormatTable, ErrUnknownReportFormat
+	}
+}
+
+// Write the per-item report of a wipe in the selected format.
+func WriteItemReport(w io.Writer, r *WipeReport, format ReportFormat, sizing SizeMode) error {
+	switch format {
+	case ReportFormatCSV:
+		return WriteItemsCSV(w, r.Items)
+	case ReportFormatHTML:
+		return WriteItemsHTML(w, r, sizing)
+	default:
+		return WriteItemsTable(w, r.Items, sizing)
+	}
+}
+
+// Aligned plain-text table, one row per item.
+func WriteItemsTable(w io.Writer, items []*ItemRecord, sizing SizeMode) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tPHASE\tSIZE\tRULE\tPATH\tERROR")
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Action, item.Phase,
+			ReportByteCount(item.Size, sizing), item.Rule, item.Path, item.Error)
+	}
+	return tw.Flush()
+}
+
+// CSV with a header row. Sizes are always in bytes.
+func WriteItemsCSV(w io.Writer, items []*ItemRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"action", "phase", "path", "is_dir", "size", "rule", "error"})
+	for _, item := range items {
+		cw.Write([]string{
+			string(item.Action),
+			item.Phase,
+			item.Path,
+			strconv.FormatBool(item.IsDir),
+			strconv.FormatInt(item.Size, 10),
+			item.Rule,
+			item.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// A self-contained HTML page (no external assets) with a bar chart of
+// the item sizes.
+func WriteItemsHTML(w io.Writer, r *WipeReport, sizing SizeMode) error {
+	var largest int64 = 1
+	for _, item := range r.Items {
+		if item.Size > largest {
+			largest = item.Size
+		}
+	}
+
+	items := make([]htmlItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, htmlItem{
+			ItemRecord: item,
+			SizeText:   ReportByteCount(item.Size, sizing),
+			Percent:    float64(item.Size) * 100 / float64(largest),
+		})
+	}
+
+	data := struct {
+		Report  *WipeReport
+		Items   []htmlItem
+		Total   string
+		Version string
+	}{r, items, ReportByteCount(r.TotalBytes, sizing), Version}
+
+	return itemsHTMLTemplate.Execute(w, data)
+}
+
+var itemsHTMLTemplate = template.Must(template.New("items").Funcs(template.FuncMap{
+	"pct": func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Browser}} {{.Report.Profile}} wipe report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 2px 8px; border-bottom: 1px solid #ddd; font-size: 0.9em; }
+td.size { text-align: right; white-space: nowrap; }
+td.bar { width: 25%; }
+div.bar { height: 0.8em; }
+tr.deleted div.bar { background: #c0392b; }
+tr.kept div.bar { background: #27ae60; }
+tr.failed div.bar { background: #f39c12; }
+tr.failed td { color: #a04000; }
+tr.skipped td { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Browser}} &laquo;{{.Report.Profile}}&raquo;</h1>
+<p>Started {{.Report.Started.Format "2006-01-02 15:04:05"}} &middot;
+ Dry run: {{.Report.DryRun}} &middot;{{if .Report.Interrupted}}
+ <strong>Interrupted</strong> &middot;{{end}}
+ Removed {{.Report.TotalItems}} items, {{.Total}}
+ &middot; {{.Version}}</p>
+<table>
+<tr><th>Action</th><th>Phase</th><th>Size</th><th></th><th>Rule</th><th>Path</th><th>Error</th></tr>
+{{range .Items}}<tr class="{{.Action}}"><td>{{.Action}}</td><td>{{.Phase}}</td><td class="size">{{.SizeText}}</td><td class="bar"><div class="bar" style="width: {{pct .Percent}}%"></div></td><td>{{.Rule}}</td><td>{{.Path}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
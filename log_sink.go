@@ -0,0 +1,237 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Log sinks for scheduled runs: journald (native protocol) & syslog
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	LogSinkStderr  = "stderr"
+	LogSinkJournal = "journal"
+	LogSinkSyslog  = "syslog"
+)
+
+// Where the local journald & syslog daemons listen
+const (
+	JournalSocket = "/run/systemd/journal/socket"
+	SyslogSocket  = "/dev/log"
+)
+
+// The prefix of our own journal fields, i.e. WIPER_PROFILE
+const JournalFieldPrefix = "WIPER_"
+
+// SYSLOG_IDENTIFIER in the journal, the tag in syslog
+const LogIdentifier = "wiper"
+
+const syslogFacilityUser = 1
+
+var (
+	_ slog.Handler = (*SocketHandler)(nil)
+	_ io.Closer    = (*SocketHandler)(nil)
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A slog handler sending one datagram per record to the journald or
+// syslog socket. Clones made by WithAttrs/WithGroup share the socket.
+type SocketHandler struct {
+	sink   string
+	level  slog.Leveler
+	conn   *sinkConn
+	attrs  []slog.Attr
+	groups string // prefix of the following attributes
+}
+
+type sinkConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// Log to journald at the given socket (JournalSocket). The attributes
+// become WIPER_* fields.
+func NewJournalHandler(socket string, level slog.Leveler) (*SocketHandler, error) {
+	return newSocketHandler(LogSinkJournal, socket, level)
+}
+
+// Log to syslog at the given socket (SyslogSocket) with the user
+// facility. The attributes are appended to the message as key=value.
+func NewSyslogHandler(socket string, level slog.Leveler) (*SocketHandler, error) {
+	return newSocketHandler(LogSinkSyslog, socket, level)
+}
+
+func newSocketHandler(sink, socket string, level slog.Leveler) (*SocketHandler, error) {
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("%s log sink: %w", sink, err)
+	}
+	return &SocketHandler{sink, level, &sinkConn{conn: conn}, nil, ""}, nil
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements slog.Handler
+func (h *SocketHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Implements slog.Handler
+func (h *SocketHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(slices.Clip(h.attrs), h.prefixed(attrs)...)
+	return &clone
+}
+
+// Implements slog.Handler
+func (h *SocketHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = h.groups + name + "_"
+	return &clone
+}
+
+// Implements slog.Handler
+func (h *SocketHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr{}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.prefixed([]slog.Attr{a})...)
+		return true
+	})
+
+	var msg []byte
+	if h.sink == LogSinkJournal {
+		msg = journalEntry(r, attrs)
+	} else {
+		msg = syslogEntry(r, attrs)
+	}
+	h.conn.mu.Lock()
+	defer h.conn.mu.Unlock()
+	_, err := h.conn.conn.Write(msg)
+	return err
+}
+
+// Implements io.Closer
+func (h *SocketHandler) Close() error {
+	return h.conn.conn.Close()
+}
+
+// flatten groups into prefixed keys
+func (h *SocketHandler) prefixed(attrs []slog.Attr) []slog.Attr {
+	var result []slog.Attr
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			inner := &SocketHandler{groups: h.groups + a.Key + "_"}
+			if a.Key == "" {
+				inner.groups = h.groups
+			}
+			result = append(result, inner.prefixed(a.Value.Group())...)
+		} else if !a.Equal(slog.Attr{}) {
+			result = append(result, slog.Attr{Key: h.groups + a.Key, Value: a.Value})
+		}
+	}
+	return result
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The syslog severity of a slog level
+func SyslogPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	}
+	return 7 // debug
+}
+
+// The journal field for an attribute key: WIPER_ + upper case, with
+// anything but letters, digits & underscores turned into underscores
+func JournalField(key string) string {
+	field := []byte(JournalFieldPrefix + strings.ToUpper(key))
+	for i, c := range field {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			field[i] = '_'
+		}
+	}
+	return string(field)
+}
+
+// A journal export of the record: KEY=value lines, or the binary form
+// (KEY, newline, 64-bit little endian length, value) for values with
+// a newline
+func journalEntry(r slog.Record, attrs []slog.Attr) []byte {
+	var buf bytes.Buffer
+	field := func(key, value string) {
+		if !strings.Contains(value, "\n") {
+			fmt.Fprintf(&buf, "%s=%s\n", key, value)
+			return
+		}
+		buf.WriteString(key)
+		buf.WriteByte('\n')
+		binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+	field("MESSAGE", r.Message)
+	field("PRIORITY", fmt.Sprint(SyslogPriority(r.Level)))
+	field("SYSLOG_IDENTIFIER", LogIdentifier)
+	field("SYSLOG_PID", fmt.Sprint(os.Getpid()))
+	for _, a := range attrs {
+		field(JournalField(a.Key), a.Value.String())
+	}
+	return buf.Bytes()
+}
+
+// A local syslog (RFC 3164) line: <PRI>Mmm dd hh:mm:ss tag[pid]: msg
+func syslogEntry(r slog.Record, attrs []slog.Attr) []byte {
+	var buf bytes.Buffer
+	stamp := r.Time
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	fmt.Fprintf(&buf, "<%d>%s %s[%d]: %s", syslogFacilityUser*8+SyslogPriority(r.Level),
+		stamp.Format(time.Stamp), LogIdentifier, os.Getpid(), r.Message)
+	for _, a := range attrs {
+		value := a.Value.String()
+		if strings.ContainsAny(value, " \"=\n") || value == "" {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&buf, " %s=%s", a.Key, value)
+	}
+	return buf.Bytes()
+}
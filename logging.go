@@ -0,0 +1,135 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Leveled logging setup (log/slog) with text or JSON output
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// Attribute keys used throughout the application
+const (
+	LogKeyComponent = "component"
+	LogKeyBrowser   = "browser"
+	LogKeyProfile   = "profile"
+	LogKeyPath      = "path"
+	LogKeyBytes     = "bytes"
+	LogKeyErr       = "err"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const (
+	DefaultLogMaxSize    = 10 // megabytes
+	DefaultLogMaxBackups = 3
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+type LogOptions struct {
+	Level  slog.Level
+	Format string // text or json
+	// stderr (or File), journal or syslog
+	Sink string
+	// a log file instead of stderr. Rotated once it reaches MaxSize
+	// megabytes, keeping MaxBackups older ones (.1 is the newest)
+	File       string
+	MaxSize    int
+	MaxBackups int
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func NewLogOptions() *LogOptions {
+	return &LogOptions{slog.LevelInfo, LogFormatText, LogSinkStderr, "", DefaultLogMaxSize, DefaultLogMaxBackups}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Parse a level name: debug, info, warn(ing) or error
+func ParseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if strings.EqualFold(name, "warning") {
+		name = "warn"
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (debug, info, warn, error)", name)
+	}
+	return level, nil
+}
+
+// A handler writing to w with the level & format of the options
+func NewLogHandler(w io.Writer, opts *LogOptions) (slog.Handler, error) {
+	hopts := &slog.HandlerOptions{Level: opts.Level}
+	switch strings.ToLower(opts.Format) {
+	case "", LogFormatText:
+		return slog.NewTextHandler(w, hopts), nil
+	case LogFormatJSON:
+		return slog.NewJSONHandler(w, hopts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (text, json)", opts.Format)
+}
+
+// Make the options the default slog logger, which NewConditionalLogger
+// builds on. It logs on stderr unless a File or another Sink is given.
+// The returned closer must be closed when done.
+func SetupLogging(opts *LogOptions) (io.Closer, error) {
+	switch strings.ToLower(opts.Sink) {
+	case "", LogSinkStderr:
+	case LogSinkJournal, LogSinkSyslog:
+		if opts.File != "" {
+			return nil, fmt.Errorf("a log file needs the %s sink", LogSinkStderr)
+		}
+		var handler *SocketHandler
+		var err error
+		if strings.EqualFold(opts.Sink, LogSinkJournal) {
+			handler, err = NewJournalHandler(JournalSocket, opts.Level)
+		} else {
+			handler, err = NewSyslogHandler(SyslogSocket, opts.Level)
+		}
+		if err != nil {
+			return nil, err
+		}
+		slog.SetDefault(slog.New(handler))
+		return handler, nil
+	default:
+		return nil, fmt.Errorf("unknown log sink %q (stderr, journal, syslog)", opts.Sink)
+	}
+
+	var w io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, int64(opts.MaxSize)<<20, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = file, file
+	}
+	handler, err := NewLogHandler(w, opts)
+	if err != nil {
+		closer.Close()
+		return nil, err
+	}
+	slog.SetDefault(slog.New(handler))
+	return closer, nil
+}
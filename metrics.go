@@ -0,0 +1,270 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Wipe metrics for the Prometheus node_exporter textfile collector
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	MetricRuns           = "wiper_runs_total"
+	MetricFreedBytes     = "wiper_freed_bytes_total"
+	MetricRemovedItems   = "wiper_removed_items_total"
+	MetricErrors         = "wiper_errors_total"
+	MetricLastRun        = "wiper_last_run_timestamp_seconds"
+	MetricLastSuccess    = "wiper_last_success_timestamp_seconds"
+	MetricLastDuration   = "wiper_last_run_duration_seconds"
+	MetricLastFreed      = "wiper_last_run_freed_bytes"
+	MetricLastItems      = "wiper_last_run_removed_items"
+	MetricLastExitCode   = "wiper_last_run_exit_code"
+	MetricBrowserDirSize = "wiper_browser_dir_size_bytes"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// The metric families we write, in this order
+var metricFamilies = []*MetricFamily{
+	{MetricRuns, "counter", "Wipes run, by result (success or failure)."},
+	{MetricFreedBytes, "counter", "Bytes freed by the wipes, by data category."},
+	{MetricRemovedItems, "counter", "Files & directories removed by the wipes."},
+	{MetricErrors, "counter", "Errors of the wipes, by exit code."},
+	{MetricLastRun, "gauge", "When the last wipe of the profile started."},
+	{MetricLastSuccess, "gauge", "When the last successful wipe of the profile started."},
+	{MetricLastDuration, "gauge", "How long the last wipe of the profile took."},
+	{MetricLastFreed, "gauge", "Bytes freed by the last wipe of the profile."},
+	{MetricLastItems, "gauge", "Files & directories removed by the last wipe of the profile."},
+	{MetricLastExitCode, "gauge", "Exit code of the last wipe of the profile (0 is success)."},
+	{MetricBrowserDirSize, "gauge", "Size of the browser's data & cache directories (all profiles) after the last wipe."},
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Name, type & help of a metric
+type MetricFamily struct {
+	Name string
+	Type string
+	Help string
+}
+
+// The samples of a textfile-collector file. Each run updates those of
+// its browser & profile and keeps the others, so a single file serves
+// all profiles; counters go on from the values of the previous file.
+type Metrics struct {
+	// by name{labels}
+	samples map[string]float64
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+func NewMetrics() *Metrics {
+	return &Metrics{make(map[string]float64)}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Set a gauge. Labels are name & value pairs.
+func (m *Metrics) Set(name string, value float64, labels ...string) {
+	m.samples[metricKey(name, labels)] = value
+}
+
+// Add to a counter. Labels are name & value pairs.
+func (m *Metrics) Add(name string, delta float64, labels ...string) {
+	m.samples[metricKey(name, labels)] += delta
+}
+
+// The value of a sample (zero if there is none)
+func (m *Metrics) Get(name string, labels ...string) float64 {
+	return m.samples[metricKey(name, labels)]
+}
+
+// Account for a finished wipe & its exit code. The scan, if any, has the
+// current size of the browser's directories.
+func (m *Metrics) RecordRun(r *WipeReport, code int, scan *BrowserScan) {
+	profile := []string{"browser", r.Browser, "profile", r.Profile}
+	with := func(labels ...string) []string {
+		return append(profile[:len(profile):len(profile)], labels...)
+	}
+
+	started := float64(r.Started.UnixMilli()) / 1000
+	if code == 0 {
+		m.Add(MetricRuns, 1, with("result", "success")...)
+		m.Set(MetricLastSuccess, started, profile...)
+	} else {
+		m.Add(MetricRuns, 1, with("result", "failure")...)
+	}
+	for category, size := range r.Categories {
+		m.Add(MetricFreedBytes, float64(size), with("category", category.String())...)
+	}
+	m.Add(MetricRemovedItems, float64(r.TotalItems), profile...)
+	for _, entry := range r.Errors {
+		m.Add(MetricErrors, 1, with("code", strconv.Itoa(entry.Code))...)
+	}
+	m.Set(MetricLastRun, started, profile...)
+	m.Set(MetricLastDuration, r.Duration.Seconds(), profile...)
+	m.Set(MetricLastFreed, float64(r.TotalBytes), profile...)
+	m.Set(MetricLastItems, float64(r.TotalItems), profile...)
+	m.Set(MetricLastExitCode, float64(code), profile...)
+
+	if scan != nil {
+		if scan.DataExists {
+			m.Set(MetricBrowserDirSize, float64(scan.DataSize), "browser", scan.Browser, "dir", "data")
+		}
+		if scan.CacheExists {
+			m.Set(MetricBrowserDirSize, float64(scan.CacheSize), "browser", scan.Browser, "dir", "cache")
+		}
+	}
+}
+
+// Write the samples in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	keys := make([]string, 0, len(m.samples))
+	for key := range m.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, family := range metricFamilies {
+		header := false
+		for _, key := range keys {
+			if metricName(key) != family.Name {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", family.Name, family.Help, family.Name, family.Type)
+				header = true
+			}
+			fmt.Fprintf(&sb, "%s %s\n", key, strconv.FormatFloat(m.samples[key], 'f', -1, 64))
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The metrics of a file written by WriteMetricsFile(). A missing file
+// has none; the samples of metrics that are not ours are dropped.
+func ReadMetricsFile(path string) (*Metrics, error) {
+	m := NewMetrics()
+	fd, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// values never have spaces, label values may
+		idx := strings.LastIndexByte(line, ' ')
+		if idx < 0 || LookupMetric(metricName(line[:idx])) == nil {
+			continue
+		}
+		if value, err := strconv.ParseFloat(line[idx+1:], 64); err == nil {
+			m.samples[line[:idx]] = value
+		}
+	}
+	return m, scanner.Err()
+}
+
+// Write the metrics atomically (a temporary file in the same directory
+// renamed over it) so that the collector never reads half a file.
+func WriteMetricsFile(path string, m *Metrics) error {
+	fd, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := fd.Name()
+	_, err = m.WriteTo(fd)
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// Update the metrics file with a finished wipe
+func UpdateMetricsFile(path string, r *WipeReport, code int, scan *BrowserScan) error {
+	m, err := ReadMetricsFile(path)
+	if err != nil {
+		return err
+	}
+	m.RecordRun(r, code, scan)
+	return WriteMetricsFile(path, m)
+}
+
+// The family of one of our metrics, nil if it isn't one
+func LookupMetric(name string) *MetricFamily {
+	for _, family := range metricFamilies {
+		if family.Name == name {
+			return family
+		}
+	}
+	return nil
+}
+
+// name{label="value",...} with the values escaped
+func metricKey(name string, labels []string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(labels[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(labels[i+1]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func metricName(key string) string {
+	if idx := strings.IndexByte(key, '{'); idx > -1 {
+		return key[:idx]
+	}
+	return key
+}
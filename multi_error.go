@@ -0,0 +1,152 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Aggregated errors of a wipe that keeps going after a failure
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	_ error = (*ItemError)(nil)
+	_ error = (*MultiError)(nil)
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A file or directory that could not be removed
+type ItemError struct {
+	Path    string `json:"path"`
+	Op      string `json:"op"` // i.e. unlinkat, open
+	Errno   int    `json:"errno,omitempty"`
+	Code    int    `json:"code"` // the E-nnn of the phase
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+// Several errors in one. errors.Is() & errors.As() look into each of
+// them. The zero value is ready to use.
+type MultiError struct {
+	Errors []error
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// The error of operation op on path. The errno is taken from the cause
+// if it has one.
+func NewItemError(path, op string, code int, err error) *ItemError {
+	ie := &ItemError{Path: path, Op: op, Code: code, Message: err.Error(), Err: err}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		ie.Errno = int(errno)
+	}
+	return ie
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements error
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("E-%03d %s %s: %s", e.Code, e.Op, e.Path, e.Message)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// Add an error (nil is ignored)
+func (m *MultiError) Add(err error) {
+	if err != nil {
+		m.Errors = append(m.Errors, err)
+	}
+}
+
+// Number of errors collected so far
+func (m *MultiError) Len() int {
+	return len(m.Errors)
+}
+
+// The aggregated error or nil if there was none
+func (m *MultiError) Err() error {
+	if len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+// The result when stopped by err (i.e. an interruption): err itself if
+// nothing failed before, else all of them.
+func (m *MultiError) Final(err error) error {
+	if len(m.Errors) == 0 {
+		return err
+	}
+	m.Add(err)
+	return m
+}
+
+// Implements error
+func (m *MultiError) Error() string {
+	if len(m.Errors) == 1 {
+		return m.Errors[0].Error()
+	}
+	msgs := make([]string, 0, len(m.Errors))
+	for _, err := range m.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d errors:\n\t%s", len(m.Errors), strings.Join(msgs, "\n\t"))
+}
+
+// For errors.Is() & errors.As()
+func (m *MultiError) Unwrap() []error {
+	return m.Errors
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The failed items behind an error, however deeply it is wrapped or
+// joined. Path errors become item errors with the given code. Errors
+// that are not about a path (i.e. ErrNotBrowserCache) are left out.
+func ItemErrors(err error, code int) []*ItemError {
+	var result []*ItemError
+	switch e := err.(type) {
+	case nil:
+	case *ItemError:
+		// the underlying path errors are more precise
+		if result = ItemErrors(e.Err, code); len(result) == 0 {
+			if e.Code == 0 {
+				e.Code = code
+			}
+			result = []*ItemError{e}
+		}
+	case *fs.PathError:
+		ie := NewItemError(e.Path, e.Op, code, e)
+		ie.Message = e.Err.Error()
+		result = []*ItemError{ie}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			result = append(result, ItemErrors(inner, code)...)
+		}
+	case interface{ Unwrap() error }:
+		result = ItemErrors(e.Unwrap(), code)
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Observers of the progress of a wipe
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"sync"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	// how often (at most) EventProgress is emitted during a long item
+	ProgressInterval = 200 * time.Millisecond
+
+	_ IObserver = ObserverFunc(nil)
+	_ IObserver = (*Observers)(nil)
+	_ IObserver = (*WipeReport)(nil)
+)
+
+/* ----------------------------------------------------------------
+ *						I n t e r f a c e s
+ *-----------------------------------------------------------------*/
+
+// Anything that wants to follow a wipe as it happens: the renderers,
+// a progress bar or your own handler when embedding the cleaners.
+// Events may be delivered from several goroutines.
+type IObserver interface {
+	Event(ev *Event)
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Adapter to use an ordinary function as an observer
+type ObserverFunc func(ev *Event)
+
+// A list of subscribed observers. The zero value is ready to use.
+type Observers struct {
+	mu   sync.RWMutex
+	list []IObserver
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// An event about a single item (file or directory). Its size & rule are
+// taken from the record.
+func NewItemEvent(kind EventKind, item *ItemRecord) *Event {
+	ev := NewEvent(kind)
+	ev.Path, ev.Bytes, ev.Rule = item.Path, item.Size, item.Rule
+	ev.Message = item.Error
+	return ev
+}
+
+// Running totals of the bytes & files done within a phase while working
+// on path
+func NewProgressEvent(path string, done DiskSize, files int) *Event {
+	ev := NewEvent(EventProgress)
+	ev.Path, ev.Bytes, ev.Items = path, done.Apparent, files
+	return ev
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Implements IObserver
+func (f ObserverFunc) Event(ev *Event) {
+	f(ev)
+}
+
+// Add observers. Nil observers are ignored.
+func (o *Observers) Subscribe(observers ...IObserver) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, obs := range observers {
+		if obs != nil {
+			o.list = append(o.list, obs)
+		}
+	}
+}
+
+// Implements IObserver by passing the event to all subscribers
+func (o *Observers) Event(ev *Event) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, obs := range o.list {
+		obs.Event(ev)
+	}
+}
+
+// Number of subscribed observers
+func (o *Observers) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.list)
+}
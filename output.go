@@ -0,0 +1,395 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Output renderers: Human (console), JSON & NDJSON (event stream)
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	OutputHuman  OutputFormat = iota // emoji-laden console output
+	OutputJSON                       // one JSON document at the end
+	OutputNDJSON                     // newline-delimited JSON event stream
+)
+
+const (
+	EventRunStarted    EventKind = "run_started"
+	EventPhaseStarted  EventKind = "phase_started"
+	EventPhaseFinished EventKind = "phase_finished"
+	EventRunFinished   EventKind = "run_finished"
+	EventError         EventKind = "error"
+	EventScanResult    EventKind = "scan_result"
+	EventWipeResult    EventKind = "wipe_result"
+	EventDiskUsage     EventKind = "du_result"
+	EventStats         EventKind = "stats_result"
+	EventUsers         EventKind = "users_result"
+	// progress within a phase
+	EventItemStarted EventKind = "item_started"
+	EventItemDeleted EventKind = "item_deleted"
+	EventItemSkipped EventKind = "item_skipped" // kept or not reached
+	EventProgress    EventKind = "progress"     // bytes & files so far
+)
+
+var (
+	_ IRenderer = (*HumanRenderer)(nil)
+	_ IRenderer = (*JSONRenderer)(nil)
+	_ IRenderer = (*NDJSONRenderer)(nil)
+
+	ErrUnknownOutputFormat = errors.New("Unknown output format (human|json|ndjson)")
+)
+
+/* ----------------------------------------------------------------
+ *						I n t e r f a c e s
+ *-----------------------------------------------------------------*/
+
+// Everything the application (and the browser cleaners) wants to tell
+// the user goes through a renderer. The human renderer is what we have
+// always had; the others are for fleet tooling.
+type IRenderer interface {
+	// free-form chatter meant for humans only
+	Printf(format string, v ...any)
+	// where other human-only output (i.e. DryRun notices) should go
+	Writer() io.Writer
+	// progress of a long run
+	IObserver
+	// final results
+	Scan(r *ScanReport) error
+	Wipe(r *WipeReport) error
+	DiskUsage(r *DiskUsageReport) error
+	Stats(s *HistoryStats) error
+	// of --all-users, one scan or wipes per user
+	Users(r *UsersReport) error
+	// a fatal application error and its exit code
+	Error(code int, err error)
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// human|json|ndjson
+type OutputFormat uint
+
+// The kind of event emitted during a run
+type EventKind string
+
+// Something worth telling while a run is in progress
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    EventKind `json:"event"`
+	Browser string    `json:"browser,omitempty"`
+	Profile string    `json:"profile,omitempty"`
+	Phase   string    `json:"phase,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Bytes   int64     `json:"bytes,omitempty"`
+	Items   int       `json:"items,omitempty"`
+	// item events: number of items in the directory being cleaned
+	Total   int    `json:"total,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// The classic console output.
+type HumanRenderer struct {
+	w        io.Writer
+	sizeMode SizeMode
+}
+
+// A single (indented) JSON document with the final result.
+type JSONRenderer struct {
+	w io.Writer
+}
+
+// One JSON object per line: events as they happen, result at the end.
+type NDJSONRenderer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// Instantiate a renderer for the given format writing to w (stdout if nil)
+func NewRenderer(format OutputFormat, w io.Writer, sizing SizeMode) IRenderer {
+	if w == nil {
+		w = os.Stdout
+	}
+	switch format {
+	case OutputJSON:
+		return &JSONRenderer{w: w}
+	case OutputNDJSON:
+		return &NDJSONRenderer{w: w}
+	default:
+		return &HumanRenderer{w: w, sizeMode: sizing}
+	}
+}
+
+// Renderer used when a component was not given one explicitly
+func DefaultRenderer() IRenderer {
+	return NewRenderer(OutputHuman, os.Stdout, SizeModeStd)
+}
+
+func NewEvent(kind EventKind) *Event {
+	return &Event{Time: time.Now(), Kind: kind}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Stringer interface
+func (o OutputFormat) String() string {
+	switch o {
+	case OutputHuman:
+		return "human"
+	case OutputJSON:
+		return "json"
+	case OutputNDJSON:
+		return "ndjson"
+	default:
+		return ""
+	}
+}
+
+// Whether the format is meant for machines rather than humans
+func (o OutputFormat) IsMachine() bool {
+	return o == OutputJSON || o == OutputNDJSON
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~ Human ~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+func (h *HumanRenderer) Printf(format string, v ...any) {
+	fmt.Fprintf(h.w, format, v...)
+}
+
+func (h *HumanRenderer) Writer() io.Writer {
+	return h.w
+}
+
+// Humans get their progress via Printf already
+func (h *HumanRenderer) Event(ev *Event) {
+}
+
+func (h *HumanRenderer) Scan(r *ScanReport) error {
+	for _, b := range r.Browsers {
+		if len(b.Error) != 0 {
+			fmt.Fprintf(h.w, "\tBad Thing: %s %s\n", b.Browser, b.Error)
+			continue
+		}
+		fmt.Fprintf(h.w, "❋✦ %s Directories:\n", b.Flavor)
+		fmt.Fprintf(h.w, "\tData : %5t %s %s\n", b.DataExists, b.DataDir, b.DataUsage().Format(h.sizeMode))
+		fmt.Fprintf(h.w, "\tCache: %5t %s %s\n", b.CacheExists, b.CacheDir, b.CacheUsage().Format(h.sizeMode))
+		fmt.Fprintf(h.w, "\tInstalled: %t\n", b.Installed)
+		if b.Profiles != nil {
+			fmt.Fprintln(h.w, "\tProfiles :")
+			for _, p := range b.Profiles {
+				fmt.Fprintln(h.w, "\t\t- ", p)
+			}
+		}
+	}
+	return nil
+}
+
+func (h *HumanRenderer) Wipe(r *WipeReport) error {
+	for _, a := range r.Actions {
+		status := "✔"
+		if a.Error != nil {
+			status = "✘"
+		}
+		fmt.Fprintf(h.w, "\t%s %-10s %6d items %s [%s]\n", status, a.Phase, a.Items,
+			a.Usage().Format(h.sizeMode), a.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(h.w, "\tTotal: %d items %s in %s\n", r.TotalItems,
+		r.TotalUsage().Format(h.sizeMode), r.Duration.Round(time.Millisecond))
+	if len(r.Failures) != 0 {
+		fmt.Fprintf(h.w, "⚠ %d items could not be removed:\n", len(r.Failures))
+		for _, f := range r.Failures {
+			fmt.Fprintf(h.w, "\t%s\n", f)
+		}
+	}
+	if r.Interrupted {
+		fmt.Fprintln(h.w, "⚠ Interrupted! This is what was (and wasn't) removed:")
+		return WriteItemsTable(h.w, r.Items, h.sizeMode)
+	}
+	return nil
+}
+
+func (h *HumanRenderer) DiskUsage(r *DiskUsageReport) error {
+	RenderDiskUsage(h.w, r, h.sizeMode)
+	return nil
+}
+
+func (h *HumanRenderer) Stats(s *HistoryStats) error {
+	RenderStats(h.w, s, h.sizeMode)
+	return nil
+}
+
+func (h *HumanRenderer) Users(r *UsersReport) error {
+	for _, run := range r.Users {
+		fmt.Fprintf(h.w, "❖ %s\n", run.LoginUser)
+		if run.Scan != nil {
+			h.Scan(run.Scan)
+		}
+		for _, wipe := range run.Wipes {
+			fmt.Fprintf(h.w, "   %s %q\n", wipe.Browser, wipe.Profile)
+			h.Wipe(wipe)
+		}
+		if run.Error != nil {
+			fmt.Fprintf(h.w, "\t⚠ exit code %d: %s\n", run.Code, run.Error.Message)
+		}
+	}
+	return nil
+}
+
+func (h *HumanRenderer) Error(code int, err error) {
+	msg := err.Error()
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	fmt.Fprintf(h.w, "⚓ Bad Thing Happened: exit code %d\n", code)
+	fmt.Fprintln(h.w, "⚓ Message:")
+	fmt.Fprintf(h.w, "⚓ \t%s", msg)
+	if entry := NewErrorEntry(code, err); len(entry.Hint) != 0 {
+		fmt.Fprintf(h.w, "⚓ Hint: %s\n", entry.Hint)
+	}
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~ JSON ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+func (j *JSONRenderer) Printf(format string, v ...any) {
+}
+
+func (j *JSONRenderer) Writer() io.Writer {
+	return io.Discard
+}
+
+func (j *JSONRenderer) Event(ev *Event) {
+}
+
+func (j *JSONRenderer) Scan(r *ScanReport) error {
+	return j.encode(r)
+}
+
+func (j *JSONRenderer) Wipe(r *WipeReport) error {
+	return j.encode(r)
+}
+
+func (j *JSONRenderer) DiskUsage(r *DiskUsageReport) error {
+	return j.encode(r)
+}
+
+func (j *JSONRenderer) Stats(s *HistoryStats) error {
+	return j.encode(s)
+}
+
+func (j *JSONRenderer) Users(r *UsersReport) error {
+	return j.encode(r)
+}
+
+func (j *JSONRenderer) Error(code int, err error) {
+	j.encode(struct {
+		Error *ErrorEntry `json:"error"`
+	}{NewErrorEntry(code, err)})
+}
+
+func (j *JSONRenderer) encode(v any) error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~ NDJSON ~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+func (n *NDJSONRenderer) Printf(format string, v ...any) {
+}
+
+func (n *NDJSONRenderer) Writer() io.Writer {
+	return io.Discard
+}
+
+func (n *NDJSONRenderer) Event(ev *Event) {
+	n.encode(ev)
+}
+
+func (n *NDJSONRenderer) Scan(r *ScanReport) error {
+	return n.encode(struct {
+		*Event
+		Scan *ScanReport `json:"scan"`
+	}{NewEvent(EventScanResult), r})
+}
+
+func (n *NDJSONRenderer) Wipe(r *WipeReport) error {
+	return n.encode(struct {
+		*Event
+		Wipe *WipeReport `json:"wipe"`
+	}{NewEvent(EventWipeResult), r})
+}
+
+func (n *NDJSONRenderer) DiskUsage(r *DiskUsageReport) error {
+	return n.encode(struct {
+		*Event
+		DiskUsage *DiskUsageReport `json:"du"`
+	}{NewEvent(EventDiskUsage), r})
+}
+
+func (n *NDJSONRenderer) Stats(s *HistoryStats) error {
+	return n.encode(struct {
+		*Event
+		Stats *HistoryStats `json:"stats"`
+	}{NewEvent(EventStats), s})
+}
+
+func (n *NDJSONRenderer) Users(r *UsersReport) error {
+	return n.encode(struct {
+		*Event
+		Users *UsersReport `json:"users"`
+	}{NewEvent(EventUsers), r})
+}
+
+func (n *NDJSONRenderer) Error(code int, err error) {
+	ev := NewEvent(EventError)
+	ev.Code = code
+	ev.Message = err.Error()
+	n.encode(ev)
+}
+
+func (n *NDJSONRenderer) encode(v any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return json.NewEncoder(n.w).Encode(v)
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Parse an output format name (case-insensitive)
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch strings.ToLower(name) {
+	case "human", "text", "":
+		return OutputHuman, nil
+	case "json":
+		return OutputJSON, nil
+	case "ndjson", "jsonl":
+		return OutputNDJSON, nil
+	default:
+		return OutputHuman, ErrUnknownOutputFormat
+	}
+}
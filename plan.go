@@ -0,0 +1,178 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Wipe plans (saved dry runs) & restoring the profile backups
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Save the report of a dry run as a plan that 'wiper apply' carries out
+// later, after it was reviewed.
+func SavePlan(path string, plan *WipeReport) error {
+	if !plan.DryRun {
+		return fmt.Errorf("%w: not a dry run", ErrBadPlan)
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Read a plan saved by SavePlan(). It must be a complete dry run no
+// older than maxAge (0 is any age).
+func LoadPlan(path string, maxAge time.Duration) (*WipeReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan := &WipeReport{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrBadPlan, path, err)
+	}
+	switch {
+	case !plan.DryRun || len(plan.Browser) == 0 || len(plan.Profile) == 0:
+		return nil, fmt.Errorf("%w: %s is not the report of a dry run", ErrBadPlan, path)
+	case plan.Interrupted || len(plan.Errors) != 0:
+		return nil, fmt.Errorf("%w: %s is the report of a failed dry run", ErrBadPlan, path)
+	case maxAge > 0 && time.Since(plan.Started) > maxAge:
+		return nil, fmt.Errorf("%w: %s is older than %s, the profile changed since", ErrBadPlan, path, maxAge)
+	}
+	return plan, nil
+}
+
+// Whether the plan has the phase (cache, profile) at all
+func PlanHas(plan *WipeReport, phase string) bool {
+	return slices.ContainsFunc(plan.Actions, func(a *WipeAction) bool { return a.Phase == phase })
+}
+
+// Names of the top-level profile items the plan removes
+func PlannedNames(plan *WipeReport) []string {
+	result := make([]string, 0)
+	for _, action := range plan.Actions {
+		if action.Phase != PhaseProfile {
+			continue
+		}
+		for _, item := range plan.Items {
+			if item.Phase == PhaseProfile && item.Action == ItemDeleted && filepath.Dir(item.Path) == action.Path {
+				result = append(result, filepath.Base(item.Path))
+			}
+		}
+	}
+	return result
+}
+
+// What a plan would do, in a few lines to read before confirming it: the
+// directories of its phases with their items & bytes, the bytes per data
+// category, what is kept and where the backup goes (none if empty).
+func WritePlanSummary(w io.Writer, plan *WipeReport, backupDir string, sizing SizeMode) error {
+	fmt.Fprintf(w, "About to wipe the %s profile %q:\n", plan.Browser, plan.Profile)
+	var items, kept int
+	var bytes int64
+	for _, action := range plan.Actions {
+		var count int
+		var size int64
+		for _, item := range plan.Items {
+			switch {
+			case item.Phase != action.Phase:
+			case item.Action == ItemDeleted:
+				count++
+				size += item.Size
+			case item.Action == ItemKept:
+				kept++
+			}
+		}
+		items, bytes = items+count, bytes+size
+		fmt.Fprintf(w, "  %-10s %6d items %12s  %s\n", action.Phase, count, ReportByteCount(size, sizing), action.Path)
+	}
+
+	categories := make([]Category, 0, len(plan.Categories))
+	for category := range plan.Categories {
+		categories = append(categories, category)
+	}
+	slices.SortFunc(categories, func(a, b Category) int {
+		return cmp.Compare(plan.Categories[b], plan.Categories[a])
+	})
+	parts := make([]string, 0, len(categories))
+	for _, category := range categories {
+		parts = append(parts, category.String()+" "+ReportByteCount(plan.Categories[category], sizing))
+	}
+	if len(parts) != 0 {
+		fmt.Fprintf(w, "  Categories: %s\n", strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(w, "  Removed:    %d items, %s (%d kept)\n", items, ReportByteCount(bytes, sizing), kept)
+	if len(backupDir) != 0 {
+		fmt.Fprintf(w, "  Backup:     the profile items are moved to %s\n", backupDir)
+	} else {
+		fmt.Fprintf(w, "  Backup:     none, what is removed cannot be restored\n")
+	}
+	return nil
+}
+
+// The backups of a profile under a backup directory, oldest first
+func ListBackups(dir, browser, profile string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, browser, profile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if _, err := time.Parse("20060102-150405", entry.Name()); err == nil && entry.IsDir() {
+			result = append(result, filepath.Join(dir, browser, profile, entry.Name()))
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w %s/%s in %s", ErrNoBackup, browser, profile, dir)
+	}
+	return result, nil
+}
+
+// Move the items of a backup back into the profile directory. Items the
+// profile has again are left in the backup unless overwrite is on. The
+// backup directory is removed once empty. Returns the restored names.
+func RestoreBackup(backup, profileDir string, overwrite bool) ([]string, error) {
+	entries, err := os.ReadDir(backup)
+	if err != nil {
+		return nil, err
+	}
+	restored := make([]string, 0, len(entries))
+	var failures MultiError
+	for _, entry := range entries {
+		src, dest := filepath.Join(backup, entry.Name()), filepath.Join(profileDir, entry.Name())
+		if _, err := os.Lstat(dest); err == nil {
+			if !overwrite {
+				failures.Add(fmt.Errorf("%s exists in the profile", entry.Name()))
+				continue
+			}
+			if err := os.RemoveAll(dest); err != nil {
+				failures.Add(err)
+				continue
+			}
+		}
+		if err := MoveTree(src, dest); err != nil {
+			failures.Add(err)
+			continue
+		}
+		restored = append(restored, entry.Name())
+	}
+	if err := failures.Err(); err != nil {
+		return restored, err
+	}
+	return restored, os.Remove(backup)
+}
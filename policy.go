@@ -0,0 +1,396 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The system policy: what the users' settings may not change
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// The policy of the managed machines. Only root may write it, wiper
+// has no flag or variable to point elsewhere.
+var SystemPolicyFile = "/etc/wiper/policy.toml"
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// The rules of the policy file. A rule applies to the users, browsers
+// & profiles it names (glob patterns), all of them if it names none.
+//
+//	[[rule]]
+//	users  = ["kiosk*"]
+//	force  = ["cookies", "history"]
+//
+//	[[rule]]
+//	forbid = ["web-apps", "bookmarks"]
+//	backup = true
+//	backup_dir = "/var/backups/wiper/{user}"
+//	max_interval = "24h"
+type Policy struct {
+	File  string        `toml:"-"`
+	Rules []*PolicyRule `toml:"rule"`
+}
+
+type PolicyRule struct {
+	Users    []string `toml:"users"`
+	Browsers []string `toml:"browsers"`
+	Profiles []string `toml:"profiles"`
+	// data categories always wiped & never wiped
+	Force  []string `toml:"force"`
+	Forbid []string `toml:"forbid"`
+	// move what is removed to a backup directory ({user} is the user
+	// name) instead of deleting it; or else overwrite it with zeros
+	Backup    bool   `toml:"backup"`
+	BackupDir string `toml:"backup_dir"`
+	Shred     bool   `toml:"shred"`
+	// scheduled wipes must run at least this often
+	MaxInterval time.Duration `toml:"max_interval"`
+	force       []Category
+	forbid      []Category
+}
+
+// What the policy demands of a profile: all the rules that apply to it
+// put together. Forbidden categories win over forced ones. The methods
+// work on a nil *ProfilePolicy (no policy at all).
+type ProfilePolicy struct {
+	File        string
+	Rules       []int // numbers of the rules that apply, from 1
+	Force       []Category
+	Forbid      []Category
+	Backup      bool
+	BackupDir   string
+	Shred       bool
+	MaxInterval time.Duration
+}
+
+// A setting the policy overrules, i.e. keep=cookies from -keep
+type PolicyConflict struct {
+	Setting string
+	Value   string
+	Source  string
+	Reason  string
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// Read & check a policy file. A missing file is no policy (nil).
+func LoadPolicy(path string) (*Policy, error) {
+	p := &Policy{File: path}
+	if _, err := toml.DecodeFile(path, p); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: policy %s", ErrBadConfig, err)
+	}
+	for i, rule := range p.Rules {
+		if err := rule.check(); err != nil {
+			return nil, fmt.Errorf("%w: policy %s rule %d: %s", ErrBadConfig, path, i+1, err)
+		}
+	}
+	return p, nil
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// The policy of a user's browser profile, nil if no rule applies. An
+// empty profile stands for any profile of the browser.
+func (p *Policy) For(user, browser, profile string) *ProfilePolicy {
+	if p == nil {
+		return nil
+	}
+	var result *ProfilePolicy
+	for i, rule := range p.Rules {
+		if !rule.Matches(user, browser, profile) {
+			continue
+		}
+		if result == nil {
+			result = &ProfilePolicy{File: p.File}
+		}
+		result.Rules = append(result.Rules, i+1)
+		result.Force = appendNew(result.Force, rule.force...)
+		result.Forbid = appendNew(result.Forbid, rule.forbid...)
+		result.Backup = result.Backup || rule.Backup
+		if len(result.BackupDir) == 0 {
+			result.BackupDir = strings.ReplaceAll(rule.BackupDir, "{user}", user)
+		}
+		result.Shred = result.Shred || rule.Shred
+		if rule.MaxInterval > 0 && (result.MaxInterval == 0 || rule.MaxInterval < result.MaxInterval) {
+			result.MaxInterval = rule.MaxInterval
+		}
+	}
+	if result != nil {
+		result.Force = slices.DeleteFunc(result.Force, func(c Category) bool { return slices.Contains(result.Forbid, c) })
+	}
+	return result
+}
+
+// Rules that force what others forbid. It is not an error (forbidding
+// wins) but probably not what was meant.
+func (p *Policy) Overlaps() []string {
+	var result []string
+	for i, a := range p.Rules {
+		for j, b := range p.Rules {
+			for _, c := range a.force {
+				if i != j && slices.Contains(b.forbid, c) {
+					result = append(result, fmt.Sprintf("rule %d forces %s, which rule %d forbids", i+1, c, j+1))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Whether the rule applies to a user's browser profile
+func (r *PolicyRule) Matches(user, browser, profile string) bool {
+	if len(r.Browsers) != 0 && !slices.ContainsFunc(r.Browsers, func(b string) bool { return strings.EqualFold(b, browser) }) {
+		return false
+	}
+	return matchAny(r.Users, user) && (len(profile) == 0 || matchAny(r.Profiles, profile))
+}
+
+func (r *PolicyRule) check() error {
+	var err error
+	if r.force, err = ParseCategories(r.Force); err != nil {
+		return fmt.Errorf("force: %w", err)
+	}
+	if r.forbid, err = ParseCategories(r.Forbid); err != nil {
+		return fmt.Errorf("forbid: %w", err)
+	}
+	for _, c := range r.force {
+		if slices.Contains(r.forbid, c) {
+			return fmt.Errorf("forces & forbids %s", c)
+		}
+	}
+	for _, pattern := range append(slices.Clone(r.Users), r.Profiles...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	if len(r.BackupDir) != 0 && !filepath.IsAbs(r.BackupDir) {
+		return fmt.Errorf("backup_dir must be an absolute path, not %q", r.BackupDir)
+	}
+	if r.MaxInterval < 0 || (r.MaxInterval > 0 && r.MaxInterval < time.Minute) {
+		return fmt.Errorf("max_interval must be at least 1m, not %s", r.MaxInterval)
+	}
+	return nil
+}
+
+// Whether the policy always wipes the category
+func (p *ProfilePolicy) Forces(c Category) bool {
+	return p != nil && slices.Contains(p.Force, c)
+}
+
+// Whether the policy never wipes the category
+func (p *ProfilePolicy) Forbids(c Category) bool {
+	return p != nil && slices.Contains(p.Forbid, c)
+}
+
+// The phases a cleaner runs: a forced cache is always cleared and a
+// forbidden one never; a forced profile category needs the profile phase.
+func (p *ProfilePolicy) Phases(doCache, doProfile bool) (bool, bool) {
+	if p.Forbids(CategoryCache) {
+		doCache = false
+	} else if p.Forces(CategoryCache) {
+		doCache = true
+	}
+	return doCache, doProfile || len(p.profileForced()) != 0
+}
+
+// The names the profile phase keeps: the cleaner's exceptions without
+// those of the forced categories & with those of the forbidden ones.
+// names gives the file names of categories in the cleaner's profiles.
+func (p *ProfilePolicy) Exceptions(exceptions []string, names func(...Category) []string) []string {
+	if p == nil {
+		return exceptions
+	}
+	forced := names(p.Force...)
+	result := slices.DeleteFunc(slices.Clone(exceptions), func(name string) bool { return slices.Contains(forced, name) })
+	return appendNew(result, names(p.Forbid...)...)
+}
+
+// Where the profile phase backs up what it removes: the given directory
+// or, if the policy requires a backup, the policy's one. Fails if the
+// policy requires a backup & neither has one.
+func (p *ProfilePolicy) BackupTarget(dir string) (string, error) {
+	if len(dir) != 0 || p == nil || !p.Backup {
+		return dir, nil
+	}
+	if len(p.BackupDir) == 0 {
+		return "", fmt.Errorf("%w: %s requires a backup & there is no backup directory", ErrPolicyViolation, p.Source())
+	}
+	return p.BackupDir, nil
+}
+
+// Whether the policy requires shredding what is removed
+func (p *ProfilePolicy) MustShred() bool {
+	return p != nil && p.Shred
+}
+
+// The settings of a configuration that go against the policy. Settings
+// with their default value never do: the policy just takes over.
+func (p *ProfilePolicy) Conflicts(cfg *Config) []*PolicyConflict {
+	if p == nil {
+		return nil
+	}
+	var result []*PolicyConflict
+	conflict := func(name, format string, v ...any) {
+		s := cfg.Lookup(name)
+		result = append(result, &PolicyConflict{name, s.Value, s.Source, fmt.Sprintf(format, v...)})
+	}
+	explicit := func(name string) bool {
+		return cfg.Lookup(name).Source != SourceDefault
+	}
+
+	for _, c := range cfg.KeptCategories() {
+		if p.Forces(c) {
+			conflict("keep", "the policy always wipes %s", c)
+		}
+	}
+	if explicit("cache") {
+		if cfg.Bool("cache") && p.Forbids(CategoryCache) {
+			conflict("cache", "the policy never wipes the cache")
+		} else if !cfg.Bool("cache") && p.Forces(CategoryCache) {
+			conflict("cache", "the policy always wipes the cache")
+		}
+	}
+	if forced := p.profileForced(); explicit("profile") && !cfg.Bool("profile") && len(forced) != 0 {
+		conflict("profile", "the policy always wipes %s", joinCategories(forced))
+	}
+	if p.Backup && len(cfg.Value("backup_dir")) == 0 && (explicit("backup_dir") || len(p.BackupDir) == 0) {
+		conflict("backup_dir", "the policy requires a backup of what is removed")
+	}
+	if p.Shred && explicit("shred") && !cfg.Bool("shred") {
+		conflict("shred", "the policy requires shredding what is removed")
+	}
+	return result
+}
+
+// Whether a schedule runs often enough, nil if it does
+func (p *ProfilePolicy) CheckSchedule(name string, cron *CronSchedule, from time.Time) *PolicyConflict {
+	if p == nil || p.MaxInterval == 0 {
+		return nil
+	}
+	span := max(2*p.MaxInterval, 8*24*time.Hour)
+	if gap := cron.LongestGap(from, span); gap > p.MaxInterval {
+		return &PolicyConflict{"schedule", cron.String(), name,
+			fmt.Sprintf("the policy wants a wipe at least every %s", p.MaxInterval)}
+	}
+	return nil
+}
+
+// i.e. /etc/wiper/policy.toml rules 1, 3
+func (p *ProfilePolicy) Source() string {
+	if p == nil {
+		return "no policy"
+	}
+	numbers := make([]string, len(p.Rules))
+	for i, n := range p.Rules {
+		numbers[i] = strconv.Itoa(n)
+	}
+	if len(numbers) == 1 {
+		return p.File + " rule " + numbers[0]
+	}
+	return p.File + " rules " + strings.Join(numbers, ", ")
+}
+
+// the forced categories the profile phase takes care of
+func (p *ProfilePolicy) profileForced() []Category {
+	if p == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(p.Force), func(c Category) bool { return c == CategoryCache })
+}
+
+// i.e. keep = "cookies" (flag -keep): the policy always wipes cookies
+func (c *PolicyConflict) String() string {
+	return fmt.Sprintf("%s = %q (%s): %s", c.Setting, c.Value, c.Source, c.Reason)
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The conflicts as a single error matching ErrPolicyViolation
+func PolicyError(conflicts []*PolicyConflict) error {
+	lines := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		lines[i] = c.String()
+	}
+	return fmt.Errorf("%w: %s", ErrPolicyViolation, strings.Join(lines, "; "))
+}
+
+// What the policy demands of a profile, one requirement per line
+func WriteProfilePolicy(w io.Writer, p *ProfilePolicy) error {
+	if p == nil {
+		_, err := fmt.Fprintln(w, "No policy rule applies")
+		return err
+	}
+	lines := []string{"Policy: " + p.Source()}
+	if len(p.Force) != 0 {
+		lines = append(lines, "  always wiped : "+joinCategories(p.Force))
+	}
+	if len(p.Forbid) != 0 {
+		lines = append(lines, "  never wiped  : "+joinCategories(p.Forbid))
+	}
+	if p.Backup {
+		lines = append(lines, "  backup       : required "+p.BackupDir)
+	}
+	if p.Shred {
+		lines = append(lines, "  shred        : required")
+	}
+	if p.MaxInterval > 0 {
+		lines = append(lines, "  schedule     : at least every "+p.MaxInterval.String())
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// no patterns match anything
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
+
+func appendNew[T comparable](list []T, items ...T) []T {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func joinCategories(categories []Category) string {
+	names := make([]string, len(categories))
+	for i, c := range categories {
+		names[i] = c.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,51 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Browser profile locks: is the browser running with that profile?
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The first of the lock files (names relative to dir) held by a running
+// browser, or an empty string if none is. On Unix Chromium & Firefox
+// make their lock a symbolic link to "host-pid" or "ip:+pid"; a lock
+// left behind by a crashed browser does not count. Plain lock files
+// (Windows) count if they can't be opened.
+func HeldLock(dir string, names ...string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if lockHeld(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+func lockHeld(path string) bool {
+	target, err := os.Readlink(path)
+	if err != nil {
+		if _, err := os.Lstat(path); err != nil {
+			return false
+		}
+		return lockFileBusy(path)
+	}
+
+	idx := strings.LastIndexAny(target, "-+")
+	pid, err := strconv.Atoi(target[idx+1:])
+	if err != nil || pid <= 0 {
+		// not a format we know, better safe than sorry
+		return true
+	}
+	return processAlive(pid)
+}
@@ -0,0 +1,31 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Unix process & lock file checks
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"syscall"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Signal 0 checks whether the process exists without disturbing it.
+// EPERM means it exists but belongs to somebody else.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Unix browsers use symbolic links (or advisory locks) rather than
+// plain lock files.
+func lockFileBusy(path string) bool {
+	return false
+}
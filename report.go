@@ -0,0 +1,281 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Machine-readable results of scans & wipes
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	PhaseCache      = "cache"
+	PhaseProfile    = "profile"
+	PhaseExtensions = "extensions"
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// What a scan found out about ONE supported browser.
+type BrowserScan struct {
+	Browser     string `json:"browser"`
+	Flavor      string `json:"flavor"` // i.e. Firefox-ESR
+	Installed   bool   `json:"installed"`
+	DataDir     string `json:"data_dir"`
+	CacheDir    string `json:"cache_dir"`
+	DataExists  bool   `json:"data_exists"`
+	CacheExists bool   `json:"cache_exists"`
+	DataSize    int64  `json:"data_size"`
+	CacheSize   int64  `json:"cache_size"`
+	// sizes of the blocks allocated on disk (hardlinks counted once)
+	DataAllocated  int64    `json:"data_allocated"`
+	CacheAllocated int64    `json:"cache_allocated"`
+	Profiles       []string `json:"profiles"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Result of scanning the system for ALL supported browsers
+type ScanReport struct {
+	Browsers []*BrowserScan `json:"browsers"`
+}
+
+// An error as reported to the outside world
+type ErrorEntry struct {
+	Code     int           `json:"code"`
+	Category ErrorCategory `json:"category,omitempty"`
+	Message  string        `json:"message"`
+	Hint     string        `json:"hint,omitempty"`
+}
+
+// One wipe action (phase) on a browser profile, i.e. clearing the cache.
+type WipeAction struct {
+	Phase     string        `json:"phase"`
+	Path      string        `json:"path"`
+	Bytes     int64         `json:"bytes"`
+	Allocated int64         `json:"allocated_bytes"`
+	Items     int           `json:"items"`
+	Skipped   int           `json:"skipped"`
+	Duration  time.Duration `json:"duration_ns"`
+	Error     *ErrorEntry   `json:"error,omitempty"`
+	started   time.Time
+}
+
+// Result of wiping a browser user profile.
+type WipeReport struct {
+	Browser string `json:"browser"`
+	Profile string `json:"profile"`
+	DryRun  bool   `json:"dry_run"`
+	// stopped by a signal or timeout before it was done
+	Interrupted bool          `json:"interrupted"`
+	Started     time.Time     `json:"started"`
+	Duration    time.Duration `json:"duration_ns"`
+	Actions     []*WipeAction `json:"actions"`
+	TotalBytes  int64         `json:"total_bytes"`
+	// freed disk blocks, what df would show
+	TotalAllocated int64         `json:"total_allocated_bytes"`
+	TotalItems     int           `json:"total_items"`
+	Errors         []*ErrorEntry `json:"errors"`
+	// every file or directory that could not be removed
+	Failures []*ItemError  `json:"failures"`
+	Items    []*ItemRecord `json:"items"`
+	// bytes removed per data category
+	Categories map[Category]int64 `json:"categories,omitempty"`
+	observers  Observers
+	current    *WipeAction
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// An error & its exit code as reported to the outside world. The hint
+// is that of the typed Error if it has one, else that of the code.
+func NewErrorEntry(code int, err error) *ErrorEntry {
+	entry := &ErrorEntry{Code: code, Message: err.Error()}
+	if info := LookupError(code); info != nil {
+		entry.Category, entry.Hint = info.Category, info.Hint
+	}
+	var typed *Error
+	if errors.As(err, &typed) && typed.Code == code {
+		entry.Category, entry.Hint = typed.Category, typed.Hint
+	}
+	return entry
+}
+
+func NewWipeReport(browser, profile string, dryRun bool) *WipeReport {
+	return &WipeReport{
+		Browser:  browser,
+		Profile:  profile,
+		DryRun:   dryRun,
+		Started:  time.Now(),
+		Actions:  make([]*WipeAction, 0),
+		Errors:   make([]*ErrorEntry, 0),
+		Failures: make([]*ItemError, 0),
+		Items:    make([]*ItemRecord, 0),
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Apparent & allocated size of the data directory
+func (s *BrowserScan) DataUsage() DiskSize {
+	return DiskSize{s.DataSize, s.DataAllocated}
+}
+
+// Apparent & allocated size of the cache directory
+func (s *BrowserScan) CacheUsage() DiskSize {
+	return DiskSize{s.CacheSize, s.CacheAllocated}
+}
+
+// Apparent & allocated size removed by this action
+func (a *WipeAction) Usage() DiskSize {
+	return DiskSize{a.Bytes, a.Allocated}
+}
+
+// Apparent & allocated size removed by the whole wipe
+func (r *WipeReport) TotalUsage() DiskSize {
+	return DiskSize{r.TotalBytes, r.TotalAllocated}
+}
+
+// Start the wipe. Progress events are emitted on the given renderer and
+// the other subscribed observers.
+func (r *WipeReport) Start(out IRenderer) {
+	r.observers.Subscribe(out)
+	r.Started = time.Now()
+	r.emit(NewEvent(EventRunStarted))
+}
+
+// Begin a new wipe phase on the given path. Call End() when it is done.
+func (r *WipeReport) Begin(phase, path string) *WipeAction {
+	action := &WipeAction{Phase: phase, Path: path, started: time.Now()}
+	r.Actions = append(r.Actions, action)
+	r.current = action
+
+	ev := NewEvent(EventPhaseStarted)
+	ev.Phase, ev.Path = phase, path
+	r.emit(ev)
+	return action
+}
+
+// End a wipe phase. A non-nil error is recorded (with its code) on both
+// the action and the report, the items it failed on as Failures. An
+// interruption gets its own exit code. Returns the (possibly adjusted) code.
+func (r *WipeReport) End(a *WipeAction, err error, code int) int {
+	a.Duration = time.Since(a.started)
+	r.TotalBytes += a.Bytes
+	r.TotalAllocated += a.Allocated
+	r.TotalItems += a.Items
+	if err != nil {
+		code = ExitCode(err, code)
+		a.Error = r.Fail(err, code)
+		r.Failures = append(r.Failures, ItemErrors(err, code)...)
+	}
+
+	ev := NewEvent(EventPhaseFinished)
+	ev.Phase, ev.Path = a.Phase, a.Path
+	ev.Bytes, ev.Items = a.Bytes, a.Items
+	r.emit(ev)
+	return code
+}
+
+// Check for an interruption before starting another phase. Returns the
+// context's error and its exit code (recorded in the report) if any.
+func (r *WipeReport) Check(ctx context.Context) (error, int) {
+	if err := ctx.Err(); err != nil {
+		code := ExitCode(err, ExitInterrupted)
+		r.Fail(err, code)
+		return err, code
+	}
+	return nil, 0
+}
+
+// Record an error that is not (necessarily) tied to a wipe action.
+func (r *WipeReport) Fail(err error, code int) *ErrorEntry {
+	if IsInterruption(err) {
+		r.Interrupted = true
+	}
+	entry := NewErrorEntry(code, err)
+	r.Errors = append(r.Errors, entry)
+
+	ev := NewEvent(EventError)
+	ev.Code, ev.Message = code, entry.Message
+	r.emit(ev)
+	return entry
+}
+
+// Mark the whole wipe as finished.
+func (r *WipeReport) Finish() {
+	r.Duration = time.Since(r.Started)
+
+	ev := NewEvent(EventRunFinished)
+	ev.Bytes, ev.Items = r.TotalBytes, r.TotalItems
+	r.emit(ev)
+}
+
+// Total the bytes of the deleted items per category as the browser's
+// classifier sees them. Items under neither root count as "other".
+func (r *WipeReport) Categorize(classifier IClassifier, profileRoot, cacheRoot string) {
+	r.Categories = make(map[Category]int64)
+	for _, item := range r.Items {
+		if item.Action != ItemDeleted || item.Size == 0 {
+			continue
+		}
+		category := CategoryOther
+		for kind, root := range []string{RootProfile: profileRoot, RootCache: cacheRoot} {
+			if rel, err := filepath.Rel(root, item.Path); err == nil && len(root) != 0 && !strings.HasPrefix(rel, "..") {
+				category, _, _ = classifier.Classify(RootKind(kind), rel, item.IsDir)
+				break
+			}
+		}
+		r.Categories[category] += item.Size
+	}
+}
+
+// Exit code of a wipe that went on despite its failures: ExitPartial if
+// anything was removed at all, else the code of the first error.
+func (r *WipeReport) PartialExitCode() int {
+	if r.TotalItems > 0 || len(r.Errors) == 0 {
+		return ExitPartial
+	}
+	return r.Errors[0].Code
+}
+
+// Whether the wipe ran without errors
+func (r *WipeReport) Succeeded() bool {
+	return len(r.Errors) == 0
+}
+
+// Subscribe observers to the progress events of this wipe
+func (r *WipeReport) Subscribe(observers ...IObserver) {
+	r.observers.Subscribe(observers...)
+}
+
+// Implements IObserver so that the components doing the actual work
+// (i.e. a DirCleaner) can report through the wipe. Their events are
+// tagged with the current phase.
+func (r *WipeReport) Event(ev *Event) {
+	if len(ev.Phase) == 0 && r.current != nil {
+		ev.Phase = r.current.Phase
+	}
+	r.emit(ev)
+}
+
+// tag the event with this report's browser & profile and send it out
+func (r *WipeReport) emit(ev *Event) {
+	ev.Browser, ev.Profile = r.Browser, r.Profile
+	r.observers.Event(ev)
+}
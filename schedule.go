@@ -0,0 +1,500 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Scheduled wiping: systemd user units (service + timer) generated
+ * from templates.
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// The first line of every unit we generate. Only those are listed and
+// removed, hand-written units are left alone.
+const UnitMarker = "# Generated by wiper schedule install. Reinstall instead of editing."
+
+const DefaultUnitName = "wiper"
+
+// What a scheduled run wipes
+var SchedulePresets = []*SchedulePreset{
+	{"quick", "Cache only", []string{"-c"}},
+	{"standard", "Cache, profile & extension junk", nil},
+	{"profile", "Profile & extension junk only", []string{"-p"}},
+}
+
+// calendar shorthands of systemd.time(7)
+var calendarShorthands = []string{"minutely", "hourly", "daily", "weekly", "monthly",
+	"yearly", "annually", "quarterly", "semiannually"}
+
+// systemd calendar shorthands as cron expressions
+var calendarCron = map[string]string{
+	"minutely":     "* * * * *",
+	"hourly":       "0 * * * *",
+	"daily":        "0 0 * * *",
+	"weekly":       "0 0 * * mon",
+	"monthly":      "0 0 1 * *",
+	"quarterly":    "0 0 1 1,4,7,10 *",
+	"semiannually": "0 0 1 1,7 *",
+	"yearly":       "0 0 1 1 *",
+	"annually":     "0 0 1 1 *",
+}
+
+var (
+	unitNameRx = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+	calendarRx = regexp.MustCompile(`^[A-Za-z0-9*,./:~ +-]+$`)
+	// [Mon..Fri|Mon,Wed] [*-*-*] HH:MM[:SS] with * or lists for the time
+	calendarSimpleRx = regexp.MustCompile(`^(?:([A-Za-z]{3}(?:(?:\.\.|,)[A-Za-z]{3})*) +)?(?:\*-\*-\* +)?([0-9*,]+):([0-9*,]+)(?::00)?$`)
+)
+
+var serviceTemplate = template.Must(template.New("service").Parse(UnitMarker + `
+[Unit]
+Description=Wipe {{.Browser}} private data ({{.Preset.Name}}: {{.Preset.Description}})
+Documentation=https://github.com/lordofscripts/wipechromium
+X-Wiper-Browser={{.Browser}}
+X-Wiper-Preset={{.Preset.Name}}
+{{- if .AtLogout}}
+X-Wiper-Schedule=logout
+{{- else}}
+X-Wiper-Schedule={{.OnCalendar}}
+{{- end}}
+
+[Service]
+Type=oneshot
+{{- if .AtLogout}}
+# started at login, the wipe runs when the user session ends
+RemainAfterExit=yes
+TimeoutStopSec=5min
+ExecStart=/bin/true
+{{- range .Commands}}
+ExecStop={{.}}
+{{- end}}
+{{- else}}
+{{- range .Commands}}
+ExecStart={{.}}
+{{- end}}
+{{- end}}
+{{- if .AtLogout}}
+
+[Install]
+WantedBy=default.target
+{{- end}}
+`))
+
+var timerTemplate = template.Must(template.New("timer").Parse(UnitMarker + `
+[Unit]
+Description=Wipe {{.Browser}} private data ({{.OnCalendar}})
+Documentation=https://github.com/lordofscripts/wipechromium
+
+[Timer]
+OnCalendar={{.OnCalendar}}
+Persistent={{.Persistent}}
+Unit={{.Name}}.service
+
+[Install]
+WantedBy=timers.target
+`))
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+type SchedulePreset struct {
+	Name        string
+	Description string
+	Args        []string // wiper options it stands for
+}
+
+// A scheduled wipe of one or more profiles of a browser
+type UnitSchedule struct {
+	Name     string // of the units, i.e. wiper gives wiper.service & wiper.timer
+	Binary   string // absolute path of wiper
+	Browser  string
+	Profiles []string
+	Preset   *SchedulePreset
+	// systemd.time(7) calendar event, i.e. daily or Mon *-*-* 09:00
+	OnCalendar string
+	// catch up on runs missed while the machine was off
+	Persistent bool
+	// wipe when the user logs out instead of on a calendar
+	AtLogout bool
+}
+
+// A generated unit file
+type UnitFile struct {
+	Name    string // i.e. wiper.timer
+	Content string
+}
+
+// A schedule found in the unit directory
+type InstalledSchedule struct {
+	Name     string
+	Browser  string
+	Preset   string
+	Schedule string // the calendar event or "logout"
+	Commands []string
+	Files    []string
+	// from before wipes had to be confirmed: without -yes they are refused
+	Outdated bool
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// A daily, persistent schedule of the standard preset
+func NewUnitSchedule(binary, browser string, profiles ...string) *UnitSchedule {
+	return &UnitSchedule{DefaultUnitName, binary, browser, profiles, LookupPreset("standard"), "daily", true, false}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Check the schedule makes valid units
+func (s *UnitSchedule) Validate() error {
+	bad := func(format string, v ...any) error {
+		return fmt.Errorf("%w: %s", ErrBadSchedule, fmt.Sprintf(format, v...))
+	}
+	if !unitNameRx.MatchString(s.Name) {
+		return bad("unit name %q", s.Name)
+	}
+	if !filepath.IsAbs(s.Binary) {
+		return bad("wiper must be an absolute path, not %q", s.Binary)
+	}
+	if s.Preset == nil {
+		return bad("no preset")
+	}
+	if s.Browser == "" || len(s.Profiles) == 0 {
+		return bad("need a browser & at least one profile")
+	}
+	for _, arg := range append([]string{s.Binary, s.Browser}, s.Profiles...) {
+		if arg == "" || strings.ContainsAny(arg, "\r\n") {
+			return bad("%q", arg)
+		}
+	}
+	if s.AtLogout {
+		if s.OnCalendar != "" {
+			return bad("either on a calendar or at logout, not both")
+		}
+		return nil
+	}
+	return ValidateCalendar(s.OnCalendar)
+}
+
+// The wiper invocation of each profile
+func (s *UnitSchedule) Commands() []string {
+	result := make([]string, 0, len(s.Profiles))
+	for _, profile := range s.Profiles {
+		args := []string{s.Binary, "-b", s.Browser, "-n", profile}
+		args = append(args, s.Preset.Args...)
+		// nobody is there to confirm, the journal has it all (WIPER_* fields)
+		args = append(args, "-k", "-yes", "-progress=false", "-log-sink", LogSinkJournal)
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = SystemdQuote(arg)
+		}
+		result = append(result, strings.Join(quoted, " "))
+	}
+	return result
+}
+
+// The service (and, unless at logout, the timer) of the schedule
+func (s *UnitSchedule) Units() ([]*UnitFile, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	templates := []*template.Template{serviceTemplate}
+	if !s.AtLogout {
+		templates = append(templates, timerTemplate)
+	}
+	result := make([]*UnitFile, 0, len(templates))
+	for _, tmpl := range templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, s); err != nil {
+			return nil, err
+		}
+		if err := checkUnitSyntax(buf.String()); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", s.Name, tmpl.Name(), err)
+		}
+		result = append(result, &UnitFile{s.Name + "." + tmpl.Name(), buf.String()})
+	}
+	return result, nil
+}
+
+// Whether the preset wipes the cache only (-c)
+func (p *SchedulePreset) CacheOnly() bool {
+	return slices.Contains(p.Args, "-c")
+}
+
+// Whether the preset leaves the cache alone (-p)
+func (p *SchedulePreset) ProfileOnly() bool {
+	return slices.Contains(p.Args, "-p")
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Preset by name or nil
+func LookupPreset(name string) *SchedulePreset {
+	for _, preset := range SchedulePresets {
+		if strings.EqualFold(preset.Name, name) {
+			return preset
+		}
+	}
+	return nil
+}
+
+// A rough check of a systemd.time(7) calendar event. systemd-analyze
+// calendar has the final word.
+func ValidateCalendar(spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return fmt.Errorf("%w: empty calendar event", ErrBadSchedule)
+	}
+	for _, short := range calendarShorthands {
+		if strings.EqualFold(spec, short) {
+			return nil
+		}
+	}
+	if !calendarRx.MatchString(spec) || !strings.ContainsAny(spec, "0123456789*") {
+		return fmt.Errorf("%w: calendar event %q (i.e. daily, weekly or Mon..Fri 18:00)", ErrBadSchedule, spec)
+	}
+	return nil
+}
+
+// The cron equivalent of the common systemd.time(7) calendar events: the
+// shorthands and "[WEEKDAYS] *-*-* HH:MM[:SS]" (Mon..Fri 18:00 included).
+// Others can't be told how often they run.
+func CalendarToCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expr, ok := calendarCron[strings.ToLower(spec)]; ok {
+		return ParseCron(expr)
+	}
+	m := calendarSimpleRx.FindStringSubmatch(spec)
+	if m == nil {
+		return nil, fmt.Errorf("%w: can't tell how often %q runs (use i.e. daily or Mon..Fri 18:00)", ErrBadSchedule, spec)
+	}
+	dow := "*"
+	if len(m[1]) != 0 {
+		dow = strings.ToLower(strings.ReplaceAll(m[1], "..", "-"))
+	}
+	cron, err := ParseCron(fmt.Sprintf("%s %s * * %s", m[3], m[2], dow))
+	if err != nil {
+		return nil, fmt.Errorf("%w: calendar event %q", ErrBadSchedule, spec)
+	}
+	return cron, nil
+}
+
+// Quote a command-line argument for Exec*= lines. % and $ are escaped
+// so that systemd does not expand them.
+func SystemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// Where systemd looks for the units of the user: ~/.config/systemd/user
+func UserUnitDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "systemd", "user"), nil
+}
+
+// Write the units into dir (created if needed). Units that exist but
+// were not generated by us are not overwritten.
+func InstallUnits(dir string, units []*UnitFile) error {
+	for _, unit := range units {
+		if data, err := os.ReadFile(filepath.Join(dir, unit.Name)); err == nil && !isGenerated(data) {
+			return fmt.Errorf("%s exists and was not generated by wiper", unit.Name)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, unit := range units {
+		path := filepath.Join(dir, unit.Name)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(unit.Content), 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// The schedules we generated in dir, by name
+func ListUnits(dir string) ([]*InstalledSchedule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	byName := make(map[string]*InstalledSchedule)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if ext != ".service" && ext != ".timer" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil || !isGenerated(data) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+		sched, ok := byName[name]
+		if !ok {
+			sched = &InstalledSchedule{Name: name}
+			byName[name] = sched
+		}
+		sched.Files = append(sched.Files, entry.Name())
+		if ext == ".service" {
+			parseService(sched, data)
+		}
+	}
+
+	result := make([]*InstalledSchedule, 0, len(byName))
+	for _, sched := range byName {
+		result = append(result, sched)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Add -yes to the wipes of the services generated before a wipe without
+// a terminal had to say it (see InstalledSchedule.Outdated), which would
+// be refused. Returns the services rewritten.
+func UpgradeUnits(dir string) ([]string, error) {
+	scheds, err := ListUnits(dir)
+	if err != nil {
+		return nil, err
+	}
+	var upgraded []string
+	for _, sched := range scheds {
+		if !sched.Outdated {
+			continue
+		}
+		name := sched.Name + ".service"
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return upgraded, err
+		}
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			if key, value, _ := strings.Cut(line, "="); isWipeCommand(key, value) && !hasYes(value) {
+				lines[i] = line + " -yes"
+			}
+		}
+		if err := InstallUnits(dir, []*UnitFile{{name, strings.Join(lines, "\n")}}); err != nil {
+			return upgraded, err
+		}
+		upgraded = append(upgraded, name)
+	}
+	return upgraded, nil
+}
+
+// Remove the units of the named schedule. Returns the files removed.
+func RemoveUnits(dir, name string) ([]string, error) {
+	var removed []string
+	for _, ext := range []string{".timer", ".service"} {
+		path := filepath.Join(dir, name+ext)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return removed, err
+		}
+		if !isGenerated(data) {
+			return removed, fmt.Errorf("%s was not generated by wiper", name+ext)
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("%w: no schedule named %q in %s", ErrBadSchedule, name, dir)
+	}
+	return removed, nil
+}
+
+func isGenerated(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(UnitMarker))
+}
+
+func parseService(sched *InstalledSchedule, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "X-Wiper-Browser":
+			sched.Browser = value
+		case "X-Wiper-Preset":
+			sched.Preset = value
+		case "X-Wiper-Schedule":
+			sched.Schedule = value
+		case "ExecStart", "ExecStop":
+			if isWipeCommand(key, value) {
+				sched.Commands = append(sched.Commands, value)
+				sched.Outdated = sched.Outdated || !hasYes(value)
+			}
+		}
+	}
+}
+
+// an ExecStart/ExecStop running wiper (not the /bin/true of a logout)
+func isWipeCommand(key, value string) bool {
+	return (key == "ExecStart" || key == "ExecStop") && value != "/bin/true"
+}
+
+// whether a wiper command line says -yes (or --yes)
+func hasYes(command string) bool {
+	return slices.ContainsFunc(strings.Fields(command), func(arg string) bool {
+		return arg == "-yes" || arg == "--yes"
+	})
+}
+
+// every line is a comment, a [Section] or a Key=value of a section
+func checkUnitSyntax(content string) error {
+	section := ""
+	for i, line := range strings.Split(content, "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = line
+		default:
+			key, value, found := strings.Cut(line, "=")
+			if !found || section == "" || key == "" || strings.TrimSpace(value) == "" {
+				return fmt.Errorf("%w: line %d %q", ErrBadSchedule, i+1, line)
+			}
+		}
+	}
+	return nil
+}
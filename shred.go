@@ -0,0 +1,133 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Shredding files before removal & moving trees across filesystems
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const shredBlock = 64 * 1024
+
+var zeroBlock [shredBlock]byte
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Overwrite the regular files of root (a file or a directory) with
+// zeros so that removing them does not leave their contents on disk.
+// Files with other hard links are left alone: overwriting them would
+// change the other links too. On SSDs & copy-on-write filesystems the
+// old blocks may survive anyway.
+func ShredTree(ctx context.Context, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		finfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if _, links, _, ok := statInfo(finfo); ok && links > 1 {
+			return nil
+		}
+		return shredFile(path, finfo.Size())
+	})
+}
+
+// Move a file or directory tree. Unlike os.Rename() it works across
+// filesystems, copying the tree & then removing the original.
+func MoveTree(src, dest string) error {
+	err := os.Rename(src, dest)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dest); err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func shredFile(path string, size int64) error {
+	fd, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	for left := size; left > 0 && err == nil; left -= shredBlock {
+		_, err = fd.Write(zeroBlock[:min(left, shredBlock)])
+	}
+	if err == nil {
+		err = fd.Sync()
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// copy a tree keeping the permissions, symbolic links are copied as such
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dest, rel)
+		finfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, finfo.Mode().Perm()|0o700)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			return copyFile(path, target, finfo.Mode().Perm())
+		}
+		// sockets, fifos & the like are not worth keeping
+		return nil
+	})
+}
+
+func copyFile(src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -63,6 +63,21 @@ func Test_ByteCountIEC(t *testing.T) {
 	}
 }
 
+func Test_AddThousands(t *testing.T) {
+	cases := map[int64]string{
+		0:       "0",
+		999:     "999",
+		1000:    "1,000",
+		123456:  "123,456",
+		1234567: "1,234,567",
+	}
+	for input, expected := range cases {
+		if result := cmn.AddThousands(input, ','); result != expected {
+			t.Errorf("%d expected %q but got %q", input, expected, result)
+		}
+	}
+}
+
 /* ----------------------------------------------------------------
  *					H e l p e r   F u n c t i o n s
  *-----------------------------------------------------------------*/
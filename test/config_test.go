@@ -0,0 +1,193 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	system, user := filepath.Join(dir, "system.toml"), filepath.Join(dir, "user.toml")
+	os.WriteFile(system, []byte(`
+[defaults]
+size = "iec"
+jobs = 4
+timeout = "10m"
+`), 0o644)
+	os.WriteFile(user, []byte(`
+[defaults]
+jobs = 2
+
+[preset.paranoid]
+profile = true
+keep = []
+
+[browser.chromium]
+size = "si"
+
+[profile."Chromium/Work"]
+keep = ["cookies", "credentials"]
+preset = "quick"
+`), 0o644)
+
+	cfg := cmn.NewConfig("Chromium", "Work")
+	if err := cfg.LoadFiles(system, user); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"WIPER_TIMEOUT": "1h", "WIPER_DRY_RUN": "1"}
+	if err := cfg.ApplyEnv(func(name string) (string, bool) { v, ok := env[name]; return v, ok }); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Set("jobs", "8", "flag -jobs")
+
+	expected := []struct{ name, value, source string }{
+		{"size", "si", user + " [browser.chromium]"},
+		{"jobs", "8", "flag -jobs"},
+		{"timeout", "1h0m0s", "env WIPER_TIMEOUT"},
+		{"dry_run", "true", "env WIPER_DRY_RUN"},
+		{"keep", "cookies,credentials", user + ` [profile."Chromium/Work"]`},
+		{"preset", "quick", user + ` [profile."Chromium/Work"]`},
+		{"profile", "false", user + ` [profile."Chromium/Work"] (preset quick)`},
+		{"output", "human", cmn.SourceDefault},
+	}
+	for _, e := range expected {
+		s := cfg.Lookup(e.name)
+		if s.Value != e.value || s.Source != e.source {
+			t.Errorf("%s: expected %q from %q got %q from %q", e.name, e.value, e.source, s.Value, s.Source)
+		}
+	}
+	if keep := cfg.KeptCategories(); len(keep) != 2 || keep[0] != cmn.CategoryCookies {
+		t.Errorf("Unexpected kept categories %v", keep)
+	}
+
+	// a preset brings its settings, later settings override them
+	cfg.Set("preset", "paranoid", "flag -preset")
+	if !cfg.Bool("profile") || len(cfg.List("keep")) != 0 {
+		t.Errorf("Expected the paranoid preset settings got %q %q", cfg.Value("profile"), cfg.Value("keep"))
+	}
+
+	// another profile only gets the browser & the defaults
+	other := cmn.NewConfig("Firefox", "Work")
+	other.LoadFiles(system, user)
+	if other.Value("size") != "iec" || other.Int("jobs") != 2 {
+		t.Errorf("Unexpected Firefox settings %q %q", other.Value("size"), other.Value("jobs"))
+	}
+}
+
+func Test_ConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	for i, text := range []string{
+		"[defaults]\nsizes = \"si\"\n",
+		"[defaults]\njobs = \"many\"\n",
+		"[defaults]\npreset = \"nope\"\n",
+		"[profile.Work]\ndry_run = true\n",
+		"[defaults]\nkeep = [\"bookmarks\", \"passwords\", \"secrets\"]\n",
+		"[defaults\n",
+	} {
+		file := filepath.Join(dir, "bad.toml")
+		os.WriteFile(file, []byte(text), 0o644)
+		if err := cmn.NewConfig("Chromium", "Work").LoadFiles(file); !errors.Is(err, cmn.ErrBadConfig) {
+			t.Errorf("#%d: expected a config error got %v", i, err)
+		}
+	}
+	if err := cmn.NewConfig("Chromium", "Work").LoadFiles(filepath.Join(dir, "none.toml")); err != nil {
+		t.Errorf("A missing config file is no error, got %v", err)
+	}
+}
+
+// ~/ is the home of the profile's user, not necessarily ours (--home)
+func Test_ConfigHome(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(file, []byte("[defaults]\nbackup_dir = \"~/backup\"\n"), 0o644)
+	alice := filepath.FromSlash("/home/alice")
+	cfg, err := cmn.LoadConfigAt(alice, "Chromium", "Work", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Set("metrics_file", "~/wiper.prom", "flag --metrics-file")
+	if got := cfg.Value("backup_dir"); got != filepath.Join(alice, "backup") {
+		t.Errorf("Expected the backup in alice's home got %q", got)
+	}
+	if got := cfg.Value("metrics_file"); got != filepath.Join(alice, "wiper.prom") {
+		t.Errorf("Expected the metrics in alice's home got %q", got)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	if cfg, _ := cmn.LoadConfig("Chromium", "Work", file); cfg.Value("backup_dir") != filepath.Join(home, "backup") {
+		t.Errorf("Expected the backup in our home got %q", cfg.Value("backup_dir"))
+	}
+}
+
+// The profile name is asked for by the presets that can't be undone &
+// whenever the wipe shreds or keeps nothing
+func Test_ConfigNameChallenge(t *testing.T) {
+	for _, preset := range cmn.NewConfig("Chromium", "Work").Presets() {
+		cfg := cmn.NewConfig("Chromium", "Work")
+		if err := cfg.Set("preset", preset.Name, "flag --preset"); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := cfg.NameChallenge(nil), preset.Name == cmn.PresetParanoid; got != want {
+			t.Errorf("Preset %s: expected the name challenge %t got %t", preset.Name, want, got)
+		}
+	}
+
+	for name, settings := range map[string][]string{
+		"confirm":      {"confirm", "name"},
+		"shred":        {"shred", "true"},
+		"keep nothing": {"keep", ""},
+	} {
+		cfg := cmn.NewConfig("Chromium", "Work")
+		cfg.Set(settings[0], settings[1], "flag")
+		if !cfg.NameChallenge(nil) {
+			t.Errorf("%s: expected the name challenge", name)
+		}
+	}
+	if cfg := cmn.NewConfig("Chromium", "Work"); !cfg.NameChallenge(&cmn.ProfilePolicy{Shred: true}) {
+		t.Error("Expected the name challenge when the policy shreds")
+	}
+	if cfg := cmn.NewConfig("Chromium", "Work"); cfg.NameChallenge(&cmn.ProfilePolicy{Backup: true, BackupDir: "/srv/backup"}) {
+		t.Error("Expected yes to do when nothing is shredded")
+	}
+}
+
+func Test_DirCleanerBackup(t *testing.T) {
+	root, backup := t.TempDir(), filepath.Join(t.TempDir(), "Chromium", "P1")
+	os.WriteFile(filepath.Join(root, "Cookies"), []byte("cookies"), 0o644)
+	os.WriteFile(filepath.Join(root, "History"), []byte("history"), 0o644)
+	os.Mkdir(filepath.Join(root, "Session Storage"), 0o755)
+
+	d := cmn.NewDirCleaner(root, cmn.SizeModeStd, false)
+	d.SetBackupDir(backup)
+	if err := d.CleanUp(context.Background(), []string{"Cookies"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"History", "Session Storage"} {
+		if _, err := os.Stat(filepath.Join(backup, name)); err != nil {
+			t.Errorf("Expected %s in the backup: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected %s gone from the profile", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "Cookies")); err != nil {
+		t.Error("Expected the kept Cookies to stay")
+	}
+}
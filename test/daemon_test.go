@@ -0,0 +1,231 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const daemonConfig = `
+[daemon]
+retry_locked = "10m"
+
+[[job]]
+browser  = "Chromium"
+profile  = "Profile 1"
+schedule = "0 * * * *"
+preset   = "quick"
+
+[[job]]
+name     = "firefox"
+browser  = "Firefox"
+profile  = "default-release"
+schedule = "30 12 * * mon-fri"
+`
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_CronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2024, time.May, 15, 10, 17, 42, 0, time.UTC)
+	expected := map[string]time.Time{
+		"* * * * *":         time.Date(2024, time.May, 15, 10, 18, 0, 0, time.UTC),
+		"*/15 * * * *":      time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC),
+		"0 9-17/4 * * *":    time.Date(2024, time.May, 15, 13, 0, 0, 0, time.UTC),
+		"30 12 * * mon-fri": time.Date(2024, time.May, 15, 12, 30, 0, 0, time.UTC),
+		"0 0 * * 1-5/2":     time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC),
+		"0 0 * * sat,7":     time.Date(2024, time.May, 18, 0, 0, 0, 0, time.UTC),
+		"0 0 * jun-aug mon": time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * fri":      time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC), // either day
+		"17 10 15 5 *":      time.Date(2025, time.May, 15, 10, 17, 0, 0, time.UTC),
+		"0 0 1 jan *":       time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":        time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 30 feb *":      {}, // never
+		"@daily":            time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC),
+		"@every 90m":        from.Add(90 * time.Minute),
+	}
+	for spec, next := range expected {
+		cron, err := cmn.ParseCron(spec)
+		if err != nil {
+			t.Errorf("%q: %v", spec, err)
+			continue
+		}
+		if got := cron.Next(from); !got.Equal(next) {
+			t.Errorf("%q: expected %s got %s", spec, next, got)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 L * *", "@often", "@every 10s"} {
+		if _, err := cmn.ParseCron(spec); !errors.Is(err, cmn.ErrBadCron) {
+			t.Errorf("%q: expected ErrBadCron got %v", spec, err)
+		}
+	}
+}
+
+func Test_DaemonRunDue(t *testing.T) {
+	dir := t.TempDir()
+	configFile, stateFile := filepath.Join(dir, "config.toml"), filepath.Join(dir, "state", "daemon.toml")
+	os.WriteFile(configFile, []byte(daemonConfig), 0o644)
+
+	now := time.Date(2024, time.May, 15, 10, 17, 0, 0, time.Local)
+	inUse := true
+	var ran []string
+	d := cmn.NewDaemon(configFile, stateFile, func(ctx context.Context, job *cmn.DaemonJob) *cmn.JobResult {
+		ran = append(ran, job.Name)
+		now = now.Add(time.Minute)
+		if inUse && job.Browser == "Chromium" {
+			return &cmn.JobResult{Code: cmn.ExitProfileInUse, Err: cmn.ErrProfileInUse}
+		}
+		return &cmn.JobResult{Bytes: 1000, Items: 3}
+	})
+	d.Now = func() time.Time { return now }
+	if err := d.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if d.RunDue(context.Background()) != 0 {
+		t.Error("Expected nothing due yet")
+	}
+
+	// 11:00 the profile is in use: retried 10 minutes later, not a failure
+	now = time.Date(2024, time.May, 15, 11, 0, 0, 0, time.Local)
+	d.RunDue(context.Background())
+	chromium := d.State().Jobs[0]
+	if chromium.LastStatus != cmn.JobInUse || chromium.Skipped != 1 || chromium.Failures != 0 ||
+		!chromium.NextRun.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Unexpected in-use state %+v", chromium)
+	}
+
+	inUse = false
+	now = chromium.NextRun
+	d.RunDue(context.Background())
+	if chromium.LastStatus != cmn.JobOK || chromium.Runs != 1 || chromium.LastBytes != 1000 ||
+		!chromium.NextRun.Equal(time.Date(2024, time.May, 15, 12, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected state %+v", chromium)
+	}
+
+	// 12:30 both are due, one after the other
+	now = time.Date(2024, time.May, 15, 12, 30, 0, 0, time.Local)
+	if n := d.RunDue(context.Background()); n != 2 || ran[len(ran)-1] != "firefox" {
+		t.Errorf("Expected both jobs to run got %d %v", n, ran)
+	}
+
+	// saved & readable by "daemon status"
+	state, err := cmn.ReadDaemonState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Jobs) != 2 || state.Jobs[0].Runs != 2 || state.Jobs[0].Skipped != 1 || state.Jobs[1].Name != "firefox" {
+		t.Errorf("Unexpected saved state %+v", state.Jobs)
+	}
+	var buf bytes.Buffer
+	cmn.WriteDaemonStatus(&buf, state)
+	if !strings.Contains(buf.String(), "Chromium/Profile 1") || !strings.Contains(buf.String(), "30 12 * * mon-fri") {
+		t.Errorf("Unexpected status\n%s", buf.String())
+	}
+}
+
+// A restarted daemon carries on with the counts & last runs of its jobs
+func Test_DaemonRestart(t *testing.T) {
+	dir := t.TempDir()
+	configFile, stateFile := filepath.Join(dir, "config.toml"), filepath.Join(dir, "daemon.toml")
+	os.WriteFile(configFile, []byte(daemonConfig), 0o644)
+	now := time.Date(2024, time.May, 15, 10, 17, 0, 0, time.Local)
+	runner := func(ctx context.Context, job *cmn.DaemonJob) *cmn.JobResult {
+		return &cmn.JobResult{Bytes: 1000, Items: 3}
+	}
+
+	first := cmn.NewDaemon(configFile, stateFile, runner)
+	first.Now = func() time.Time { return now }
+	if err := first.Load(); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Date(2024, time.May, 15, 12, 30, 0, 0, time.Local)
+	if n := first.RunDue(context.Background()); n != 2 {
+		t.Fatalf("Expected both jobs to run got %d", n)
+	}
+	lastRun := first.State().Jobs[0].LastRun
+
+	// stopped right away, it still loads & saves the state
+	now = now.Add(time.Minute)
+	second := cmn.NewDaemon(configFile, stateFile, runner)
+	second.Now = func() time.Time { return now }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := second.Serve(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	state, err := cmn.ReadDaemonState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Jobs) != 2 || state.PID != 0 {
+		t.Fatalf("Unexpected saved state %+v", state)
+	}
+	for _, js := range state.Jobs {
+		if js.Runs != 1 || js.LastBytes != 1000 || js.LastStatus != cmn.JobOK || js.NextRun.IsZero() {
+			t.Errorf("Expected the state of %s kept got %+v", js.Name, js)
+		}
+	}
+	if !state.Jobs[0].LastRun.Equal(lastRun) {
+		t.Errorf("Expected the last run %v got %v", lastRun, state.Jobs[0].LastRun)
+	}
+}
+
+func Test_DaemonReload(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.toml")
+	os.WriteFile(configFile, []byte(daemonConfig), 0o644)
+	d := cmn.NewDaemon(configFile, filepath.Join(dir, "daemon.toml"), nil)
+	if err := d.Load(); err != nil {
+		t.Fatal(err)
+	}
+	d.State().Jobs[0].Runs = 5
+
+	// a broken config keeps the jobs
+	os.WriteFile(configFile, []byte("[[job]]\nbrowser = \"Chromium\"\n"), 0o644)
+	if err := d.Load(); !errors.Is(err, cmn.ErrBadConfig) || len(d.State().Jobs) != 2 {
+		t.Errorf("Expected ErrBadConfig & the previous jobs got %v %d", err, len(d.State().Jobs))
+	}
+
+	// the jobs that are still there keep their state
+	os.WriteFile(configFile, []byte(strings.SplitAfter(daemonConfig, `preset   = "quick"`)[0]), 0o644)
+	if err := d.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.State().Jobs) != 1 || d.State().Jobs[0].Runs != 5 {
+		t.Errorf("Expected the state of the Chromium job kept got %+v", d.State().Jobs)
+	}
+
+	for name, config := range map[string]string{
+		"no jobs":   "[daemon]\n",
+		"cron":      "[[job]]\nbrowser = \"Chromium\"\nprofile = \"a\"\nschedule = \"daily\"\n",
+		"preset":    "[[job]]\nbrowser = \"Chromium\"\nprofile = \"a\"\nschedule = \"@daily\"\npreset = \"nuke\"\n",
+		"duplicate": "[[job]]\nbrowser = \"C\"\nprofile = \"a\"\nschedule = \"@daily\"\n[[job]]\nbrowser = \"C\"\nprofile = \"a\"\nschedule = \"@hourly\"\n",
+		"syntax":    "[[job]\n",
+	} {
+		os.WriteFile(configFile, []byte(config), 0o644)
+		if _, err := cmn.LoadDaemonConfig(configFile); !errors.Is(err, cmn.ErrBadConfig) {
+			t.Errorf("%s: expected ErrBadConfig got %v", name, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -156,7 +157,7 @@ func Test_DirCleanerCache(t *testing.T) {
 	}
 
 	cleaner := wipechromium.NewDirCleanerVFS(mfs, CacheDir, wipechromium.SizeModeSI, logx)
-	if err := cleaner.CleanUp(ExceptionsCache); err == nil {
+	if err := cleaner.CleanUp(context.Background(), ExceptionsCache); err == nil {
 		fmt.Printf("Cache cleaned: %d\n%s\n", cleaner.CleanedSize(), cleaner)
 		if count := tallyTree(mfs, CacheDir); count != -1 && count < totalObjects {
 			fmt.Println("Appears OK")
@@ -183,7 +184,7 @@ func Test_DirCleanerData(t *testing.T) {
 	}
 
 	cleaner := wipechromium.NewDirCleanerVFS(mfs, DataDir, wipechromium.SizeModeSI, logx)
-	if err := cleaner.CleanUp(ExceptionsData); err == nil {
+	if err := cleaner.CleanUp(context.Background(), ExceptionsData); err == nil {
 		fmt.Printf("Data cleaned: %d\n%s\n", cleaner.CleanedSize(), cleaner)
 		if count := tallyTree(mfs, DataDir); count != -1 && count < totalObjects {
 			fmt.Println("Appears OK")
@@ -0,0 +1,82 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_DirectoryUsage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no block counts nor hardlinks on this platform")
+	}
+
+	const (
+		FileSize   = 10000
+		SparseSize = 8 << 20
+	)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "Cookies"), make([]byte, FileSize), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(root, "Cookies"), filepath.Join(root, "Cookies-link")); err != nil {
+		t.Skip("hardlinks not supported:", err)
+	}
+	fd, err := os.Create(filepath.Join(root, "sparse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Truncate(SparseSize)
+	fd.Close()
+
+	usage, err := cmn.GetDirectoryUsage(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hardlink is counted once
+	if usage.Apparent != FileSize+SparseSize {
+		t.Errorf("Expected apparent size %d got %d", FileSize+SparseSize, usage.Apparent)
+	}
+	if size, _ := cmn.GetDirectorySize(context.Background(), root); size != usage.Apparent {
+		t.Errorf("GetDirectorySize %d differs from apparent size %d", size, usage.Apparent)
+	}
+	// the sparse file has (almost) nothing allocated
+	if usage.Allocated < FileSize || usage.Allocated >= SparseSize {
+		t.Errorf("Unexpected allocated size %d", usage.Allocated)
+	}
+
+	// the link count drops as links are removed, yet it is counted once
+	sizer := cmn.NewDiskSizer()
+	finfo, _ := os.Lstat(filepath.Join(root, "Cookies"))
+	first := sizer.Of(finfo)
+	os.Remove(filepath.Join(root, "Cookies"))
+	finfo, _ = os.Lstat(filepath.Join(root, "Cookies-link"))
+	if second := sizer.Of(finfo); first.Apparent != FileSize || second.Apparent != 0 {
+		t.Errorf("Expected hardlink counted once got %d & %d", first.Apparent, second.Apparent)
+	}
+}
+
+func Test_DiskSizeFormat(t *testing.T) {
+	size := cmn.DiskSize{Apparent: 1500, Allocated: 4096}
+	if got := size.Format(cmn.SizeModeStd); got != "1,500 (4,096 on disk)" {
+		t.Errorf("Unexpected %q", got)
+	}
+	size.Add(cmn.DiskSize{Apparent: 500, Allocated: 4096})
+	if size.Apparent != 2000 || size.Allocated != 8192 {
+		t.Errorf("Unexpected sum %+v", size)
+	}
+}
@@ -0,0 +1,127 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_AnalyzeDiskUsage(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"Bookmarks":                   100,
+		"Cookies":                     4000,
+		"History":                     2000,
+		"Local Storage/leveldb/a.ldb": 3000,
+		"Local Storage/leveldb/b.ldb": 1000,
+		"Extensions/abc/1.0/main.js":  5000,
+		"Extension State/LOG":         50,
+		"Extension State/000003.log":  70,
+	}
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	classifier := &chromium.ChromiumClassifier{Exceptions: chromium.ProfileExceptions}
+	node, err := cmn.AnalyzeDiskUsage(root, cmn.RootProfile, classifier, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Size != 15220 {
+		t.Errorf("Expected total size 15220 got %d", node.Size)
+	}
+	if node.Children[0].Name != "Extensions" || node.Children[0].Verdict != cmn.ItemKept {
+		t.Errorf("Expected kept Extensions first got %s %s", node.Children[0].Verdict, node.Children[0].Name)
+	}
+
+	expect := map[string]struct {
+		category cmn.Category
+		verdict  cmn.ItemAction
+	}{
+		"Bookmarks":       {cmn.CategoryBookmarks, cmn.ItemKept},
+		"Cookies":         {cmn.CategoryCookies, cmn.ItemDeleted},
+		"History":         {cmn.CategoryHistory, cmn.ItemDeleted},
+		"Local Storage":   {cmn.CategorySiteStorage, cmn.ItemDeleted},
+		"Extension State": {cmn.CategoryExtensions, cmn.ItemKept},
+	}
+	for _, child := range node.Children {
+		if want, ok := expect[child.Name]; ok {
+			if child.Category != want.category || child.Verdict != want.verdict {
+				t.Errorf("%s: expected %s/%s got %s/%s", child.Name, want.category, want.verdict, child.Category, child.Verdict)
+			}
+		}
+		// depth 2: children of Local Storage are not expanded
+		if child.Name == "Local Storage" && (len(child.Children) != 1 || child.Children[0].Children != nil) {
+			t.Errorf("Local Storage expanded beyond depth: %+v", child.Children)
+		}
+		// extension junk inside a kept directory is still deleted
+		if child.Name == "Extension State" {
+			for _, junk := range child.Children {
+				if junk.Verdict != cmn.ItemDeleted || junk.Category != cmn.CategoryLogs {
+					t.Errorf("%s/%s: expected deleted logs got %s/%s", child.Name, junk.Name, junk.Verdict, junk.Category)
+				}
+			}
+		}
+	}
+
+	report := cmn.NewDiskUsageReport("Chromium", "Profile 1", 2, 3, node)
+	if len(report.Roots[0].Children) != 3 || report.Roots[0].Omitted != 3 {
+		t.Errorf("Expected top 3 with 3 omitted got %d/%d", len(report.Roots[0].Children), report.Roots[0].Omitted)
+	}
+	// totals are computed on the full tree, not the pruned one
+	if sum := report.ByVerdict[cmn.ItemDeleted] + report.ByVerdict[cmn.ItemKept]; sum != node.Size {
+		t.Errorf("Expected verdict totals of %d got %d", node.Size, sum)
+	}
+	if report.ByCategory[cmn.CategoryLogs] != 120 {
+		t.Errorf("Expected 120 bytes of logs got %d", report.ByCategory[cmn.CategoryLogs])
+	}
+
+	var buf bytes.Buffer
+	cmn.NewRenderer(cmn.OutputJSON, &buf, cmn.SizeModeStd).DiskUsage(report)
+	var decoded cmn.DiskUsageReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ByCategory[cmn.CategorySiteStorage] != 4000 {
+		t.Errorf("Expected site-storage 4000 in JSON got %v", decoded.ByCategory)
+	}
+
+	buf.Reset()
+	cmn.RenderDiskUsage(&buf, report, cmn.SizeModeStd)
+	if !strings.Contains(buf.String(), "… 3 more") {
+		t.Errorf("Expected omitted marker in:\n%s", buf.String())
+	}
+}
+
+func Test_ParseCategory(t *testing.T) {
+	for _, cat := range cmn.Categories() {
+		if parsed, err := cmn.ParseCategory(strings.ToUpper(cat.String())); err != nil || parsed != cat {
+			t.Errorf("%s: got %s %v", cat, parsed, err)
+		}
+	}
+	if _, err := cmn.ParseCategory("junk"); err != cmn.ErrUnknownCategory {
+		t.Errorf("Expected ErrUnknownCategory got %v", err)
+	}
+}
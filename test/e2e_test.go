@@ -0,0 +1,310 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						E n d   t o   E n d   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+	"github.com/lordofscripts/wipechromium/testkit"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	// the wiper binary, built once for all the tests
+	wiperOnce sync.Once
+	wiperBin  string
+	wiperErr  error
+	// what changes from one run to the next in the human output
+	durationRE = regexp.MustCompile(`\d+(\.\d+)?(ns|µs|ms|s|m)\b`)
+	onDiskRE   = regexp.MustCompile(`\([\d,.]+ ?\w* on disk\)`)
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// What a run of wiper left: its output & exit code
+type wiperRun struct {
+	stdout, stderr string
+	code           int
+}
+
+// A fake home with a generated Chromium & Firefox in it
+type e2eHome struct {
+	dir      string
+	chromium *testkit.Tree
+	firefox  *testkit.Tree
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// The browser directories of the home, for Manifest()
+func (h *e2eHome) browserDirs() []string {
+	dirs := make([]string, 0)
+	for _, tree := range []*testkit.Tree{h.chromium, h.firefox} {
+		dirs = append(dirs, tree.Root)
+		for _, p := range tree.Profiles {
+			if cache := filepath.Dir(p.Cache); !slices.Contains(dirs, cache) && !strings.HasPrefix(cache, tree.Root) {
+				dirs = append(dirs, cache)
+			}
+		}
+	}
+	return dirs
+}
+
+func (h *e2eHome) manifest(t *testing.T) string {
+	t.Helper()
+	manifest, err := testkit.Manifest(h.chromium.Env, h.browserDirs()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The wiper binary of the end-to-end tests goes once they are done
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if len(wiperBin) != 0 {
+		os.RemoveAll(filepath.Dir(wiperBin))
+	}
+	os.Exit(code)
+}
+
+func Test_E2EScan(t *testing.T) {
+	home := newE2EHome(t, false)
+	run := runWiper(t, home, "", "scan", "-o", "json")
+	if run.code != cmn.ExitOK {
+		t.Fatalf("Unexpected exit code %d: %s", run.code, run.stderr)
+	}
+	var report cmn.ScanReport
+	if err := json.Unmarshal([]byte(run.stdout), &report); err != nil {
+		t.Fatalf("Not a JSON scan: %v\n%s", err, run.stdout)
+	}
+	if len(report.Browsers) != 2 {
+		t.Fatalf("Expected a scan of both browsers, got %d", len(report.Browsers))
+	}
+	for _, scan := range report.Browsers {
+		if !scan.DataExists || !scan.CacheExists || !scan.Installed || len(scan.Profiles) != 2 {
+			t.Errorf("Unexpected scan of %s: %+v", scan.Browser, scan)
+		}
+	}
+
+	// the human one lists both, without asking for anything
+	run = runWiper(t, home, "", "scan")
+	if run.code != cmn.ExitOK || !strings.Contains(run.stdout, "Chromium") || !strings.Contains(run.stdout, "Firefox") {
+		t.Errorf("Unexpected human scan (%d):\n%s", run.code, run.stdout)
+	}
+}
+
+// Wipe a profile of each browser for real & compare what is left with
+// the golden manifest: a change of what is kept shows up there.
+func Test_E2EWipe(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the golden files are of the Linux layout")
+	}
+	home := newE2EHome(t, false)
+	before := home.manifest(t)
+
+	// (a) a dry run (a plan on stdout) leaves it all be
+	run := runWiper(t, home, "", "wipe", "-b", "Chromium", "-n", "Profile 1", "--dry-run", "--progress=false")
+	if run.code != cmn.ExitOK {
+		t.Fatalf("Unexpected dry run exit code %d: %s%s", run.code, run.stdout, run.stderr)
+	}
+	checkGolden(t, "e2e-chromium-dry-run.stdout", scrubOutput(home, run.stdout))
+	if home.manifest(t) != before {
+		t.Error("Expected a dry run to change nothing")
+	}
+
+	// (b) the real thing, with the JSON report
+	for _, target := range [][]string{{"Chromium", "Profile 1"}, {"Firefox", "work"}} {
+		run = runWiper(t, home, "", "wipe", "-b", target[0], "-n", target[1], "--yes", "-o", "json")
+		if run.code != cmn.ExitOK {
+			t.Fatalf("%s: Unexpected exit code %d: %s%s", target[0], run.code, run.stdout, run.stderr)
+		}
+		var report cmn.WipeReport
+		if err := json.Unmarshal([]byte(run.stdout), &report); err != nil {
+			t.Fatalf("%s: Not a JSON report: %v\n%s", target[0], err, run.stdout)
+		}
+		if report.DryRun || report.TotalItems == 0 || len(report.Errors) != 0 || report.Profile != target[1] {
+			t.Errorf("%s: Unexpected report of %d items, %d errors", target[0], report.TotalItems, len(report.Errors))
+		}
+	}
+	checkGolden(t, "e2e-wipe.manifest", home.manifest(t))
+
+	// (c) whatever the Chromium profile had of its exceptions is still there
+	p := home.chromium.Profile("Profile 1")
+	for _, name := range chromium.ProfileExceptions {
+		if had := strings.Contains(before, "/Profile 1/"+name); had && !home.chromium.Exists(p, "{data}/"+name) {
+			t.Errorf("Expected the %q exception kept", name)
+		}
+	}
+}
+
+// The exit codes of bad flags & of what stops a wipe, with nothing
+// removed in any case
+func Test_E2EExitCodes(t *testing.T) {
+	home := newE2EHome(t, false)
+	before := home.manifest(t)
+
+	// with -o json stdout is a JSON document even on a failure
+	cases := []struct {
+		args    []string
+		code    int
+		message string
+		json    bool
+	}{
+		{[]string{"wipe", "-b", "Chromium"}, cmn.ExitUsage, "profile", false},
+		{[]string{"wipe", "-b", "Netscape", "-n", "Default"}, cmn.ExitBadBrowser, "Netscape", false},
+		{[]string{"wipe", "-n", "Default", "--size", "Huge"}, cmn.ExitBadSizeMode, "Huge", false},
+		{[]string{"wipe", "-n", "Default", "-o", "yaml"}, cmn.ExitBadOutput, "yaml", false},
+		{[]string{"wipe", "-n", "Default", "--jobs", "-1"}, cmn.ExitUsage, "negative", false},
+		{[]string{"wipe", "-n", "Default", "--jobs", "-1", "-o", "json"}, cmn.ExitUsage, "negative", true},
+		{[]string{"wipe", "-n", "Default", "--timeout", "-1s", "-o", "ndjson"}, cmn.ExitUsage, "negative", true},
+		{[]string{"wipe", "-n", "Default", "--no-such-flag"}, cmn.ExitUsage, "no-such-flag", false},
+		{[]string{"apply", filepath.Join(home.dir, "missing.plan")}, cmn.ExitUsage, "missing.plan", false},
+		// no such profile, or a name that can't be that of one
+		{[]string{"wipe", "-b", "Chromium", "-n", "Nope", "--yes"}, cmn.ExitNoSuchProfile, "No such profile", false},
+		{[]string{"wipe", "-b", "Chromium", "-n", "Nope", "--yes", "-o", "json"}, cmn.ExitNoSuchProfile, "No such profile", true},
+		{[]string{"wipe", "-b", "Chromium", "-n", "../x", "--yes", "-o", "json"}, cmn.ExitNoSuchProfile, "does not exist", true},
+		{[]string{"wipe", "-b", "Firefox", "-n", "nope", "--yes"}, cmn.ExitNoSuchProfile, "nope", false},
+		{[]string{"wipe", "-b", "Firefox", "-n", "nope", "--yes", "-o", "json"}, cmn.ExitNoSuchProfile, "nope", true},
+		// no terminal to confirm it on & no --yes
+		{[]string{"wipe", "-b", "Chromium", "-n", "Default"}, cmn.ExitNotConfirmed, "--yes", false},
+	}
+	for _, tc := range cases {
+		run := runWiper(t, home, "", tc.args...)
+		if run.code != tc.code {
+			t.Errorf("%v: Expected exit code %d instead of %d\n%s%s", tc.args, tc.code, run.code, run.stdout, run.stderr)
+		} else if !strings.Contains(run.stdout+run.stderr, tc.message) {
+			t.Errorf("%v: Expected %q in the message\n%s%s", tc.args, tc.message, run.stdout, run.stderr)
+		}
+		var document map[string]any
+		if err := json.Unmarshal([]byte(run.stdout), &document); tc.json && err != nil {
+			t.Errorf("%v: Expected a JSON document on stdout: %v\n%s", tc.args, err, run.stdout)
+		}
+	}
+	if home.manifest(t) != before {
+		t.Error("Expected nothing removed")
+	}
+}
+
+func Test_E2EProfileInUse(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no symbolic link locks on Windows")
+	}
+	home := newE2EHome(t, true)
+	before := home.manifest(t)
+	run := runWiper(t, home, "", "wipe", "-b", "Chromium", "-n", "Default", "--yes", "-o", "json")
+	if run.code != cmn.ExitProfileInUse {
+		t.Errorf("Expected exit code %d instead of %d\n%s", cmn.ExitProfileInUse, run.code, run.stdout)
+	}
+	if home.manifest(t) != before {
+		t.Error("Expected nothing removed from a profile in use")
+	}
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// A fake home with both browsers, locked (by this process) if asked
+func newE2EHome(t *testing.T, locked bool) *e2eHome {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds & runs wiper")
+	}
+	dir := filepath.Join(t.TempDir(), "alice")
+	env, err := testkit.OnDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := testkit.DefaultSpec()
+	spec.Locked = locked
+	home := &e2eHome{dir: dir}
+	if home.chromium, err = testkit.Chromium(env, spec); err != nil {
+		t.Fatal(err)
+	}
+	if home.firefox, err = testkit.Firefox(env, spec); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+// Run the wiper binary (built the first time) in the fake home: HOME,
+// the XDG directories & no WIPER_ settings from the outside.
+func runWiper(t *testing.T, home *e2eHome, stdin string, args ...string) *wiperRun {
+	t.Helper()
+	wiperOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wiper-e2e")
+		if err != nil {
+			wiperErr = err
+			return
+		}
+		wiperBin = filepath.Join(dir, "wiper")
+		if runtime.GOOS == "windows" {
+			wiperBin += ".exe"
+		}
+		build := exec.Command("go", "build", "-o", wiperBin, "../cmd/wiper")
+		if output, err := build.CombinedOutput(); err != nil {
+			wiperErr = errors.New(string(output))
+		}
+	})
+	if wiperErr != nil {
+		t.Fatalf("Could not build wiper: %v", wiperErr)
+	}
+
+	cmd := exec.Command(wiperBin, args...)
+	cmd.Env = []string{
+		"HOME=" + home.dir,
+		"USERPROFILE=" + home.dir,
+		"XDG_CONFIG_HOME=" + filepath.Join(home.dir, ".config"),
+		"XDG_CACHE_HOME=" + filepath.Join(home.dir, ".cache"),
+		"XDG_STATE_HOME=" + filepath.Join(home.dir, ".local", "state"),
+		"PATH=" + os.Getenv("PATH"),
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	run := &wiperRun{stdout.String(), stderr.String(), 0}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		run.code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+// the human output without what changes from one run to the next
+func scrubOutput(home *e2eHome, output string) string {
+	output = strings.ReplaceAll(output, home.dir, "~")
+	output = onDiskRE.ReplaceAllString(output, "(on disk)")
+	return durationRE.ReplaceAllString(output, "<duration>")
+}
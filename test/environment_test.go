@@ -0,0 +1,111 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lordofscripts/vfs"
+	"github.com/lordofscripts/vfs/memfs"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+	"github.com/lordofscripts/wipechromium/browsers/firefox"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_Environment(t *testing.T) {
+	home := t.TempDir()
+	env, err := cmn.NewEnvironment(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Home != home || !env.IsOS() || !strings.HasPrefix(env.ConfigHome, home) {
+		t.Errorf("Unexpected environment %+v", env)
+	}
+	if got := env.FromHome(env.AtHome(".mozilla", "firefox")); got != filepath.Join("~", ".mozilla", "firefox") {
+		t.Errorf("Unexpected path from home %q", got)
+	}
+	if _, err := cmn.NewEnvironment(filepath.Join(home, "nobody")); err == nil {
+		t.Error("Expected an error for a missing home")
+	}
+}
+
+func Test_EnvironmentChromium(t *testing.T) {
+	env := cmn.NewEnvironmentVFS(memfs.Create(), "/home/alice")
+	data, cache := chromium.GetChromiumDirs(env)
+	for _, dir := range []string{"System Profile", "Default", "Avatars", "Safe Browsing", "Work/Extension Rules", "Work/Sessions"} {
+		vfs.MkdirAll(env.FS, filepath.Join(data, dir), 0o700)
+	}
+	vfs.MkdirAll(env.FS, filepath.Join(cache, "Work", "Cache"), 0o700)
+	vfs.MkdirAll(env.FS, filepath.Join(cache, "Work", "Code Cache"), 0o700)
+	for name, size := range map[string]int{"Preferences": 10, "Bookmarks": 10, "Cookies": 1000, "Extension Rules/LOG": 2} {
+		vfs.WriteFile(env.FS, filepath.Join(data, "Work", name), make([]byte, size), 0o600)
+	}
+	vfs.WriteFile(env.FS, filepath.Join(cache, "Work", "Cache", "data_0"), make([]byte, 500), 0o600)
+
+	wipe := func(dry bool) *cmn.WipeReport {
+		cleaner, err := chromium.NewChromiumCleaner(env, "Work", cmn.SizeModeStd, dry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cleaner.SetRenderer(cmn.NewRenderer(cmn.OutputHuman, io.Discard, cmn.SizeModeStd))
+		if !cleaner.IdentifyAppDataRoot() {
+			t.Fatal("Expected the in-memory Chromium identified")
+		}
+		if names, err := cleaner.FindProfileNames(); err != nil || !slices.Equal(names, []string{"Work"}) {
+			t.Fatalf("Unexpected profiles %v %v", names, err)
+		}
+		if err, code := cleaner.ClearProfile(context.Background(), true, true); err != nil {
+			t.Fatalf("Unexpected failure %v (%d)", err, code)
+		}
+		return cleaner.Report()
+	}
+
+	// a dry run sizes it all but leaves it be
+	if report := wipe(true); report.TotalBytes != 1502 || env.IsFile(filepath.Join(data, "Work", "Cookies")) != cmn.Yes {
+		t.Errorf("Unexpected dry run of %d bytes", report.TotalBytes)
+	}
+	if report := wipe(false); report.TotalBytes != 1502 || report.TotalItems != 4 {
+		t.Errorf("Unexpected wipe of %d items %d bytes", report.TotalItems, report.TotalBytes)
+	}
+	for _, gone := range []string{"Work/Cookies", "Work/Sessions", "Work/Extension Rules/LOG"} {
+		if _, err := env.FS.Lstat(filepath.Join(data, gone)); err == nil {
+			t.Errorf("Expected %s removed", gone)
+		}
+	}
+	if env.IsFile(filepath.Join(data, "Work", "Bookmarks")) != cmn.Yes || env.IsDirectory(filepath.Join(cache, "Work")) {
+		t.Error("Expected the bookmarks kept & the cache removed")
+	}
+}
+
+func Test_EnvironmentFirefox(t *testing.T) {
+	env := cmn.NewEnvironmentVFS(memfs.Create(), "/home/alice")
+	_, root := firefox.GetRootDataDir(env)
+	vfs.MkdirAll(env.FS, filepath.Join(root, "abcd1234.default-release"), 0o700)
+	vfs.WriteFile(env.FS, filepath.Join(root, "profiles.ini"),
+		[]byte("[Profile0]\nName=default-release\nPath=abcd1234.default-release\nDefault=1\n"), 0o600)
+
+	cleaner, err := firefox.NewFirefoxCleaner(env, "", true, cmn.SizeModeStd, true)
+	if err != nil {
+		t.Fatalf("Expected a Firefox cleaner of the in-memory profiles.ini: %v", err)
+	}
+	if names, err := cleaner.FindProfileNames(); err != nil || !slices.Equal(names, []string{"default-release (default)"}) {
+		t.Errorf("Unexpected profiles %v %v", names, err)
+	}
+	if scan := cleaner.Inspect(); !scan.DataExists || scan.CacheExists {
+		t.Errorf("Unexpected scan %+v", scan)
+	}
+}
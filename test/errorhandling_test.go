@@ -8,6 +8,11 @@ package test
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 
 	cmn "github.com/lordofscripts/wipechromium"
@@ -36,6 +41,88 @@ func Test_WrapError(t *testing.T) {
 	}
 }
 
+func Test_TypedError(t *testing.T) {
+	denied := &fs.PathError{Op: "unlinkat", Path: "/c/x", Err: syscall.EACCES}
+	err := cmn.WrapError(denied, cmn.ExitCacheRemove, "Could not remove cache dir %q", "/c")
+
+	// matches the sentinel of its code & its cause, not other sentinels
+	if !errors.Is(err, cmn.ErrCacheRemove) || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected errors.Is() sentinel & cause for %v", err)
+	}
+	if errors.Is(err, cmn.ErrProfileInUse) {
+		t.Errorf("Unexpected match with %v", cmn.ErrProfileInUse)
+	}
+	var typed *cmn.Error
+	if !errors.As(err, &typed) || typed.Category != cmn.ErrorFilesystem || len(typed.Hint) == 0 {
+		t.Errorf("Expected a filesystem error with a hint got %#v", typed)
+	}
+	if !strings.HasPrefix(err.Error(), "E-041 ") {
+		t.Errorf("Expected the code in %q", err)
+	}
+
+	// exit codes of typed errors, sentinels & anything else
+	inUse := cmn.NewError(cmn.ExitProfileInUse, "lock", "/p/SingletonLock", nil)
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("wrapped: %w", inUse), cmn.ExitProfileInUse},
+		{cmn.ErrNotBrowserProfile, cmn.ExitNotProfile},
+		{fmt.Errorf("phase: %w", cmn.ErrNotBrowserCache), cmn.ExitNotCache},
+		{errors.New("anything"), cmn.ExitCachePhase},
+		{nil, cmn.ExitCachePhase},
+	} {
+		if code := cmn.ExitCode(tc.err, cmn.ExitCachePhase); code != tc.code {
+			t.Errorf("Expected %d for %v got %d", tc.code, tc.err, code)
+		}
+	}
+	if !errors.Is(inUse, cmn.ErrProfileInUse) || !strings.Contains(inUse.Error(), "/p/SingletonLock") {
+		t.Errorf("Unexpected %v", inUse)
+	}
+}
+
+func Test_ErrorCatalog(t *testing.T) {
+	seen := make(map[int]bool)
+	last := -1
+	for _, info := range cmn.ErrorCatalog() {
+		if seen[info.Code] || info.Code < last {
+			t.Errorf("Code %d repeated or out of order", info.Code)
+		}
+		seen[info.Code], last = true, info.Code
+		if len(info.Summary) == 0 || (info.Code != cmn.ExitOK && len(info.Hint) == 0) {
+			t.Errorf("Code %d lacks a summary or hint", info.Code)
+		}
+		if info.Sentinel != nil && cmn.ExitCode(info.Sentinel, -1) != info.Code {
+			t.Errorf("Sentinel %v does not map to %d", info.Sentinel, info.Code)
+		}
+	}
+
+	var sb strings.Builder
+	cmn.WriteExitCodes(&sb)
+	if !strings.Contains(sb.String(), "42") || strings.Count(sb.String(), "\n") != len(seen)+1 {
+		t.Errorf("Unexpected exit code table:\n%s", sb.String())
+	}
+}
+
+func Test_HeldLock(t *testing.T) {
+	dir := t.TempDir()
+	if lock := cmn.HeldLock(dir, "SingletonLock"); lock != "" {
+		t.Errorf("Expected no lock got %s", lock)
+	}
+
+	// a crashed browser leaves its lock behind
+	os.Symlink("myhost-999999999", filepath.Join(dir, "SingletonLock"))
+	if lock := cmn.HeldLock(dir, "SingletonLock"); lock != "" {
+		t.Errorf("Expected a stale lock got %s", lock)
+	}
+
+	// Firefox style lock held by a running process (this one)
+	os.Symlink(fmt.Sprintf("127.0.1.1:+%d", os.Getpid()), filepath.Join(dir, "lock"))
+	if lock := cmn.HeldLock(dir, "SingletonLock", "lock"); lock != filepath.Join(dir, "lock") {
+		t.Errorf("Expected the lock to be held got %q", lock)
+	}
+}
+
 /* ----------------------------------------------------------------
  *					H e l p e r   F u n c t i o n s
  *-----------------------------------------------------------------*/
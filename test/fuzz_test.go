@@ -0,0 +1,149 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						F u z z   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+	"github.com/lordofscripts/wipechromium/browsers/firefox"
+	"github.com/lordofscripts/wipechromium/testkit"
+)
+
+/* ----------------------------------------------------------------
+ *				F u z z   T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// go test ./test -run '^$' -fuzz Fuzz_FirefoxProfilesIni
+//
+// Whatever profiles.ini says, the profiles it gives are directories
+// under the Firefox root.
+func Fuzz_FirefoxProfilesIni(f *testing.F) {
+	env := testkit.InMemory(fuzzHome())
+	tree, err := testkit.Firefox(env, testkit.DefaultSpec())
+	if err != nil {
+		f.Fatal(err)
+	}
+	generated, err := env.ReadFile(filepath.Join(tree.Root, "profiles.ini"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(generated)
+	for _, seed := range []string{
+		"[Profile0]\nName=up\nIsRelative=1\nPath=../../.ssh\n",
+		"[Profile0]\nName=abs\nIsRelative=0\nPath=/etc\n",
+		"[Profile0]\nName=inside\nIsRelative=0\nPath=" + filepath.Join(fuzzRoot(), "abcd1234.inside") + "\n",
+		"[Profile0]\nName=dot\nPath=.\n[Profile1]\nName=empty\nPath=\n",
+		"[Profile0]\nName=sneaky\nPath=Profiles/x/../../../..\n",
+		"[Profile0]\nName=win\nIsRelative=1\nPath=Profiles\\abcd.default\n",
+		"[profile0]\nname=\"quoted\"\npath=`raw`\nisrelative=yes\ndefault=maybe\n",
+		"[Profile0\nName",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	root := fuzzRoot()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dirs, err := firefox.ProfileDirs(root, data)
+		if err != nil {
+			return
+		}
+		for name, dir := range dirs {
+			if !filepath.IsLocal(dir) || filepath.Clean(dir) == "." {
+				t.Fatalf("Profile %q: %q is not a directory under the root", name, dir)
+			}
+			if rel, ok := cmn.Within(root, filepath.Join(root, dir)); !ok || rel == "." {
+				t.Fatalf("Profile %q: %q takes it out of %s", name, dir, root)
+			}
+		}
+	})
+}
+
+// Whatever profile name is given, a Chromium cleaner (if there is one)
+// wipes a directory of that very name under the data & cache roots, else
+// the name is that of no profile.
+func Fuzz_ChromiumProfileDir(f *testing.F) {
+	for _, seed := range []string{"Default", "Profile 1", "", ".", "..", "../..", "/etc", "a/../..", `..\..`, " Default\t"} {
+		f.Add(seed)
+	}
+
+	env := testkit.InMemory(fuzzHome())
+	data, cache := chromium.GetChromiumDirs(env)
+	f.Fuzz(func(t *testing.T, profile string) {
+		c, err := chromium.NewChromiumCleaner(env, profile, cmn.SizeModeStd, true)
+		if err != nil {
+			if c != nil || !errors.Is(err, cmn.ErrProfileDoesNotExist) {
+				t.Fatalf("Profile %q: expected no cleaner & ErrProfileDoesNotExist got %v", profile, err)
+			}
+			return
+		}
+		if len(profile) == 0 {
+			// a scan, nothing to wipe
+			if len(c.ProfileRoot) != 0 || len(c.CacheRoot) != 0 {
+				t.Fatalf("Expected no directories without a profile got %q & %q", c.ProfileRoot, c.CacheRoot)
+			}
+			return
+		}
+		if rel, ok := cmn.Within(data, c.ProfileRoot); !ok || rel == "." {
+			t.Fatalf("Profile %q: %q is not under %s", profile, c.ProfileRoot, data)
+		}
+		if rel, ok := cmn.Within(cache, c.CacheRoot); !ok || rel == "." {
+			t.Fatalf("Profile %q: %q is not under %s", profile, c.CacheRoot, cache)
+		}
+		if c.ProfileName != profile || filepath.Base(c.ProfileRoot) != profile {
+			t.Fatalf("Profile %q: named %q in %q", profile, c.ProfileName, c.ProfileRoot)
+		}
+	})
+}
+
+// JoinWithin() never leads out of the root, Within() finds the way back
+// & what is under home is shown from ~
+func Fuzz_PathJoin(f *testing.F) {
+	f.Add("home/alice", ".config", "chromium")
+	f.Add("home/alice", "..", "bob")
+	f.Add("home/alice", "/etc", "passwd")
+	f.Add("home/alice", "a/../../alice2", "")
+	f.Add("", "", "")
+	f.Add("home/alice/", "..foo", ".")
+
+	f.Fuzz(func(t *testing.T, root, a, b string) {
+		root = filepath.Join(string(filepath.Separator), root)
+		path, ok := cmn.JoinWithin(root, a, b)
+		if !ok {
+			return
+		}
+		rel, ok := cmn.Within(root, path)
+		if !ok {
+			t.Fatalf("JoinWithin(%q, %q, %q) = %q is out of the root", root, a, b, path)
+		}
+		if back := filepath.Join(root, rel); back != path {
+			t.Fatalf("Within(%q, %q) = %q leads to %q", root, path, rel, back)
+		}
+
+		env := cmn.NewEnvironmentVFS(nil, root)
+		if shown := env.FromHome(env.AtHome(a, b)); !strings.HasPrefix(shown, "~") {
+			t.Fatalf("Expected %q shown from ~ (home %s)", shown, root)
+		}
+	})
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func fuzzHome() string {
+	return filepath.FromSlash("/home/alice")
+}
+
+// the Firefox root in fuzzHome() (where Linux has it)
+func fuzzRoot() string {
+	return filepath.Join(fuzzHome(), ".mozilla", "firefox")
+}
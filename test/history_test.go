@@ -0,0 +1,107 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ReportCategorize(t *testing.T) {
+	profileRoot, cacheRoot := "/home/u/.config/chromium/P1", "/home/u/.cache/chromium/P1"
+	report := cmn.NewWipeReport("Chromium", "P1", false)
+	report.Record(cmn.PhaseCache, cmn.NewDeletedItem(cacheRoot, true, 5000, cmn.RuleCache+"*"))
+	report.Record(cmn.PhaseProfile,
+		cmn.NewDeletedItem(filepath.Join(profileRoot, "Cookies"), false, 300, ""),
+		cmn.NewDeletedItem(filepath.Join(profileRoot, "History"), false, 200, ""),
+		cmn.NewKeptItem(filepath.Join(profileRoot, "Bookmarks"), false, 100, ""),
+		cmn.NewDeletedItem("/elsewhere/file", false, 7, ""))
+	report.Categorize(&chromium.ChromiumClassifier{Exceptions: chromium.ProfileExceptions}, profileRoot, cacheRoot)
+
+	expected := map[cmn.Category]int64{cmn.CategoryCache: 5000, cmn.CategoryCookies: 300,
+		cmn.CategoryHistory: 200, cmn.CategoryOther: 7}
+	if len(report.Categories) != len(expected) {
+		t.Errorf("Expected %v got %v", expected, report.Categories)
+	}
+	for category, size := range expected {
+		if report.Categories[category] != size {
+			t.Errorf("%s: expected %d got %d", category, size, report.Categories[category])
+		}
+	}
+}
+
+func Test_HistoryStats(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history", "runs.jsonl")
+	day := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	run := func(days int, profile string, cache int64, code int) *cmn.RunRecord {
+		return &cmn.RunRecord{Time: day.AddDate(0, 0, days), Browser: "Chromium", Profile: profile,
+			Phases: []string{cmn.PhaseCache, cmn.PhaseProfile}, Bytes: cache + 100, CacheBytes: cache,
+			Categories: map[cmn.Category]int64{cmn.CategoryCache: cache, cmn.CategoryCookies: 100}, Code: code}
+	}
+	// written out of order, with a half-written line in between
+	for _, rec := range []*cmn.RunRecord{run(0, "P1", 9000, 0), run(2, "P1", 4000, 0), run(1, "P2", 50, 0)} {
+		if err := cmn.AppendHistory(file, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fd, _ := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	fd.WriteString("{\"time\":\"2024-05-0\n")
+	fd.Close()
+	failed := run(3, "P1", 0, cmn.ExitProfileInUse)
+	failed.Errors = []string{"profile in use"}
+	cmn.AppendHistory(file, failed)
+	cmn.AppendHistory(file, run(6, "P1", 8000, 0))
+
+	records, err := cmn.ReadHistory(file)
+	if err != nil || len(records) != 5 || records[1].Profile != "P2" {
+		t.Fatalf("Expected 5 runs in order got %d %v", len(records), err)
+	}
+
+	stats := cmn.ComputeStats(records, time.Time{}, nil)
+	if stats.Runs != 5 || stats.Failed != 1 || len(stats.Profiles) != 2 || len(stats.FailedRuns) != 1 {
+		t.Errorf("Unexpected totals %+v", stats)
+	}
+	// (4000 + 8000) in 6 days, the first run only tells where we start
+	p1 := stats.Profiles[0]
+	if p1.Profile != "P1" || p1.Runs != 4 || p1.Failed != 1 || p1.CacheGrowth != 2000 {
+		t.Errorf("Unexpected P1 stats %+v", p1)
+	}
+	if stats.Profiles[1].CacheGrowth != 0 {
+		t.Error("Expected no cache growth with a single run")
+	}
+	if top := stats.Offenders[0]; top.Category != cmn.CategoryCache || top.Bytes != 21050 {
+		t.Errorf("Unexpected biggest offender %+v", top)
+	}
+
+	since := cmn.ComputeStats(records, day.AddDate(0, 0, 2), func(r *cmn.RunRecord) bool { return r.Profile == "P1" })
+	if since.Runs != 3 || since.Profiles[0].CacheGrowth != 8000.0/4 {
+		t.Errorf("Unexpected filtered stats %+v", since.Profiles[0])
+	}
+
+	var buf bytes.Buffer
+	cmn.RenderStats(&buf, stats, cmn.SizeModeStd)
+	for _, want := range []string{"5 runs since 2024-05-01", "Chromium/P1", "/day", "Biggest offenders", "E-042  profile in use"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in\n%s", want, buf.String())
+		}
+	}
+
+	if records, err := cmn.ReadHistory(filepath.Join(t.TempDir(), "none.jsonl")); err != nil || len(records) != 0 {
+		t.Errorf("Expected an empty history got %v %v", records, err)
+	}
+}
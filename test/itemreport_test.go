@@ -0,0 +1,123 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lordofscripts/vfs/memfs"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_DirCleanerItems(t *testing.T) {
+	const (
+		DataDir = "/home/pi/.config/chromium/Profile 2"
+	)
+	mfs := memfs.Create()
+	if _, err := createDummyTree(mfs, ChromiumData, DataDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cleaner := cmn.NewDirCleanerVFS(mfs, DataDir, cmn.SizeModeStd, logx)
+	if err := cleaner.CleanUp(context.Background(), ExceptionsData); err != nil {
+		t.Fatal(err)
+	}
+
+	items := cleaner.Items()
+	if len(items) != cleaner.RemovedCount()+cleaner.SkippedCount() {
+		t.Errorf("Expected %d items got %d", cleaner.RemovedCount()+cleaner.SkippedCount(), len(items))
+	}
+	for _, item := range items {
+		switch item.Action {
+		case cmn.ItemKept:
+			if item.Rule != cmn.RuleException+item.Path[len(DataDir)+1:] {
+				t.Errorf("Kept %q with unexpected rule %q", item.Path, item.Rule)
+			}
+		case cmn.ItemDeleted:
+			if !strings.HasPrefix(item.Rule, cmn.RuleWipe) {
+				t.Errorf("Deleted %q with unexpected rule %q", item.Path, item.Rule)
+			}
+		default:
+			t.Errorf("Unexpected %s %q", item.Action, item.Path)
+		}
+	}
+}
+
+func Test_ParseReportFormat(t *testing.T) {
+	cases := []struct {
+		name, file string
+		expected   cmn.ReportFormat
+	}{
+		{"", "report.csv", cmn.ReportFormatCSV},
+		{"", "report.HTML", cmn.ReportFormatHTML},
+		{"", "-", cmn.ReportFormatTable},
+		{"csv", "report.html", cmn.ReportFormatCSV},
+	}
+	for _, c := range cases {
+		if format, err := cmn.ParseReportFormat(c.name, c.file); err != nil || format != c.expected {
+			t.Errorf("(%q,%q) expected %s got %s", c.name, c.file, c.expected, format)
+		}
+	}
+}
+
+func Test_ItemReportCSV(t *testing.T) {
+	report := sampleItemReport()
+	var buf bytes.Buffer
+	if err := cmn.WriteItemReport(&buf, report, cmn.ReportFormatCSV, cmn.SizeModeStd); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected header + 3 rows got %d", len(rows))
+	}
+	if rows[3][0] != "failed" || rows[3][6] != "permission denied" {
+		t.Errorf("Unexpected failed row %v", rows[3])
+	}
+}
+
+func Test_ItemReportHTML(t *testing.T) {
+	report := sampleItemReport()
+	var buf bytes.Buffer
+	if err := cmn.WriteItemReport(&buf, report, cmn.ReportFormatHTML, cmn.SizeModeSI); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+	for _, expected := range []string{"<!DOCTYPE html>", "exception:Bookmarks", "width: 100.0%", "&lt;script&gt;"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("HTML report lacks %q", expected)
+		}
+	}
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func sampleItemReport() *cmn.WipeReport {
+	report := cmn.NewWipeReport("Chromium", "Profile 1", false)
+	report.Record(cmn.PhaseProfile,
+		cmn.NewDeletedItem("/p/History", false, 2000, cmn.RuleWipe+"*"),
+		cmn.NewKeptItem("/p/Bookmarks", false, 100, cmn.RuleException+"Bookmarks"),
+		cmn.NewFailedItem("/p/<script>", false, 10, cmn.RuleWipe+"*", errors.New("permission denied")),
+	)
+	return report
+}
@@ -0,0 +1,131 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ParseLogLevel(t *testing.T) {
+	expected := map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn,
+		"Warning": slog.LevelWarn, "error": slog.LevelError,
+	}
+	for name, level := range expected {
+		if got, err := cmn.ParseLogLevel(name); err != nil || got != level {
+			t.Errorf("%q: expected %s got %s %v", name, level, got, err)
+		}
+	}
+	if _, err := cmn.ParseLogLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func Test_ConditionalLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	opts := cmn.NewLogOptions()
+	opts.Level, opts.Format = slog.LevelWarn, cmn.LogFormatJSON
+	handler, err := cmn.NewLogHandler(&buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent := cmn.NewLoggerAdapter(slog.New(handler), true, "Main")
+	child := parent.InheritAs("DirCleaner")
+	child.Info("below the level")
+	child.Warn("could not recreate cache", cmn.LogKeyPath, "/tmp/cache")
+	parent.Printf("printf %d", 1) // info, filtered too
+	cmn.NewLoggerAdapter(slog.New(handler), false, "Off").Error("disabled")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one record got %q", buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "WARN" || record[cmn.LogKeyComponent] != "DirCleaner" || record[cmn.LogKeyPath] != "/tmp/cache" {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+// A logger of the caller's own, with just the ILogger methods, still
+// goes into the cleaners (which log through what InheritAs() gives).
+func Test_CustomILogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &printLogger{cmn.NewLoggerAdapter(slog.New(slog.NewTextHandler(&buf, nil)), true, "Custom")}
+	cleaner := cmn.NewDirCleanerVFS(nil, "/tmp/none", cmn.SizeModeStd, logger)
+	if cleaner == nil {
+		t.Fatal("Expected a cleaner")
+	}
+	if !strings.Contains(buf.String(), "inherited as") {
+		t.Errorf("Expected InheritAs() called got %q", buf.String())
+	}
+}
+
+func Test_RotatingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "logs", "wiper.log")
+	file, err := cmn.OpenRotatingFile(name, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	// a write never goes past 10 bytes: one+two, three, four+five
+	expected := map[string]string{name: "four\nfive\n", name + ".1": "three\n", name + ".2": "one\ntwo\n"}
+	for path, content := range expected {
+		if data, err := os.ReadFile(path); err != nil || string(data) != content {
+			t.Errorf("%s: expected %q got %q %v", filepath.Base(path), content, data, err)
+		}
+	}
+
+	// the existing content counts, the oldest backup is dropped
+	file, _ = cmn.OpenRotatingFile(name, 10, 2)
+	file.Write([]byte("six six\n"))
+	file.Close()
+	if data, _ := os.ReadFile(name + ".2"); string(data) != "three\n" {
+		t.Errorf("Expected the backups shifted got %q", data)
+	}
+	if _, err := os.Stat(name + ".3"); err == nil {
+		t.Error("Expected no more than 2 backups")
+	}
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   T y p e s
+ *-----------------------------------------------------------------*/
+
+// ILogger as implemented outside the package (before it had levels)
+type printLogger struct {
+	base *cmn.ConditionalLogger
+}
+
+func (l *printLogger) Printf(template string, v ...any) { l.base.Printf(template, v...) }
+func (l *printLogger) Print(v ...any)                   { l.base.Print(v...) }
+func (l *printLogger) IsEnabled() bool                  { return l.base.IsEnabled() }
+func (l *printLogger) InheritAs(prefix string) *cmn.ConditionalLogger {
+	l.base.Printf("inherited as %s", prefix)
+	return l.base.InheritAs(prefix)
+}
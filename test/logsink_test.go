@@ -0,0 +1,129 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// a stand-in for the journald/syslog socket
+func listenDatagrams(t *testing.T) (string, *net.UnixConn) {
+	dir, err := os.MkdirTemp("", "sink") // t.TempDir() may be too long for a socket
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("No unix datagram sockets: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return socket, conn
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) []byte {
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+// decode the journal native protocol
+func journalFields(t *testing.T, msg []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(msg) > 0 {
+		eol := bytes.IndexByte(msg, '\n')
+		if eol < 0 {
+			t.Fatalf("Unterminated field %q", msg)
+		}
+		line := string(msg[:eol])
+		msg = msg[eol+1:]
+		if key, value, found := strings.Cut(line, "="); found {
+			fields[key] = value
+			continue
+		}
+		size := binary.LittleEndian.Uint64(msg)
+		fields[line] = string(msg[8 : 8+size])
+		msg = msg[8+size+1:]
+	}
+	return fields
+}
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_JournalHandler(t *testing.T) {
+	socket, conn := listenDatagrams(t)
+	handler, err := cmn.NewJournalHandler(socket, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handler.Close()
+
+	logx := cmn.NewLoggerAdapter(slog.New(handler), true, "Main").
+		With(cmn.LogKeyBrowser, "Chromium", cmn.LogKeyProfile, "Profile 1").
+		InheritAs("ChromiumCleaner")
+	logx.Debug("below the level")
+	logx.Warn("clear extensions", cmn.LogKeyBytes, 1024, cmn.LogKeyErr, "line one\nline two",
+		slog.Group("walk", "jobs", 4))
+
+	fields := journalFields(t, readDatagram(t, conn))
+	expected := map[string]string{
+		"MESSAGE": "clear extensions", "PRIORITY": "4", "SYSLOG_IDENTIFIER": "wiper",
+		"WIPER_COMPONENT": "ChromiumCleaner", "WIPER_BROWSER": "Chromium", "WIPER_PROFILE": "Profile 1",
+		"WIPER_BYTES": "1024", "WIPER_ERR": "line one\nline two", "WIPER_WALK_JOBS": "4",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%s: expected %q got %q", key, value, fields[key])
+		}
+	}
+}
+
+func Test_SyslogHandler(t *testing.T) {
+	socket, conn := listenDatagrams(t)
+	handler, err := cmn.NewSyslogHandler(socket, slog.LevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handler.Close()
+
+	slog.New(handler).Error("wipe failed", cmn.LogKeyProfile, "Profile 1", "code", 42)
+	line := string(readDatagram(t, conn))
+	// user facility (1) * 8 + err (3)
+	if !strings.HasPrefix(line, "<11>") || !strings.Contains(line, " wiper[") ||
+		!strings.HasSuffix(line, `: wipe failed profile="Profile 1" code=42`) {
+		t.Errorf("Unexpected syslog line %q", line)
+	}
+}
+
+func Test_JournalField(t *testing.T) {
+	for key, field := range map[string]string{"profile": "WIPER_PROFILE", "walk.jobs": "WIPER_WALK_JOBS", "max-size": "WIPER_MAX_SIZE"} {
+		if got := cmn.JournalField(key); got != field {
+			t.Errorf("%q: expected %s got %s", key, field, got)
+		}
+	}
+}
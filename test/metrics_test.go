@@ -0,0 +1,89 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_MetricsFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "wiper.prom")
+	wipe := func(profile string, code int) *cmn.WipeReport {
+		r := cmn.NewWipeReport("Chromium", profile, false)
+		r.Started = time.Unix(1714564800, 500_000_000)
+		r.Duration = 1500 * time.Millisecond
+		r.TotalBytes, r.TotalItems = 3000, 4
+		r.Categories = map[cmn.Category]int64{cmn.CategoryCache: 2000, cmn.CategoryCookies: 1000}
+		if code != 0 {
+			r.Fail(cmn.ErrProfileInUse, code)
+		}
+		return r
+	}
+	scan := &cmn.BrowserScan{Browser: "Chromium", DataExists: true, DataSize: 777}
+
+	for _, profile := range []string{`Work "A"`, `Work "A"`, "Home"} {
+		if err := cmn.UpdateMetricsFile(file, wipe(profile, 0), 0, scan); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmn.UpdateMetricsFile(file, wipe("Home", cmn.ExitProfileInUse), cmn.ExitProfileInUse, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := cmn.ReadMetricsFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := []string{"browser", "Chromium", "profile", `Work "A"`}
+	home := []string{"browser", "Chromium", "profile", "Home"}
+	expected := []struct {
+		name   string
+		labels []string
+		value  float64
+	}{
+		{cmn.MetricRuns, append(work, "result", "success"), 2},
+		{cmn.MetricFreedBytes, append(work, "category", "cache"), 4000},
+		{cmn.MetricRemovedItems, home, 8},
+		{cmn.MetricRuns, append(home, "result", "failure"), 1},
+		{cmn.MetricErrors, append(home, "code", "42"), 1},
+		{cmn.MetricLastExitCode, home, 42},
+		{cmn.MetricLastExitCode, work, 0},
+		{cmn.MetricLastSuccess, home, 1714564800.5},
+		{cmn.MetricLastDuration, work, 1.5},
+		{cmn.MetricBrowserDirSize, []string{"browser", "Chromium", "dir", "data"}, 777},
+	}
+	for _, e := range expected {
+		if got := m.Get(e.name, e.labels...); got != e.value {
+			t.Errorf("%s%v: expected %g got %g", e.name, e.labels, e.value, got)
+		}
+	}
+
+	text, _ := os.ReadFile(file)
+	for _, want := range []string{
+		"# TYPE wiper_runs_total counter\n",
+		`wiper_freed_bytes_total{browser="Chromium",profile="Work \"A\"",category="cookies"} 2000` + "\n",
+		"wiper_last_success_timestamp_seconds{browser=\"Chromium\",profile=\"Home\"} 1714564800.5\n",
+	} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("Expected %q in\n%s", want, text)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
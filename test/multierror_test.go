@@ -0,0 +1,124 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/lordofscripts/vfs"
+	"github.com/lordofscripts/vfs/memfs"
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				T y p e s
+ *-----------------------------------------------------------------*/
+
+// a file system that refuses to remove some of its files
+type stubbornFS struct {
+	vfs.Filesystem
+	refuse map[string]bool
+}
+
+func (s *stubbornFS) Remove(name string) error {
+	if s.refuse[filepath.Base(name)] {
+		return &fs.PathError{Op: "remove", Path: name, Err: syscall.EPERM}
+	}
+	return s.Filesystem.Remove(name)
+}
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_MultiError(t *testing.T) {
+	var m cmn.MultiError
+	if m.Err() != nil {
+		t.Error("Expected no error from an empty MultiError")
+	}
+	if err := m.Final(context.Canceled); err != context.Canceled {
+		t.Errorf("Expected the final error alone got %v", err)
+	}
+
+	denied := &fs.PathError{Op: "unlinkat", Path: "/a/b", Err: syscall.EACCES}
+	m.Add(nil)
+	m.Add(cmn.WrapError(errors.Join(denied, cmn.ErrNotBrowserCache), 41, "Could not remove %q", "/a"))
+	m.Add(cmn.NewItemError("/a/c", "remove", 60, syscall.EPERM))
+	err := m.Final(context.DeadlineExceeded)
+	if m.Len() != 3 {
+		t.Fatalf("Expected 3 errors got %d", m.Len())
+	}
+
+	// every cause is reachable
+	for _, target := range []error{fs.ErrPermission, cmn.ErrNotBrowserCache, context.DeadlineExceeded} {
+		if !errors.Is(err, target) {
+			t.Errorf("Expected errors.Is(%v)", target)
+		}
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/a/b" {
+		t.Errorf("Expected the path error of /a/b got %v", pathErr)
+	}
+	if cmn.ExitCode(err, 1) != cmn.ExitTimeout {
+		t.Errorf("Expected the timeout exit code")
+	}
+
+	// flattened to the items (and only the items)
+	items := cmn.ItemErrors(err, 50)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 item errors got %v", items)
+	}
+	if items[0].Path != "/a/b" || items[0].Op != "unlinkat" || items[0].Errno != int(syscall.EACCES) || items[0].Code != 50 {
+		t.Errorf("Unexpected %+v", items[0])
+	}
+	if items[1].Path != "/a/c" || items[1].Errno != int(syscall.EPERM) || items[1].Code != 60 {
+		t.Errorf("Unexpected %+v", items[1])
+	}
+}
+
+func Test_DirCleanerKeepGoing(t *testing.T) {
+	const root = "/home/pi/.config/chromium/Profile 1"
+	names := []string{"Bookmarks", "Cookies", "History", "Preferences", "Top Sites"}
+
+	for _, keepGoing := range []bool{false, true} {
+		sfs := &stubbornFS{memfs.Create(), map[string]bool{"Cookies": true, "Preferences": true}}
+		vfs.MkdirAll(sfs, root, 0o755)
+		for _, name := range names {
+			vfs.WriteFile(sfs, filepath.Join(root, name), []byte(name), 0o644)
+		}
+
+		cleaner := cmn.NewDirCleanerVFS(sfs, root, cmn.SizeModeStd)
+		cleaner.SetKeepGoing(keepGoing)
+		err := cleaner.CleanUp(context.Background(), []string{"Bookmarks"})
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Fatalf("keep-going %t: expected permission error got %v", keepGoing, err)
+		}
+
+		failed := cmn.ItemErrors(err, 60)
+		if !keepGoing {
+			// stops at the first one
+			if cleaner.RemovedCount() != 0 || len(failed) != 1 {
+				t.Errorf("Expected to stop at Cookies got %d removed %v", cleaner.RemovedCount(), failed)
+			}
+			continue
+		}
+		if cleaner.RemovedCount() != 2 || len(failed) != 2 || len(cleaner.Items()) != len(names) {
+			t.Errorf("Expected 2 removed, 2 failed & %d records got %d %v %d", len(names),
+				cleaner.RemovedCount(), failed, len(cleaner.Items()))
+		}
+		for _, f := range failed {
+			if base := filepath.Base(f.Path); !sfs.refuse[base] || f.Code != 60 {
+				t.Errorf("Unexpected failure %s", f)
+			}
+		}
+	}
+}
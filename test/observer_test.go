@@ -0,0 +1,118 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_DirCleanerEvents(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"Bookmarks", "Cookies", "History"} {
+		os.WriteFile(filepath.Join(root, name), []byte(name), 0o644)
+	}
+	makeWideTree(t, filepath.Join(root, "Local Storage"), 2, 2, 3, 10)
+
+	var mu sync.Mutex
+	counts := make(map[cmn.EventKind]int)
+	var finished []*cmn.Event
+	cleaner := cmn.NewDirCleaner(root, cmn.SizeModeStd, false)
+	cleaner.Subscribe(nil, cmn.ObserverFunc(func(ev *cmn.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[ev.Kind]++
+		if ev.Kind != cmn.EventItemStarted && ev.Kind != cmn.EventProgress {
+			finished = append(finished, ev)
+		}
+	}))
+
+	if err := cleaner.CleanUp(context.Background(), []string{"Bookmarks"}); err != nil {
+		t.Fatal(err)
+	}
+	if counts[cmn.EventItemStarted] != 3 || counts[cmn.EventItemDeleted] != 3 || counts[cmn.EventItemSkipped] != 1 {
+		t.Errorf("Unexpected event counts %v", counts)
+	}
+	// one finished event per item record, in the same order
+	if len(finished) != len(cleaner.Items()) {
+		t.Fatalf("Expected %d events got %d", len(cleaner.Items()), len(finished))
+	}
+	for i, ev := range finished {
+		item := cleaner.Items()[i]
+		if ev.Path != item.Path || ev.Bytes != item.Size || ev.Rule != item.Rule {
+			t.Errorf("Event %s %d %s does not match %s %d %s", ev.Path, ev.Bytes, ev.Rule, item.Path, item.Size, item.Rule)
+		}
+		if ev.Items != i+1 || ev.Total != 4 {
+			t.Errorf("Expected item %d/4 got %d/%d", i+1, ev.Items, ev.Total)
+		}
+	}
+}
+
+func Test_WalkerProgress(t *testing.T) {
+	root := t.TempDir()
+	total := makeWideTree(t, root, 3, 3, 4, 50)
+
+	var mu sync.Mutex
+	var calls int
+	var last cmn.DiskSize
+	walker := cmn.NewWalker(4).OnProgress(time.Nanosecond, func(done cmn.DiskSize, files int) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if done.Apparent > last.Apparent {
+			last = done
+		}
+	})
+	usage, err := walker.Usage(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls == 0 || last.Apparent > usage.Apparent || usage.Apparent != total {
+		t.Errorf("Expected progress up to %d bytes got %d calls up to %d", total, calls, last.Apparent)
+	}
+}
+
+func Test_WipeReportObservers(t *testing.T) {
+	var kinds []cmn.EventKind
+	report := cmn.NewWipeReport("Chromium", "Default", true)
+	report.Subscribe(cmn.ObserverFunc(func(ev *cmn.Event) {
+		if ev.Browser != "Chromium" || ev.Profile != "Default" {
+			t.Errorf("Event %s not tagged with browser & profile", ev.Kind)
+		}
+		if ev.Kind == cmn.EventItemDeleted && ev.Phase != cmn.PhaseCache {
+			t.Errorf("Expected phase %q got %q", cmn.PhaseCache, ev.Phase)
+		}
+		kinds = append(kinds, ev.Kind)
+	}))
+
+	report.Start(cmn.NewRenderer(cmn.OutputJSON, nil, cmn.SizeModeStd))
+	action := report.Begin(cmn.PhaseCache, "/tmp/cache")
+	report.Event(cmn.NewItemEvent(cmn.EventItemDeleted, cmn.NewDeletedItem("/tmp/cache", true, 10, cmn.RuleCache+"*")))
+	report.End(action, nil, 50)
+	report.Finish()
+
+	expected := []cmn.EventKind{cmn.EventRunStarted, cmn.EventPhaseStarted, cmn.EventItemDeleted,
+		cmn.EventPhaseFinished, cmn.EventRunFinished}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected %v got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Errorf("Expected %v got %v", expected, kinds)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ParseOutputFormat(t *testing.T) {
+	cases := map[string]cmn.OutputFormat{
+		"human":  cmn.OutputHuman,
+		"JSON":   cmn.OutputJSON,
+		"ndjson": cmn.OutputNDJSON,
+	}
+	for name, expected := range cases {
+		if format, err := cmn.ParseOutputFormat(name); err != nil || format != expected {
+			t.Errorf("%q expected %s got %s (%v)", name, expected, format, err)
+		}
+	}
+
+	if _, err := cmn.ParseOutputFormat("xml"); err != cmn.ErrUnknownOutputFormat {
+		t.Errorf("Expected ErrUnknownOutputFormat got %v", err)
+	}
+}
+
+func Test_JSONRendererWipe(t *testing.T) {
+	var buf bytes.Buffer
+	out := cmn.NewRenderer(cmn.OutputJSON, &buf, cmn.SizeModeStd)
+
+	out.Printf("this is human chatter %d\n", 1)
+	report := sampleWipeReport(out)
+	if err := out.Wipe(report); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded cmn.WipeReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Not a single JSON document: %v\n%s", err, buf.String())
+	}
+	if decoded.TotalBytes != 1500 || decoded.TotalItems != 3 {
+		t.Errorf("Unexpected totals %d bytes %d items", decoded.TotalBytes, decoded.TotalItems)
+	}
+	if !decoded.DryRun {
+		t.Errorf("Dry-run flag lost")
+	}
+	if len(decoded.Errors) != 1 || decoded.Errors[0].Code != 60 {
+		t.Errorf("Expected one error with code 60, got %v", decoded.Errors)
+	}
+	if decoded.Actions[1].Error == nil {
+		t.Errorf("Failed action has no error entry")
+	}
+}
+
+func Test_NDJSONRendererEvents(t *testing.T) {
+	var buf bytes.Buffer
+	out := cmn.NewRenderer(cmn.OutputNDJSON, &buf, cmn.SizeModeStd)
+
+	report := sampleWipeReport(out)
+	out.Wipe(report)
+
+	kinds := make([]string, 0)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Bad NDJSON line %q: %v", scanner.Text(), err)
+		}
+		kinds = append(kinds, line["event"].(string))
+	}
+
+	expected := "run_started phase_started phase_finished phase_started error phase_finished run_finished wipe_result"
+	if got := strings.Join(kinds, " "); got != expected {
+		t.Errorf("Unexpected event stream\n\tgot: %s\n\texp: %s", got, expected)
+	}
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// a dry-run wipe with a successful cache phase and a failed profile phase
+func sampleWipeReport(out cmn.IRenderer) *cmn.WipeReport {
+	report := cmn.NewWipeReport("Chromium", "Profile 1", true)
+	report.Start(out)
+
+	action := report.Begin(cmn.PhaseCache, "/tmp/cache")
+	action.Bytes, action.Items = 1000, 1
+	report.End(action, nil, 50)
+
+	action = report.Begin(cmn.PhaseProfile, "/tmp/profile")
+	action.Bytes, action.Items = 500, 2
+	report.End(action, errors.New("permission denied"), 60)
+
+	report.Finish()
+	return report
+}
@@ -0,0 +1,115 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_Plan(t *testing.T) {
+	root, file := "/home/me/.config/chromium/Work", filepath.Join(t.TempDir(), "wipe.plan")
+	plan := cmn.NewWipeReport("Chromium", "Work", true)
+	plan.Actions = append(plan.Actions, &cmn.WipeAction{Phase: cmn.PhaseProfile, Path: root})
+	plan.Record(cmn.PhaseProfile,
+		cmn.NewDeletedItem(filepath.Join(root, "Cookies"), false, 10, cmn.RuleWipe+"*"),
+		cmn.NewKeptItem(filepath.Join(root, "Bookmarks"), false, 10, cmn.RuleException+"Bookmarks"),
+		cmn.NewDeletedItem(filepath.Join(root, "Sessions"), true, 10, cmn.RuleWipe+"*"))
+	if err := cmn.SavePlan(file, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cmn.LoadPlan(file, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := cmn.PlannedNames(loaded); !slices.Equal(names, []string{"Cookies", "Sessions"}) {
+		t.Errorf("Unexpected planned items %v", names)
+	}
+	if !cmn.PlanHas(loaded, cmn.PhaseProfile) || cmn.PlanHas(loaded, cmn.PhaseCache) {
+		t.Error("Expected a profile-only plan")
+	}
+
+	// stale plans & real wipes are no plans
+	plan.Started = time.Now().Add(-2 * time.Hour)
+	cmn.SavePlan(file, plan)
+	if _, err := cmn.LoadPlan(file, time.Hour); !errors.Is(err, cmn.ErrBadPlan) {
+		t.Errorf("Expected a stale plan error got %v", err)
+	}
+	if err := cmn.SavePlan(file, cmn.NewWipeReport("Chromium", "Work", false)); !errors.Is(err, cmn.ErrBadPlan) {
+		t.Errorf("Expected a real wipe refused got %v", err)
+	}
+}
+
+func Test_PlanSummary(t *testing.T) {
+	root := "/home/me/.config/chromium/Work"
+	plan := cmn.NewWipeReport("Chromium", "Work", true)
+	plan.Actions = append(plan.Actions, &cmn.WipeAction{Phase: cmn.PhaseProfile, Path: root})
+	plan.Record(cmn.PhaseProfile,
+		cmn.NewDeletedItem(filepath.Join(root, "Cookies"), false, 1000, cmn.RuleWipe+"*"),
+		cmn.NewKeptItem(filepath.Join(root, "Bookmarks"), false, 10, cmn.RuleException+"Bookmarks"),
+		cmn.NewDeletedItem(filepath.Join(root, "History"), false, 500, cmn.RuleWipe+"*"))
+	plan.Categories = map[cmn.Category]int64{cmn.CategoryHistory: 500, cmn.CategoryCookies: 1000}
+
+	var sb strings.Builder
+	cmn.WritePlanSummary(&sb, plan, "", cmn.SizeModeStd)
+	for _, want := range []string{`"Work"`, "2 items", root, "cookies 1,000, history 500", "(1 kept)", "cannot be restored"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected %q in the summary:\n%s", want, sb.String())
+		}
+	}
+	sb.Reset()
+	cmn.WritePlanSummary(&sb, plan, "/backup", cmn.SizeModeStd)
+	if !strings.Contains(sb.String(), "moved to /backup") {
+		t.Errorf("Expected the backup directory in the summary:\n%s", sb.String())
+	}
+}
+
+func Test_RestoreBackup(t *testing.T) {
+	dir, profile := t.TempDir(), t.TempDir()
+	older := cmn.BackupPath(dir, "Chromium", "Work", time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC))
+	latest := cmn.BackupPath(dir, "Chromium", "Work", time.Date(2024, time.May, 2, 9, 0, 0, 0, time.UTC))
+	for _, backup := range []string{older, latest} {
+		os.MkdirAll(filepath.Join(backup, "Sessions"), 0o755)
+		os.WriteFile(filepath.Join(backup, "Cookies"), []byte("backup"), 0o644)
+	}
+	os.WriteFile(filepath.Join(profile, "Cookies"), []byte("new"), 0o644)
+
+	backups, err := cmn.ListBackups(dir, "Chromium", "Work")
+	if err != nil || !slices.Equal(backups, []string{older, latest}) {
+		t.Fatalf("Unexpected backups %v %v", backups, err)
+	}
+	if _, err := cmn.ListBackups(dir, "Firefox", "Work"); !errors.Is(err, cmn.ErrNoBackup) {
+		t.Errorf("Expected no backup got %v", err)
+	}
+
+	// what the profile has again stays unless overwritten
+	restored, err := cmn.RestoreBackup(latest, profile, false)
+	if err == nil || !slices.Equal(restored, []string{"Sessions"}) {
+		t.Errorf("Expected only Sessions restored got %v %v", restored, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(profile, "Cookies")); string(data) != "new" {
+		t.Error("Expected the new Cookies kept")
+	}
+	if restored, err = cmn.RestoreBackup(latest, profile, true); err != nil || len(restored) != 1 {
+		t.Errorf("Expected Cookies overwritten got %v %v", restored, err)
+	}
+	if _, err := os.Stat(latest); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected the emptied backup removed")
+	}
+}
@@ -0,0 +1,151 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_Policy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.toml")
+	os.WriteFile(file, []byte(`
+[[rule]]
+users = ["kiosk*"]
+force = ["cookies", "history"]
+
+[[rule]]
+browsers = ["chromium"]
+profiles = ["Work*"]
+forbid = ["bookmarks", "history"]
+backup = true
+backup_dir = "/var/backups/wiper/{user}"
+max_interval = "24h"
+`), 0o644)
+	policy, err := cmn.LoadPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := policy.For("alice", "Firefox", "default"); p != nil {
+		t.Errorf("Expected no rule for alice's Firefox got %+v", p)
+	}
+	kiosk := policy.For("kiosk7", "Chromium", "Work 2")
+	if !slices.Equal(kiosk.Rules, []int{1, 2}) || !kiosk.Forces(cmn.CategoryCookies) ||
+		kiosk.Forces(cmn.CategoryHistory) || !kiosk.Forbids(cmn.CategoryHistory) {
+		t.Errorf("Unexpected kiosk policy %+v", kiosk)
+	}
+	if kiosk.BackupDir != "/var/backups/wiper/kiosk7" || kiosk.MaxInterval != 24*time.Hour {
+		t.Errorf("Unexpected backup & schedule %q %s", kiosk.BackupDir, kiosk.MaxInterval)
+	}
+	if overlaps := policy.Overlaps(); len(overlaps) != 1 || !strings.Contains(overlaps[0], "history") {
+		t.Errorf("Expected the history overlap got %v", overlaps)
+	}
+
+	// forced cookies go, forbidden bookmarks & history stay
+	names := func(categories ...cmn.Category) []string {
+		return cmn.NamesOf(chromium.ProfileCategories, categories...)
+	}
+	kept := kiosk.Exceptions(append([]string{"Cookies"}, chromium.ProfileExceptions...), names)
+	if slices.Contains(kept, "Cookies") || !slices.Contains(kept, "Bookmarks") || !slices.Contains(kept, "History") {
+		t.Errorf("Unexpected exceptions %v", kept)
+	}
+	if doCache, doProfile := kiosk.Phases(true, false); !doCache || !doProfile {
+		t.Error("Expected the forced cookies to need the profile phase")
+	}
+
+	// the settings that go against it, defaults never do
+	cfg := cmn.NewConfig("Chromium", "Work 2")
+	if conflicts := kiosk.Conflicts(cfg); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts with the defaults got %v", conflicts)
+	}
+	cfg.Set("preset", "quick", "flag -preset")
+	cfg.Set("keep", "cookies", "flag -keep")
+	conflicts := kiosk.Conflicts(cfg)
+	if len(conflicts) != 2 || conflicts[0].Setting != "keep" || conflicts[1].Setting != "profile" {
+		t.Errorf("Unexpected conflicts %v", conflicts)
+	}
+	if err := cmn.PolicyError(conflicts); !errors.Is(err, cmn.ErrPolicyViolation) ||
+		!strings.Contains(err.Error(), `keep = "cookies" (flag -keep): the policy always wipes cookies`) {
+		t.Errorf("Unexpected policy error %v", err)
+	}
+
+	// schedules must run at least daily
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	for spec, ok := range map[string]bool{"daily": true, "Mon..Fri 18:00": false, "*-*-* 04,16:00": true, "weekly": false} {
+		cron, err := cmn.CalendarToCron(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conflict := kiosk.CheckSchedule("unit wiper", cron, from); (conflict == nil) != ok {
+			t.Errorf("%s: expected ok=%t got %v", spec, ok, conflict)
+		}
+	}
+
+	var buf bytes.Buffer
+	cmn.WriteProfilePolicy(&buf, kiosk)
+	if !strings.Contains(buf.String(), "always wiped : cookies") {
+		t.Errorf("Unexpected policy summary\n%s", buf.String())
+	}
+}
+
+func Test_PolicyErrors(t *testing.T) {
+	dir := t.TempDir()
+	for i, text := range []string{
+		"[[rule]]\nforce = [\"passwords\"]\n",
+		"[[rule]]\nforce = [\"cookies\"]\nforbid = [\"cookies\"]\n",
+		"[[rule]]\nusers = [\"[kiosk\"]\n",
+		"[[rule]]\nbackup_dir = \"backups\"\n",
+		"[[rule]]\nmax_interval = \"10s\"\n",
+	} {
+		file := filepath.Join(dir, "policy.toml")
+		os.WriteFile(file, []byte(text), 0o644)
+		if _, err := cmn.LoadPolicy(file); !errors.Is(err, cmn.ErrBadConfig) {
+			t.Errorf("#%d: expected a policy error got %v", i, err)
+		}
+	}
+	if policy, err := cmn.LoadPolicy(filepath.Join(dir, "none.toml")); policy != nil || err != nil {
+		t.Errorf("Expected no policy got %v %v", policy, err)
+	}
+	var none *cmn.ProfilePolicy
+	if dir, err := none.BackupTarget(""); dir != "" || err != nil || none.MustShred() {
+		t.Error("Expected no policy to demand nothing")
+	}
+}
+
+func Test_ShredTree(t *testing.T) {
+	root := t.TempDir()
+	secret, linked := filepath.Join(root, "sub", "secret"), filepath.Join(root, "linked")
+	os.Mkdir(filepath.Dir(secret), 0o755)
+	os.WriteFile(secret, bytes.Repeat([]byte("password"), 20000), 0o600)
+	os.WriteFile(linked, []byte("shared"), 0o600)
+	os.Link(linked, filepath.Join(t.TempDir(), "other"))
+
+	if err := cmn.ShredTree(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(secret); len(data) != 160000 || bytes.Contains(data, []byte("password")) {
+		t.Error("Expected the secret overwritten with zeros")
+	}
+	if data, _ := os.ReadFile(linked); string(data) != "shared" {
+		t.Error("Expected a hard-linked file left alone")
+	}
+}
@@ -0,0 +1,159 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ScheduleUnits(t *testing.T) {
+	sched := cmn.NewUnitSchedule("/usr/local/bin/wiper", "Chromium", "Default", "Profile 1")
+	sched.OnCalendar = "Mon..Fri 18:00"
+	units, err := sched.Units()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 2 || units[0].Name != "wiper.service" || units[1].Name != "wiper.timer" {
+		t.Fatalf("Expected wiper.service & wiper.timer got %v", units)
+	}
+	for _, line := range []string{
+		"ExecStart=/usr/local/bin/wiper -b Chromium -n Default -k -yes -progress=false -log-sink journal",
+		`ExecStart=/usr/local/bin/wiper -b Chromium -n "Profile 1" -k -yes -progress=false -log-sink journal`,
+		"X-Wiper-Preset=standard",
+	} {
+		if !strings.Contains(units[0].Content, line+"\n") {
+			t.Errorf("Expected %q in the service:\n%s", line, units[0].Content)
+		}
+	}
+	for _, line := range []string{"OnCalendar=Mon..Fri 18:00", "Persistent=true", "Unit=wiper.service", "WantedBy=timers.target"} {
+		if !strings.Contains(units[1].Content, line+"\n") {
+			t.Errorf("Expected %q in the timer:\n%s", line, units[1].Content)
+		}
+	}
+
+	// at logout: a service only, wiping when it stops
+	sched.AtLogout, sched.OnCalendar, sched.Preset = true, "", cmn.LookupPreset("quick")
+	units, err = sched.Units()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || !strings.Contains(units[0].Content, "\nExecStop=/usr/local/bin/wiper -b Chromium -n Default -c -k") ||
+		!strings.Contains(units[0].Content, "\nWantedBy=default.target\n") {
+		t.Errorf("Unexpected logout units %v", units)
+	}
+}
+
+func Test_ScheduleValidate(t *testing.T) {
+	for name, change := range map[string]func(*cmn.UnitSchedule){
+		"relative binary": func(s *cmn.UnitSchedule) { s.Binary = "wiper" },
+		"unit name":       func(s *cmn.UnitSchedule) { s.Name = "../wiper" },
+		"no profile":      func(s *cmn.UnitSchedule) { s.Profiles = nil },
+		"newline":         func(s *cmn.UnitSchedule) { s.Profiles = []string{"a\nExecStart=/bin/sh"} },
+		"calendar":        func(s *cmn.UnitSchedule) { s.OnCalendar = "whenever" },
+		"both":            func(s *cmn.UnitSchedule) { s.AtLogout = true },
+		"no preset":       func(s *cmn.UnitSchedule) { s.Preset = cmn.LookupPreset("nuke") },
+	} {
+		sched := cmn.NewUnitSchedule("/usr/bin/wiper", "Firefox", "default-release")
+		change(sched)
+		if _, err := sched.Units(); !errors.Is(err, cmn.ErrBadSchedule) {
+			t.Errorf("%s: expected ErrBadSchedule got %v", name, err)
+		}
+	}
+
+	quoted := map[string]string{"plain": "plain", "Profile 1": `"Profile 1"`, `a"b`: `"a\"b"`, "100%": "100%%", "$HOME": "$$HOME"}
+	for arg, expected := range quoted {
+		if got := cmn.SystemdQuote(arg); got != expected {
+			t.Errorf("%q: expected %s got %s", arg, expected, got)
+		}
+	}
+}
+
+func Test_ScheduleInstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "systemd", "user")
+	units, _ := cmn.NewUnitSchedule("/usr/bin/wiper", "Chromium", "Default").Units()
+	if err := cmn.InstallUnits(dir, units); err != nil {
+		t.Fatal(err)
+	}
+	// reinstalling replaces ours...
+	if err := cmn.InstallUnits(dir, units); err != nil {
+		t.Fatal(err)
+	}
+	// ...but never a hand-written unit
+	os.WriteFile(filepath.Join(dir, "mine.service"), []byte("[Service]\nExecStart=/bin/true\n"), 0o644)
+	if err := cmn.InstallUnits(dir, []*cmn.UnitFile{{Name: "mine.service", Content: units[0].Content}}); err == nil {
+		t.Error("Expected a hand-written unit to be left alone")
+	}
+
+	scheds, err := cmn.ListUnits(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheds) != 1 || scheds[0].Name != "wiper" || scheds[0].Schedule != "daily" ||
+		scheds[0].Browser != "Chromium" || len(scheds[0].Commands) != 1 || len(scheds[0].Files) != 2 {
+		t.Fatalf("Unexpected schedules %+v", scheds)
+	}
+
+	if _, err := cmn.RemoveUnits(dir, "mine"); err == nil {
+		t.Error("Expected a hand-written unit not to be removed")
+	}
+	removed, err := cmn.RemoveUnits(dir, "wiper")
+	if err != nil || len(removed) != 2 {
+		t.Errorf("Expected 2 files removed got %v %v", removed, err)
+	}
+	if _, err := cmn.RemoveUnits(dir, "wiper"); !errors.Is(err, cmn.ErrBadSchedule) {
+		t.Errorf("Expected no such schedule got %v", err)
+	}
+}
+
+// The services from before a wipe had to say -yes are brought up to date
+func Test_ScheduleUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	sched := cmn.NewUnitSchedule("/usr/bin/wiper", "Chromium", "Default", "Profile 1")
+	units, err := sched.Units()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := units[0].Content
+	units[0].Content = strings.ReplaceAll(current, " -yes", "")
+	if err := cmn.InstallUnits(dir, units); err != nil {
+		t.Fatal(err)
+	}
+	if scheds, _ := cmn.ListUnits(dir); len(scheds) != 1 || !scheds[0].Outdated {
+		t.Fatalf("Expected an outdated schedule got %+v", scheds)
+	}
+
+	upgraded, err := cmn.UpgradeUnits(dir)
+	if err != nil || len(upgraded) != 1 || upgraded[0] != "wiper.service" {
+		t.Fatalf("Expected wiper.service upgraded got %v %v", upgraded, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "wiper.service"))
+	scheds, _ := cmn.ListUnits(dir)
+	if len(scheds) != 1 || scheds[0].Outdated || strings.Count(string(data), " -yes") != 2 {
+		t.Errorf("Unexpected upgraded service %+v\n%s", scheds, data)
+	}
+	if upgraded, _ := cmn.UpgradeUnits(dir); len(upgraded) != 0 {
+		t.Errorf("Expected nothing more to upgrade got %v", upgraded)
+	}
+
+	// the up to date ones are left alone
+	other := t.TempDir()
+	units[0].Content = current
+	cmn.InstallUnits(other, units)
+	if upgraded, _ := cmn.UpgradeUnits(other); len(upgraded) != 0 {
+		t.Errorf("Expected nothing to upgrade got %v", upgraded)
+	}
+}
@@ -0,0 +1,185 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+	"github.com/lordofscripts/wipechromium/browsers/firefox"
+	"github.com/lordofscripts/wipechromium/testkit"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+// go test ./test -run Golden -update rewrites the golden files
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_TestkitTrees(t *testing.T) {
+	for _, browser := range browsers.SupportedBrowsers {
+		tree, err := testkit.Generate(testkit.InMemory("/home/alice"), browser, testkit.DefaultSpec())
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := testkit.Generate(testkit.InMemory("/home/alice"), browser, testkit.DefaultSpec())
+		if tree.Files != again.Files || tree.Bytes != again.Bytes || len(tree.Profiles) != 2 {
+			t.Errorf("%s: Expected the same spec to give the same tree", browser)
+		}
+
+		cleaner := testkitCleaner(t, tree, "", true)
+		if !cleaner.IdentifyAppDataRoot() {
+			t.Errorf("%s: Expected the generated root identified", browser)
+		}
+		names, err := cleaner.FindProfileNames()
+		if err != nil || len(names) != len(tree.Profiles) {
+			t.Errorf("%s: Unexpected profiles %v %v", browser, names, err)
+		}
+	}
+
+	// real SQLite databases, one page per table
+	tree, _ := testkit.Chromium(testkit.InMemory("/home/alice"), testkit.DefaultSpec())
+	db, err := tree.Env.ReadFile(tree.Abs(tree.Profiles[0], "{data}/History"))
+	if err != nil || !bytes.HasPrefix(db, []byte("SQLite format 3\x00")) || len(db) != 4*4096 {
+		t.Errorf("Unexpected History database of %d bytes %v", len(db), err)
+	}
+}
+
+// The plan & the outcome of wiping a generated profile, against the
+// golden files (of the Linux layout)
+func Test_TestkitGolden(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the golden files are of the Linux layout")
+	}
+	cases := []struct {
+		browser     browsers.Browser
+		profile     string
+		kept, wiped string
+		cached      string
+	}{
+		{browsers.ChromiumBrowser, "Profile 1", "{data}/Bookmarks", "{data}/Cookies", "{cache}/Cache"},
+		{browsers.FirefoxBrowser, "work", "{data}/places.sqlite", "{data}/cookies.sqlite", "{cache}/cache2/index"},
+	}
+	for _, tc := range cases {
+		tree, err := testkit.Generate(testkit.InMemory("/home/alice"), tc.browser, testkit.DefaultSpec())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := tree.Profile(tc.profile)
+		for _, dry := range []bool{true, false} {
+			cleaner := testkitCleaner(t, tree, tc.profile, dry)
+			if err, code := cleaner.ClearProfile(context.Background(), true, true); err != nil {
+				t.Fatalf("%s: Unexpected failure %v (%d)", tc.browser, err, code)
+			}
+			name := fmt.Sprintf("%s-%s.golden", strings.ToLower(tc.browser.String()), map[bool]string{true: "plan", false: "wipe"}[dry])
+			checkGolden(t, name, testkit.Golden(tree, cleaner.Report()))
+		}
+		if !tree.Exists(p, tc.kept) || tree.Exists(p, tc.wiped) || tree.Exists(p, tc.cached) {
+			t.Errorf("%s: Expected %s kept, %s & the cache wiped", tc.browser, tc.kept, tc.wiped)
+		}
+		if other := tree.Profiles[0]; !tree.Exists(other, tc.wiped) {
+			t.Errorf("%s: Expected the other profile untouched", tc.browser)
+		}
+	}
+}
+
+func Test_TestkitLocked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no symbolic link locks on Windows")
+	}
+	env, err := testkit.OnDisk(filepath.Join(t.TempDir(), "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := testkit.DefaultSpec()
+	spec.Locked = true
+	tree, err := testkit.Chromium(env, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleaner := testkitCleaner(t, tree, "Default", false)
+	if err, code := cleaner.ClearProfile(context.Background(), true, true); code != cmn.ExitProfileInUse {
+		t.Errorf("Expected the running browser to stop it, not %v (%d)", err, code)
+	}
+	if !tree.Exists(tree.Profiles[0], "{data}/Cookies") {
+		t.Error("Expected nothing removed")
+	}
+}
+
+/* ----------------------------------------------------------------
+ *					H e l p e r   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func testkitCleaner(t *testing.T, tree *testkit.Tree, profile string, dry bool) browsers.IBrowsers {
+	t.Helper()
+	var cleaner browsers.IBrowsers
+	switch tree.Browser {
+	case browsers.ChromiumBrowser:
+		if c, err := chromium.NewChromiumCleaner(tree.Env, profile, cmn.SizeModeStd, dry); err == nil {
+			cleaner = c
+		}
+	case browsers.FirefoxBrowser:
+		if c, err := firefox.NewFirefoxCleaner(tree.Env, profile, len(profile) == 0, cmn.SizeModeStd, dry); err == nil {
+			cleaner = c
+		}
+	}
+	if cleaner == nil {
+		t.Fatalf("No %s cleaner for %q", tree.Browser, profile)
+	}
+	cleaner.SetRenderer(cmn.NewRenderer(cmn.OutputHuman, io.Discard, cmn.SizeModeStd))
+	return cleaner
+}
+
+// compare with testdata/golden/name, or rewrite it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *updateGolden {
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (go test ./test -run Golden -update creates it)", err)
+	}
+	if got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := range max(len(gotLines), len(wantLines)) {
+			if i >= len(gotLines) || i >= len(wantLines) || gotLines[i] != wantLines[i] {
+				t.Errorf("%s differs at line %d:\n got: %s\nwant: %s", name, i+1,
+					at(gotLines, i), at(wantLines, i))
+				return
+			}
+		}
+	}
+}
+
+func at(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return "(end)"
+}
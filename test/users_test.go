@@ -0,0 +1,68 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_ParsePasswd(t *testing.T) {
+	const passwd = `root:x:0:0:root:/root:/bin/bash
+# a comment
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+alice:x:1000:1000:Alice,,,:/home/alice:/bin/bash
+backup:x:1001:1001::/var/backups:/bin/false
+kiosk1:x:1002:100::/home/kiosk1:/usr/bin/zsh
+relative:x:1003:1003::home/relative:/bin/sh
+nobody:x:65534:65534:nobody:/nonexistent:/bin/sh
+`
+	users, err := cmn.ParsePasswd(strings.NewReader(passwd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "alice" || users[1].Name != "kiosk1" {
+		t.Fatalf("Unexpected login users %v", users)
+	}
+	if u := users[1]; u.Uid != 1002 || u.Gid != 100 || u.Home != "/home/kiosk1" {
+		t.Errorf("Unexpected user %+v", u)
+	}
+	if _, err := cmn.ParsePasswd(strings.NewReader("alice:x:1000:1000:/home/alice:/bin/bash\n")); err == nil {
+		t.Error("Expected an error for a line of 6 fields")
+	}
+}
+
+func Test_UsersReportExitCode(t *testing.T) {
+	failed := func() *cmn.UserRun {
+		run := &cmn.UserRun{LoginUser: &cmn.LoginUser{Name: "bob"}}
+		run.Fail(cmn.ExitProfileInUse, errors.New("in use"))
+		run.Fail(cmn.ExitPolicy, errors.New("second failure"))
+		return run
+	}
+	ok := &cmn.UserRun{LoginUser: &cmn.LoginUser{Name: "alice"}}
+
+	for _, tc := range []struct {
+		runs []*cmn.UserRun
+		code int
+	}{
+		{[]*cmn.UserRun{ok}, cmn.ExitOK},
+		{[]*cmn.UserRun{ok, failed()}, cmn.ExitPartial},
+		{[]*cmn.UserRun{failed(), failed()}, cmn.ExitProfileInUse},
+		{nil, cmn.ExitOK},
+	} {
+		if code := (&cmn.UsersReport{Users: tc.runs}).ExitCode(); code != tc.code {
+			t.Errorf("Expected exit code %d instead of %d", tc.code, code)
+		}
+	}
+}
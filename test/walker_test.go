@@ -0,0 +1,195 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ *						U n i t   T e s t
+ *-----------------------------------------------------------------*/
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmn "github.com/lordofscripts/wipechromium"
+)
+
+/* ----------------------------------------------------------------
+ *				U n i t  T e s t   F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+func Test_WalkerUsage(t *testing.T) {
+	root := t.TempDir()
+	total := makeWideTree(t, root, 3, 4, 5, 100)
+
+	reference, err := cmn.NewDiskSizer().Directory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reference.Apparent != total {
+		t.Fatalf("Expected %d bytes got %d", total, reference.Apparent)
+	}
+
+	// same totals regardless of the number of goroutines & their timing
+	for _, jobs := range []int{1, 2, 8, 0} {
+		for round := 0; round < 5; round++ {
+			usage, err := cmn.NewWalker(jobs).Usage(context.Background(), root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usage != reference {
+				t.Errorf("jobs=%d: expected %+v got %+v", jobs, reference, usage)
+			}
+		}
+	}
+}
+
+func Test_WalkerRemoveAll(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "Service Worker")
+	makeWideTree(t, root, 3, 3, 4, 50)
+	expect, _ := cmn.NewDiskSizer().Directory(root)
+
+	usage, err := cmn.NewWalker(4).RemoveAll(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage != expect {
+		t.Errorf("Expected %+v got %+v", expect, usage)
+	}
+	if _, err := os.Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone: %v", root, err)
+	}
+	// like os.RemoveAll() it is not an error if it doesn't exist
+	if usage, err := cmn.NewWalker(4).RemoveAll(context.Background(), root); err != nil || usage.Apparent != 0 {
+		t.Errorf("Expected nothing removed got %+v %v", usage, err)
+	}
+}
+
+func Test_WalkerCancelled(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "Cache")
+	makeWideTree(t, root, 2, 2, 3, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	usage, err := cmn.NewWalker(2).RemoveAll(ctx, root)
+	if !errors.Is(err, context.Canceled) || usage.Apparent != 0 {
+		t.Errorf("Expected nothing removed & context.Canceled got %+v %v", usage, err)
+	}
+	if _, err := os.Lstat(root); err != nil {
+		t.Errorf("Expected %s to be left alone: %v", root, err)
+	}
+}
+
+func Test_DirCleanerInterrupted(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"Bookmarks", "Cookies", "History", "Preferences"} {
+		os.WriteFile(filepath.Join(root, name), []byte(name), 0o644)
+	}
+
+	// stops before the first item, everything is reported as skipped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cleaner := cmn.NewDirCleaner(root, cmn.SizeModeStd, false)
+	err := cleaner.CleanUp(ctx, []string{"Bookmarks"})
+	if cmn.ExitCode(err, 51) != cmn.ExitInterrupted {
+		t.Errorf("Expected interrupted exit code for %v", err)
+	}
+	if cleaner.RemovedCount() != 0 || len(cleaner.Items()) != 4 {
+		t.Errorf("Expected 0 removed & 4 records got %d & %d", cleaner.RemovedCount(), len(cleaner.Items()))
+	}
+	for _, item := range cleaner.Items() {
+		if item.Action != cmn.ItemSkipped || item.Rule != cmn.RuleInterrupted {
+			t.Errorf("Expected %s skipped got %s %s", item.Path, item.Action, item.Rule)
+		}
+		if _, err := os.Lstat(item.Path); err != nil {
+			t.Errorf("Skipped %s is gone", item.Path)
+		}
+	}
+
+	// a timeout has its own exit code
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if err := cleaner.CleanUp(ctx, nil); cmn.ExitCode(err, 51) != cmn.ExitTimeout {
+		t.Errorf("Expected timeout exit code for %v", err)
+	}
+}
+
+/* ----------------------------------------------------------------
+ *				B e n c h m a r k s
+ *-----------------------------------------------------------------*/
+
+// sequential (old) vs. concurrent sizing
+func Benchmark_DirectorySize(b *testing.B) {
+	root := b.TempDir()
+	makeWideTree(b, root, 3, 6, 20, 512)
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cmn.NewDiskSizer().Directory(root)
+		}
+	})
+	for _, jobs := range []int{2, 4, 0} {
+		b.Run(fmt.Sprintf("walker-%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cmn.NewWalker(jobs).Usage(context.Background(), root)
+			}
+		})
+	}
+}
+
+// size first then os.RemoveAll() (old) vs. sizing & deleting in one pass
+func Benchmark_SizeAndRemove(b *testing.B) {
+	run := func(b *testing.B, remove func(string)) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			root := filepath.Join(b.TempDir(), "IndexedDB")
+			makeWideTree(b, root, 2, 6, 30, 256)
+			b.StartTimer()
+			remove(root)
+		}
+	}
+
+	b.Run("two-pass", func(b *testing.B) {
+		run(b, func(root string) {
+			cmn.NewDiskSizer().Directory(root)
+			os.RemoveAll(root)
+		})
+	})
+	for _, jobs := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("walker-%d", jobs), func(b *testing.B) {
+			run(b, func(root string) {
+				cmn.NewWalker(jobs).RemoveAll(context.Background(), root)
+			})
+		})
+	}
+}
+
+/* ----------------------------------------------------------------
+ *				H e l p e r s
+ *-----------------------------------------------------------------*/
+
+// A tree depth levels deep with fanout subdirectories and files per
+// directory, each file size bytes long. Returns the total apparent size.
+func makeWideTree(tb testing.TB, root string, depth, fanout, files, size int) int64 {
+	tb.Helper()
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	var total int64
+	data := make([]byte, size)
+	for i := 0; i < files; i++ {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("%06d.ldb", i)), data, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		total += int64(size)
+	}
+	if depth > 1 {
+		for i := 0; i < fanout; i++ {
+			total += makeWideTree(tb, filepath.Join(root, fmt.Sprintf("d%02d", i)), depth-1, fanout, files, size)
+		}
+	}
+	return total
+}
@@ -0,0 +1,254 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * A synthetic Chromium: Local State, profiles, caches & extensions
+ *-----------------------------------------------------------------*/
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+	"github.com/lordofscripts/wipechromium/browsers/chromium"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	chromiumVersion = "126.0.6478.126"
+	// microseconds since 1601 (WebKit time), mid 2024
+	webkitEpoch int64 = 13360000000000000
+	// the letters of a Chromium extension id
+	extensionLetters = "abcdefghijklmnop"
+)
+
+// the usual profiles of a Chromium
+var ChromiumProfiles = []string{"Default", "Profile 1"}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Generate a Chromium in the home directory of env where the chromium
+// package looks for it: the data root with its Local State & lock, each
+// profile with its SQLite databases, LevelDB stores, sessions &
+// extensions, and each profile's cache.
+func Chromium(env *cmn.Environment, spec *Spec) (*Tree, error) {
+	b := newBuilder(env, browsers.ChromiumBrowser, spec)
+	profiles := spec.Profiles
+	if len(profiles) == 0 {
+		profiles = ChromiumProfiles
+	}
+	dataRoot, cacheRoot := chromium.GetChromiumDirs(env)
+	b.tree.Root = dataRoot
+
+	// (a) the data root, Default & the other dirs IdentifyAppDataRoot() wants
+	b.chromiumLocalState(profiles)
+	b.text(chromiumVersion, dataRoot, "Last Version")
+	b.text("", dataRoot, "First Run")
+	b.junk(2048, dataRoot, "Safe Browsing", "UrlMalware.store")
+	b.junk(1024, dataRoot, "Avatars", "avatar_generic.png")
+	b.text(`{"profile":{"exit_type":"Normal"}}`, dataRoot, "System Profile", "Preferences")
+	b.junk(512, dataRoot, "Crash Reports", "settings.dat")
+	b.junk(4096, dataRoot, "ShaderCache", "data_0")
+	b.junk(768, dataRoot, "CertificateRevocation", "9015", "crl-set")
+	b.dir(dataRoot, "Default")
+	if spec.Locked {
+		host, _ := os.Hostname()
+		b.symlink(fmt.Sprintf("%s-%d", host, os.Getpid()), dataRoot, "SingletonLock")
+		b.symlink("4862329431395874452", dataRoot, "SingletonCookie")
+	}
+
+	// (b) the profiles & their caches
+	for i, name := range profiles {
+		p := &Profile{name, filepath.Join(dataRoot, name), filepath.Join(cacheRoot, name)}
+		b.tree.Profiles = append(b.tree.Profiles, p)
+		b.chromiumProfile(p, i, spec)
+		b.chromiumCache(p, spec)
+	}
+	return b.tree, b.err
+}
+
+// profile.info_cache & co. of the profiles
+func (b *builder) chromiumLocalState(profiles []string) {
+	cache := make(map[string]any, len(profiles))
+	for i, name := range profiles {
+		cache[name] = map[string]any{
+			"name":                  fmt.Sprintf("Person %d", i+1),
+			"avatar_icon":           fmt.Sprintf("chrome://theme/IDR_PROFILE_AVATAR_%d", 26+i),
+			"is_using_default_name": true,
+			"active_time":           1718000000.0 + float64(i),
+		}
+	}
+	state := map[string]any{
+		"browser": map[string]any{"enabled_labs_experiments": []string{}},
+		"profile": map[string]any{
+			"info_cache":     cache,
+			"last_used":      profiles[0],
+			"profiles_order": profiles,
+		},
+		"user_experience_metrics": map[string]any{"stability": map[string]any{"exited_cleanly": true}},
+	}
+	data, _ := json.MarshalIndent(state, "", "   ")
+	b.file(data, b.tree.Root, "Local State")
+}
+
+// what IdentifyProfileData() wants, what the cleaner keeps & what it wipes
+func (b *builder) chromiumProfile(p *Profile, index int, spec *Spec) {
+	dir := p.Data
+
+	// (a) kept: preferences, bookmarks, extensions & web apps
+	prefs := map[string]any{
+		"profile":    map[string]any{"name": fmt.Sprintf("Person %d", index+1), "exit_type": "Normal"},
+		"extensions": map[string]any{"last_chrome_version": chromiumVersion},
+	}
+	data, _ := json.MarshalIndent(prefs, "", "   ")
+	b.file(data, dir, "Preferences")
+	b.text(`{"protection":{"macs":{}}}`, dir, "Secure Preferences")
+	bookmarks := b.chromiumBookmarks(spec.Rows)
+	b.file(bookmarks, dir, "Bookmarks")
+	b.file(bookmarks, dir, "Bookmarks.bak")
+	b.text("", dir, "LOCK")
+	b.text(`{"apps":[]}`, dir, "PreferredApps")
+	b.junk(1024, dir, "Web Applications", "Manifest Resources", "app0", "Icons", "128.png")
+	b.junk(256, dir, "Web Applications", "Temp", "download.tmp")
+	b.junk(512, dir, "File System", "000", "t", "00", "00000000")
+	for e := 0; e < spec.Extensions; e++ {
+		id := b.token(32, extensionLetters)
+		manifest := fmt.Sprintf(`{"manifest_version":3,"name":"Extension %d","version":"1.%d.0"}`, e+1, e)
+		b.text(manifest, dir, "Extensions", id, fmt.Sprintf("1.%d.0_0", e), "manifest.json")
+		b.junk(2048, dir, "Extensions", id, fmt.Sprintf("1.%d.0_0", e), "background.js")
+		b.levelDB(dir, "Local Extension Settings", id)
+	}
+
+	// (b) extension junk: LevelDB logs in the extension data dirs
+	for _, junkDir := range chromium.ExtensionJunkDirs {
+		b.levelDB(dir, junkDir)
+		b.text("log line of the previous run\n", dir, junkDir, "LOG.old")
+	}
+
+	// (c) the rest is wiped: databases, storage, sessions...
+	b.file(b.chromiumHistory(spec.Rows), dir, "History")
+	b.file(b.chromiumCookies(spec.Rows), dir, "Cookies")
+	b.file(b.chromiumWebData(spec.Rows), dir, "Web Data")
+	b.file(b.chromiumLoginData(spec.Rows), dir, "Login Data")
+	b.file(SQLite(&Table{"favicons", "CREATE TABLE favicons(id INTEGER PRIMARY KEY,url LONGVARCHAR NOT NULL,icon_type INTEGER DEFAULT 1)",
+		b.rows(spec.Rows, func(i int) []any { return []any{"https://" + b.site() + "/favicon.ico", 1} })}), dir, "Favicons")
+	b.file(SQLite(&Table{"top_sites", "CREATE TABLE top_sites(url LONGVARCHAR NOT NULL,url_rank INTEGER NOT NULL,title LONGVARCHAR NOT NULL)",
+		b.rows(spec.Rows, func(i int) []any { return []any{"https://" + sites[i%len(sites)] + "/", i, sites[i%len(sites)]} })}), dir, "Top Sites")
+	b.file(SQLite(&Table{"omni_box_shortcuts", "CREATE TABLE omni_box_shortcuts(id VARCHAR,text VARCHAR,fill_into_edit VARCHAR,url VARCHAR)",
+		b.rows(spec.Rows, func(i int) []any {
+			site := b.site()
+			return []any{fmt.Sprintf("%08X-0000-4000-8000-%012X", i, b.rnd.Int63()&0xffffffffffff), site[:3], site, "https://" + site + "/"}
+		})}), dir, "Shortcuts")
+	b.junk(131072, dir, "Visited Links")
+	b.junk(8192, dir, "Network Action Predictor")
+	b.junk(3072, dir, "Sessions", fmt.Sprintf("Session_%d", webkitEpoch+int64(index)))
+	b.junk(1024, dir, "Sessions", fmt.Sprintf("Tabs_%d", webkitEpoch+int64(index)))
+	b.levelDB(dir, "Local Storage", "leveldb")
+	b.levelDB(dir, "Session Storage")
+	b.levelDB(dir, "IndexedDB", "https_"+sites[index%len(sites)]+"_0.indexeddb.leveldb")
+	b.text("", dir, "Service Worker", "CacheStorage", b.token(40, "0123456789abcdef"), "index.txt")
+	b.junk(4096, dir, "Service Worker", "ScriptCache", "index")
+	b.junk(4096, dir, "GPUCache", "data_0")
+}
+
+// the cache of a profile: what IdentifyProfileCache() wants
+func (b *builder) chromiumCache(p *Profile, spec *Spec) {
+	b.junk(256, p.Cache, "Cache", "Cache_Data", "index")
+	for i := 0; i < spec.CacheFiles; i++ {
+		b.junk(spec.CacheBytes, p.Cache, "Cache", "Cache_Data", fmt.Sprintf("%016x_0", b.rnd.Uint64()))
+	}
+	b.junk(8192, p.Cache, "Cache", "Cache_Data", "data_0")
+	b.junk(24, p.Cache, "Code Cache", "js", "index")
+	b.junk(24, p.Cache, "Code Cache", "wasm", "index")
+	for i := 0; i < spec.CacheFiles/2; i++ {
+		b.junk(spec.CacheBytes/2, p.Cache, "Code Cache", "js", fmt.Sprintf("%016x_0", b.rnd.Uint64()))
+	}
+}
+
+// a LevelDB store: CURRENT, LOG, MANIFEST & a write-ahead log
+func (b *builder) levelDB(parts ...string) {
+	dir := filepath.Join(parts...)
+	b.text("MANIFEST-000001\n", dir, "CURRENT")
+	b.text("", dir, "LOCK")
+	b.text("2024/06/10-10:00:00.000 1 Recovering log #3\n", dir, "LOG")
+	b.junk(64, dir, "MANIFEST-000001")
+	b.junk(512, dir, "000003.log")
+}
+
+func (b *builder) chromiumBookmarks(n int) []byte {
+	children := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		site := sites[i%len(sites)]
+		children = append(children, map[string]any{
+			"id": fmt.Sprint(i + 5), "name": site, "type": "url", "url": "https://" + site + "/",
+		})
+	}
+	bookmarks := map[string]any{
+		"version": 1,
+		"roots": map[string]any{
+			"bookmark_bar": map[string]any{"id": "1", "name": "Bookmarks bar", "type": "folder", "children": children},
+			"other":        map[string]any{"id": "2", "name": "Other bookmarks", "type": "folder", "children": []any{}},
+			"synced":       map[string]any{"id": "3", "name": "Mobile bookmarks", "type": "folder", "children": []any{}},
+		},
+	}
+	data, _ := json.MarshalIndent(bookmarks, "", "   ")
+	return data
+}
+
+func (b *builder) chromiumHistory(n int) []byte {
+	urls := b.rows(n, func(i int) []any {
+		site := b.site()
+		return []any{fmt.Sprintf("https://%s/page-%d", site, i), "Page " + fmt.Sprint(i) + " of " + site,
+			1 + b.rnd.Intn(20), webkitEpoch + int64(i)*3600000000}
+	})
+	visits := b.rows(n, func(i int) []any { return []any{1 + b.rnd.Intn(n), webkitEpoch + int64(i)*3600000000, 805306368} })
+	return SQLite(
+		&Table{"meta", "CREATE TABLE meta(key LONGVARCHAR NOT NULL,value LONGVARCHAR)",
+			[][]any{{"version", "67"}, {"last_compatible_version", "16"}}},
+		&Table{"urls", "CREATE TABLE urls(id INTEGER PRIMARY KEY,url LONGVARCHAR,title LONGVARCHAR,visit_count INTEGER DEFAULT 0 NOT NULL,last_visit_time INTEGER NOT NULL)", urls},
+		&Table{"visits", "CREATE TABLE visits(id INTEGER PRIMARY KEY,url INTEGER NOT NULL,visit_time INTEGER NOT NULL,transition INTEGER DEFAULT 0 NOT NULL)", visits},
+	)
+}
+
+func (b *builder) chromiumCookies(n int) []byte {
+	cookies := b.rows(n, func(i int) []any {
+		return []any{webkitEpoch + int64(i), "." + b.site(), fmt.Sprintf("cookie%d", i), b.token(24, "0123456789abcdef"), "/",
+			webkitEpoch + 31536000000000, 1, 1}
+	})
+	return SQLite(&Table{"cookies", "CREATE TABLE cookies(creation_utc INTEGER NOT NULL,host_key TEXT NOT NULL,name TEXT NOT NULL,value TEXT NOT NULL,path TEXT NOT NULL,expires_utc INTEGER NOT NULL,is_secure INTEGER NOT NULL,is_httponly INTEGER NOT NULL)", cookies})
+}
+
+func (b *builder) chromiumWebData(n int) []byte {
+	autofill := b.rows(n, func(i int) []any {
+		return []any{[]string{"email", "name", "search", "zip"}[i%4], fmt.Sprintf("value %d", i), 1 + b.rnd.Intn(9)}
+	})
+	return SQLite(&Table{"autofill", "CREATE TABLE autofill(name VARCHAR,value VARCHAR,count INTEGER DEFAULT 1)", autofill})
+}
+
+func (b *builder) chromiumLoginData(n int) []byte {
+	logins := b.rows(n, func(i int) []any {
+		site := b.site()
+		password := make([]byte, 16)
+		b.rnd.Read(password)
+		return []any{"https://" + site + "/login", fmt.Sprintf("user%d", i), append([]byte("v11"), password...), webkitEpoch + int64(i)}
+	})
+	return SQLite(&Table{"logins", "CREATE TABLE logins(origin_url VARCHAR NOT NULL,username_value VARCHAR,password_value BLOB,date_created INTEGER NOT NULL)", logins})
+}
+
+// n rows made by row(0), row(1)...
+func (b *builder) rows(n int, row func(i int) []any) [][]any {
+	rows := make([][]any, 0, n)
+	for i := 0; i < n; i++ {
+		rows = append(rows, row(i))
+	}
+	return rows
+}
@@ -0,0 +1,186 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * A synthetic Firefox: profiles.ini, installs.ini, profiles & caches
+ *-----------------------------------------------------------------*/
+package testkit
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+	"github.com/lordofscripts/wipechromium/browsers/firefox"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	// microseconds since 1970 (PRTime), mid 2024
+	prTimeEpoch int64 = 1718000000000000
+	// the hash of the installation directory in profiles.ini & installs.ini
+	installHash = "4F96D1932A9F858E"
+	// of a profile directory, i.e. abcd1234.default-release
+	saltLetters = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// the usual profiles of a Firefox
+var FirefoxProfiles = []string{"default-release", "work"}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Generate a Firefox in the home directory of env where the firefox
+// package looks for it: the root with profiles.ini & installs.ini, each
+// profile with its SQLite databases, session store, extensions & locks,
+// and each profile's cache.
+func Firefox(env *cmn.Environment, spec *Spec) (*Tree, error) {
+	b := newBuilder(env, browsers.FirefoxBrowser, spec)
+	names := spec.Profiles
+	if len(names) == 0 {
+		names = FirefoxProfiles
+	}
+	dirs := make([]string, 0, len(names))
+	for _, name := range names {
+		dirs = append(dirs, b.token(8, saltLetters)+"."+name)
+	}
+	// on Windows the directories are looked for: they must be there first
+	if runtime.GOOS == "windows" {
+		b.dir(env.ConfigHome, "Mozilla", "Firefox")
+	}
+	_, root := firefox.GetRootDataDir(env)
+	b.tree.Root = root
+
+	// (a) the root: what IdentifyAppDataRoot() wants
+	b.firefoxIni(names, dirs)
+	b.dir(root, "firefox-mpris")
+	b.text("", root, "Crash Reports", "InstallTime20240610000000")
+	b.text(`{"type":"main"}`, root, "Pending Pings", "0b7e3a55-6d3e-4bd4-8f0e-2bd3ad7ad7f1")
+
+	// (b) the profiles & their caches
+	for i, name := range names {
+		if runtime.GOOS == "windows" {
+			b.dir(env.ConfigHome, "Mozilla", "Firefox", "Profiles", dirs[i], "cache2")
+		}
+		_, data, cache := firefox.GetFirefoxDirs(env, dirs[i])
+		p := &Profile{name, data, cache}
+		b.tree.Profiles = append(b.tree.Profiles, p)
+		b.firefoxProfile(p, spec)
+		b.firefoxCache(p, spec)
+	}
+	return b.tree, b.err
+}
+
+// profiles.ini (the first profile is the default) & installs.ini
+func (b *builder) firefoxIni(names, dirs []string) {
+	var profiles strings.Builder
+	fmt.Fprintf(&profiles, "[Install%s]\nDefault=%s\nLocked=1\n\n", installHash, dirs[0])
+	for i, name := range names {
+		fmt.Fprintf(&profiles, "[Profile%d]\nName=%s\nIsRelative=1\nPath=%s\n", i, name, dirs[i])
+		if i == 0 {
+			profiles.WriteString("Default=1\n")
+		}
+		profiles.WriteString("\n")
+	}
+	profiles.WriteString("[General]\nStartWithLastProfile=1\nVersion=2\n")
+	b.text(profiles.String(), b.tree.Root, "profiles.ini")
+	b.text(fmt.Sprintf("[%s]\nDefault=%s\nLocked=1\n", installHash, dirs[0]), b.tree.Root, "installs.ini")
+}
+
+// what IdentifyProfileData() wants, what the cleaner keeps & what it wipes
+func (b *builder) firefoxProfile(p *Profile, spec *Spec) {
+	dir := p.Data
+
+	// (a) kept: bookmarks & history, extensions & the security settings
+	b.file(b.firefoxPlaces(spec.Rows), dir, "places.sqlite")
+	b.junk(2048, dir, "bookmarkbackups", "bookmarks-2024-06-10_20_"+b.token(24, saltLetters)+".jsonlz4")
+	addons := make([]string, 0, spec.Extensions)
+	for e := 0; e < spec.Extensions; e++ {
+		id := fmt.Sprintf("addon%d@%s", e+1, sites[e%len(sites)])
+		addons = append(addons, fmt.Sprintf(`{"id":%q,"version":"1.%d.0","active":true}`, id, e))
+		b.file(append([]byte("PK\x03\x04"), b.noise(4096)...), dir, "extensions", id+".xpi")
+	}
+	b.dir(dir, "extensions")
+	b.text(`{"schemaVersion":36,"addons":[`+strings.Join(addons, ",")+`]}`, dir, "extensions.json")
+	b.text("{}", dir, "extension-preferences.json")
+	b.junk(1024, dir, "security_state", "data.safe.bin")
+	b.text(`{"version":1}`, dir, "settings", "data.json")
+	b.dir(dir, "features")
+	if spec.Locked {
+		b.symlink(fmt.Sprintf("127.0.0.1:+%d", os.Getpid()), dir, "lock")
+		b.text("", dir, ".parentlock")
+	}
+
+	// (b) the rest is wiped: cookies, forms, sessions, storage...
+	b.file(b.firefoxCookies(spec.Rows), dir, "cookies.sqlite")
+	b.file(SQLite(&Table{"moz_formhistory", "CREATE TABLE moz_formhistory(id INTEGER PRIMARY KEY,fieldname TEXT NOT NULL,value TEXT NOT NULL,timesUsed INTEGER)",
+		b.rows(spec.Rows, func(i int) []any {
+			return []any{[]string{"email", "q", "searchbar-history"}[i%3], fmt.Sprintf("value %d", i), 1 + b.rnd.Intn(9)}
+		})}),
+		dir, "formhistory.sqlite")
+	b.file(SQLite(&Table{"moz_icons", "CREATE TABLE moz_icons(id INTEGER PRIMARY KEY,icon_url TEXT NOT NULL,width INTEGER NOT NULL DEFAULT 0)",
+		b.rows(spec.Rows, func(i int) []any { return []any{"https://" + b.site() + "/favicon.ico", 16} })}), dir, "favicons.sqlite")
+	b.file(SQLite(&Table{"moz_perms", "CREATE TABLE moz_perms(id INTEGER PRIMARY KEY,origin TEXT,type TEXT,permission INTEGER)",
+		b.rows(spec.Rows, func(i int) []any { return []any{"https://" + sites[i%len(sites)], "desktop-notification", 2} })}), dir, "permissions.sqlite")
+	b.file(SQLite(&Table{"webappsstore2", "CREATE TABLE webappsstore2(originKey TEXT,scope TEXT,key TEXT,value TEXT)",
+		b.rows(spec.Rows, func(i int) []any {
+			return []any{"moc.elpmaxe.:https:443", "", fmt.Sprintf("key%d", i), b.token(16, saltLetters)}
+		})}),
+		dir, "webappsstore.sqlite")
+	b.text(`{"nextId":1,"logins":[]}`, dir, "logins.json")
+	b.text(`{"created":1718000000000,"firstUse":null}`, dir, "times.json")
+	b.text("user_pref(\"browser.startup.page\", 3);\n", dir, "prefs.js")
+	b.text("[Compatibility]\nLastVersion=115.12.0_20240603000000/20240603000000\n", dir, "compatibility.ini")
+	b.file(b.mozLz4(8192), dir, "sessionstore.jsonlz4")
+	b.file(b.mozLz4(8192), dir, "sessionstore-backups", "recovery.jsonlz4")
+	b.file(b.mozLz4(4096), dir, "sessionstore-backups", "previous.jsonlz4")
+	b.junk(2048, dir, "storage", "default", "https+++"+sites[0], "ls", "data.sqlite")
+	b.junk(1024, dir, "storage", "permanent", "chrome", "idb", "1451318868ntouromlalnodry--epcr.sqlite")
+	b.text(`{"clientID":"00000000-0000-4000-8000-000000000000"}`, dir, "datareporting", "state.json")
+	b.file(b.mozLz4(512), dir, "crashes", "store.json.mozlz4")
+}
+
+// the cache of a profile: what IdentifyProfileCache() wants
+func (b *builder) firefoxCache(p *Profile, spec *Spec) {
+	b.junk(1024, p.Cache, "cache2", "index")
+	for i := 0; i < spec.CacheFiles; i++ {
+		b.junk(spec.CacheBytes, p.Cache, "cache2", "entries", strings.ToUpper(b.token(40, "0123456789abcdef")))
+	}
+	b.dir(p.Cache, "cache2", "doomed")
+	b.junk(4096, p.Cache, "startupCache", "startupCache.8.little")
+	b.junk(2048, p.Cache, "thumbnails", b.token(32, "0123456789abcdef")+".png")
+}
+
+func (b *builder) firefoxPlaces(n int) []byte {
+	places := b.rows(n, func(i int) []any {
+		site := b.site()
+		return []any{fmt.Sprintf("https://%s/page-%d", site, i), "Page " + fmt.Sprint(i) + " of " + site,
+			1 + b.rnd.Intn(20), prTimeEpoch + int64(i)*3600000000}
+	})
+	bookmarks := b.rows(n, func(i int) []any { return []any{1, i + 1, 3, sites[i%len(sites)]} })
+	return SQLite(
+		&Table{"moz_places", "CREATE TABLE moz_places(id INTEGER PRIMARY KEY,url LONGVARCHAR,title LONGVARCHAR,visit_count INTEGER DEFAULT 0,last_visit_date INTEGER)", places},
+		&Table{"moz_bookmarks", "CREATE TABLE moz_bookmarks(id INTEGER PRIMARY KEY,type INTEGER,fk INTEGER DEFAULT NULL,parent INTEGER,title LONGVARCHAR)", bookmarks},
+	)
+}
+
+func (b *builder) firefoxCookies(n int) []byte {
+	cookies := b.rows(n, func(i int) []any {
+		return []any{fmt.Sprintf("cookie%d", i), b.token(24, "0123456789abcdef"), "." + b.site(), "/",
+			prTimeEpoch/1000000 + 31536000, prTimeEpoch + int64(i)}
+	})
+	return SQLite(&Table{"moz_cookies", "CREATE TABLE moz_cookies(id INTEGER PRIMARY KEY,name TEXT,value TEXT,host TEXT,path TEXT,expiry INTEGER,creationTime INTEGER)", cookies})
+}
+
+// a mozLz4 file: the magic, the uncompressed size & (here) noise
+func (b *builder) mozLz4(size int) []byte {
+	data := append([]byte("mozLz40\x00"), byte(size), byte(size>>8), byte(size>>16), byte(size>>24))
+	return append(data, b.noise(size/2)...)
+}
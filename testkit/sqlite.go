@@ -0,0 +1,168 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Real SQLite database files (without SQLite) for the fixtures
+ *-----------------------------------------------------------------*/
+package testkit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	sqlitePageSize = 4096
+	// a leaf table page: type, first freeblock, cells, content start, fragments
+	sqliteLeafHeader = 8
+	// SQLite 3.40.1, the version that "wrote" the file
+	sqliteVersion = 3040001
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// A table of a generated database. A first column declared INTEGER
+// PRIMARY KEY is the rowid, its values are not in the rows.
+type Table struct {
+	Name string
+	SQL  string
+	Rows [][]any
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// A SQLite 3 database (format 4, UTF-8, one page per table) with the
+// tables & as many of their rows as fit in a page. sqlite3 opens it &
+// its integrity check passes. Values are int, int64, string, []byte or
+// nil.
+func SQLite(tables ...*Table) []byte {
+	db := make([]byte, sqlitePageSize*(1+len(tables)))
+	schema := make([][]byte, 0, len(tables))
+	for i, table := range tables {
+		rootPage := i + 2
+		schema = append(schema, record([]any{"table", table.Name, table.Name, rootPage, table.SQL}))
+		alias := hasRowidAlias(table.SQL)
+		cells := make([][]byte, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			if alias {
+				row = append([]any{nil}, row...)
+			}
+			cells = append(cells, record(row))
+		}
+		leafPage(db[sqlitePageSize*(rootPage-1):sqlitePageSize*rootPage], 0, cells)
+	}
+	leafPage(db[:sqlitePageSize], 100, schema)
+
+	// the 100 bytes of the database header
+	copy(db, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(db[16:], sqlitePageSize)
+	db[18], db[19] = 1, 1                                      // legacy (rollback journal) write & read
+	db[21], db[22], db[23] = 64, 32, 32                        // payload fractions, fixed
+	binary.BigEndian.PutUint32(db[24:], 1)                     // change counter
+	binary.BigEndian.PutUint32(db[28:], uint32(1+len(tables))) // pages
+	binary.BigEndian.PutUint32(db[40:], 1)                     // schema cookie
+	binary.BigEndian.PutUint32(db[44:], 4)                     // schema format
+	binary.BigEndian.PutUint32(db[56:], 1)                     // UTF-8
+	binary.BigEndian.PutUint32(db[92:], 1)                     // valid for change 1
+	binary.BigEndian.PutUint32(db[96:], sqliteVersion)
+	return db
+}
+
+// Fill a leaf table b-tree page with the records (rowids 1, 2...), as
+// many as fit. The page header is at offset (100 on the first page).
+func leafPage(page []byte, offset int, records [][]byte) {
+	end := len(page)
+	pointers := offset + sqliteLeafHeader
+	count := 0
+	for i, rec := range records {
+		cell := append(varint(uint64(len(rec))), varint(uint64(i+1))...)
+		cell = append(cell, rec...)
+		// a payload that big would need overflow pages
+		if len(rec) > sqlitePageSize-35 || end-len(cell) < pointers+2 {
+			break
+		}
+		end -= len(cell)
+		copy(page[end:], cell)
+		binary.BigEndian.PutUint16(page[pointers:], uint16(end))
+		pointers += 2
+		count++
+	}
+	page[offset] = 0x0d // leaf table b-tree
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(count))
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(end))
+}
+
+// A record: the header of serial types & the values
+func record(values []any) []byte {
+	var header, body []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			header = append(header, varint(0)...)
+		case int:
+			header, body = putInt(header, body, int64(v))
+		case int64:
+			header, body = putInt(header, body, v)
+		case string:
+			header = append(header, varint(uint64(13+2*len(v)))...)
+			body = append(body, v...)
+		case []byte:
+			header = append(header, varint(uint64(12+2*len(v)))...)
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("testkit: no SQLite type for %T", value))
+		}
+	}
+	// the header size counts itself (one byte for the sizes used here)
+	size := varint(uint64(len(header) + 1))
+	if len(size) > 1 {
+		size = varint(uint64(len(header) + 2))
+	}
+	return append(append(size, header...), body...)
+}
+
+// an integer with the smallest serial type that holds it
+func putInt(header, body []byte, v int64) ([]byte, []byte) {
+	switch {
+	case v == 0:
+		return append(header, 8), body
+	case v == 1:
+		return append(header, 9), body
+	case v >= -1<<7 && v < 1<<7:
+		return append(header, 1), append(body, byte(v))
+	case v >= -1<<15 && v < 1<<15:
+		return append(header, 2), binary.BigEndian.AppendUint16(body, uint16(v))
+	case v >= -1<<31 && v < 1<<31:
+		return append(header, 4), binary.BigEndian.AppendUint32(body, uint32(v))
+	default:
+		return append(header, 6), binary.BigEndian.AppendUint64(body, uint64(v))
+	}
+}
+
+// SQLite's big-endian varint (values under 2^56 only, 8 bytes at most)
+func varint(v uint64) []byte {
+	buf := []byte{byte(v & 0x7f)}
+	for v >>= 7; v != 0; v >>= 7 {
+		buf = append([]byte{byte(v&0x7f) | 0x80}, buf...)
+	}
+	return buf
+}
+
+// whether the first column is an alias of the rowid
+func hasRowidAlias(sql string) bool {
+	open := strings.Index(sql, "(")
+	if open < 0 {
+		return false
+	}
+	first, _, _ := strings.Cut(sql[open+1:], ",")
+	return strings.Contains(strings.ToUpper(first), "INTEGER PRIMARY KEY")
+}
@@ -0,0 +1,322 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Synthetic browser profiles (on disk or in memory) for tests & demos
+ *-----------------------------------------------------------------*/
+package testkit
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lordofscripts/vfs"
+	"github.com/lordofscripts/vfs/memfs"
+
+	cmn "github.com/lordofscripts/wipechromium"
+	"github.com/lordofscripts/wipechromium/browsers"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	// placeholders of Rel() for the directories of a profile
+	PlaceRoot  = "{root}"
+	PlaceData  = "{data}"
+	PlaceCache = "{cache}"
+)
+
+// the sites of the generated history, cookies, storage...
+var sites = []string{
+	"example.com", "news.example.org", "mail.example.net", "shop.example.com",
+	"video.example.org", "docs.example.net", "maps.example.com", "wiki.example.org",
+}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// What a generated browser tree has. The same spec (& seed) always
+// gives the same tree, byte for byte.
+type Spec struct {
+	// profile names, the browser's usual ones if none. The first one is
+	// the default. Chromium's are directory names (Default, Profile 1).
+	Profiles []string
+	// entries of each profile's cache & the size of each one
+	CacheFiles int
+	CacheBytes int
+	// rows of each table of the SQLite databases (as many as fit in a page)
+	Rows int
+	// installed extensions of each profile
+	Extensions int
+	// the lock files of a running browser, pointing at this process
+	Locked bool
+	// of the pseudo-random content
+	Seed int64
+}
+
+// A generated browser tree
+type Tree struct {
+	Env     *cmn.Environment
+	Browser browsers.Browser
+	// the data root, i.e. ~/.config/chromium or ~/.mozilla/firefox
+	Root     string
+	Profiles []*Profile
+	// what was written
+	Files int
+	Bytes int64
+}
+
+// A profile of a generated tree
+type Profile struct {
+	// as the cleaners know it (Chromium: its directory)
+	Name string
+	// the data & cache directories
+	Data  string
+	Cache string
+}
+
+// writes a tree, the first failure stops it
+type builder struct {
+	tree *Tree
+	rnd  *rand.Rand
+	err  error
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// A few profiles of a few megabytes (without locks)
+func DefaultSpec() *Spec {
+	return &Spec{nil, 8, 4096, 20, 2, false, 1}
+}
+
+// An empty home directory in memory
+func InMemory(home string) *cmn.Environment {
+	return cmn.NewEnvironmentVFS(memfs.Create(), home)
+}
+
+// A home directory on disk (created if need be)
+func OnDisk(home string) (*cmn.Environment, error) {
+	if err := os.MkdirAll(home, 0o700); err != nil {
+		return nil, err
+	}
+	return cmn.NewEnvironment(home)
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// The profile of that name, nil if none
+func (t *Tree) Profile(name string) *Profile {
+	if i := slices.IndexFunc(t.Profiles, func(p *Profile) bool { return strings.EqualFold(p.Name, name) }); i >= 0 {
+		return t.Profiles[i]
+	}
+	return nil
+}
+
+// A path of the tree relative to a profile, i.e. "{cache}/Cache/index"
+// or "{data}/Cookies", else to the root ("{root}/Local State") or home
+// ("~/..."), with forward slashes on every platform.
+func (t *Tree) Rel(p *Profile, path string) string {
+	places := []struct{ dir, name string }{{t.Root, PlaceRoot}}
+	if p != nil {
+		places = append(places, struct{ dir, name string }{p.Data, PlaceData},
+			struct{ dir, name string }{p.Cache, PlaceCache})
+	}
+	// the longest prefix: a cache may be within the data (Firefox on macOS)
+	slices.SortFunc(places, func(a, b struct{ dir, name string }) int { return len(b.dir) - len(a.dir) })
+	for _, place := range places {
+		if rel, ok := cmn.Within(place.dir, path); ok {
+			return filepath.ToSlash(filepath.Join(place.name, rel))
+		}
+	}
+	return filepath.ToSlash(t.Env.FromHome(path))
+}
+
+// Whether the item of a Rel() path exists
+func (t *Tree) Exists(p *Profile, rel string) bool {
+	_, err := t.Env.Lstat(t.Abs(p, rel))
+	return err == nil
+}
+
+// The path of a Rel() path
+func (t *Tree) Abs(p *Profile, rel string) string {
+	place, rest, _ := strings.Cut(filepath.FromSlash(rel), string(filepath.Separator))
+	switch place {
+	case PlaceData:
+		return filepath.Join(p.Data, rest)
+	case PlaceCache:
+		return filepath.Join(p.Cache, rest)
+	case PlaceRoot:
+		return filepath.Join(t.Root, rest)
+	}
+	return t.Env.AtHome(rest)
+}
+
+func (b *builder) dir(parts ...string) string {
+	path := filepath.Join(parts...)
+	if b.err == nil {
+		b.err = vfs.MkdirAll(b.tree.Env.FS, path, 0o700)
+	}
+	return path
+}
+
+func (b *builder) file(data []byte, parts ...string) {
+	path := filepath.Join(parts...)
+	b.dir(filepath.Dir(path))
+	if b.err == nil {
+		b.err = vfs.WriteFile(b.tree.Env.FS, path, data, 0o600)
+		b.tree.Files++
+		b.tree.Bytes += int64(len(data))
+	}
+}
+
+func (b *builder) text(text string, parts ...string) {
+	b.file([]byte(text), parts...)
+}
+
+// size bytes of noise, as incompressible as a real cache entry
+func (b *builder) junk(size int, parts ...string) {
+	b.file(b.noise(size), parts...)
+}
+
+func (b *builder) symlink(target string, parts ...string) {
+	path := filepath.Join(parts...)
+	if b.err == nil {
+		b.err = b.tree.Env.FS.Symlink(target, path)
+	}
+}
+
+func (b *builder) noise(size int) []byte {
+	data := make([]byte, size)
+	b.rnd.Read(data)
+	return data
+}
+
+// n letters of the alphabet, i.e. a Firefox profile salt
+func (b *builder) token(n int, alphabet string) string {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = alphabet[b.rnd.Intn(len(alphabet))]
+	}
+	return string(buf)
+}
+
+func (b *builder) site() string {
+	return sites[b.rnd.Intn(len(sites))]
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Generate the tree of a browser in the home directory of env
+func Generate(env *cmn.Environment, browser browsers.Browser, spec *Spec) (*Tree, error) {
+	switch browser {
+	case browsers.ChromiumBrowser:
+		return Chromium(env, spec)
+	case browsers.FirefoxBrowser:
+		return Firefox(env, spec)
+	}
+	return nil, cmn.ErrUnsupportedBrowser
+}
+
+// The lines of a wipe's (or a plan's) outcome that don't change from one
+// run to the next: no times nor absolute paths (see Tree.Rel). Golden
+// files of the cleaners are made of it.
+func Golden(t *Tree, r *cmn.WipeReport) string {
+	var sb strings.Builder
+	p := t.Profile(r.Profile)
+	fmt.Fprintf(&sb, "browser %s profile %q dry_run %t interrupted %t\n", r.Browser, r.Profile, r.DryRun, r.Interrupted)
+	for _, a := range r.Actions {
+		fmt.Fprintf(&sb, "phase %s %s items %d skipped %d bytes %d", a.Phase, t.Rel(p, a.Path), a.Items, a.Skipped, a.Bytes)
+		if a.Error != nil {
+			fmt.Fprintf(&sb, " exit %d", a.Error.Code)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "total items %d bytes %d\n", r.TotalItems, r.TotalBytes)
+	for _, e := range r.Errors {
+		fmt.Fprintf(&sb, "error %d\n", e.Code)
+	}
+
+	items := slices.Clone(r.Items)
+	slices.SortStableFunc(items, func(a, b *cmn.ItemRecord) int {
+		return strings.Compare(a.Phase+"\x00"+t.Rel(p, a.Path), b.Phase+"\x00"+t.Rel(p, b.Path))
+	})
+	for _, item := range items {
+		kind := "file"
+		if item.IsDir {
+			kind = "dir"
+		}
+		fmt.Fprintf(&sb, "%-8s %-10s %-4s %8d %s [%s]\n", item.Action, item.Phase, kind, item.Size, t.Rel(p, item.Path), item.Rule)
+	}
+
+	categories := make([]string, 0, len(r.Categories))
+	for category, bytes := range r.Categories {
+		categories = append(categories, fmt.Sprintf("category %s bytes %d", category, bytes))
+	}
+	slices.Sort(categories)
+	for _, line := range categories {
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// A listing of what is under the paths (relative to home), one item per
+// line & sorted: "d" directories, "f" files with their size & "l"
+// symbolic links with their target. Golden manifests of the trees left
+// by a wipe are made of it.
+func Manifest(env *cmn.Environment, paths ...string) (string, error) {
+	lines := make([]string, 0)
+	for _, root := range paths {
+		if _, err := env.Lstat(root); err != nil {
+			lines = append(lines, "- "+filepath.ToSlash(env.FromHome(root)))
+			continue
+		}
+		err := vfs.Walk(env.FS, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel := filepath.ToSlash(env.FromHome(path))
+			switch {
+			case info.Mode()&os.ModeSymlink != 0:
+				lines = append(lines, fmt.Sprintf("l %s -> %s", rel, readlink(env, path)))
+			case info.IsDir():
+				lines = append(lines, "d "+rel+"/")
+			default:
+				lines = append(lines, fmt.Sprintf("f %s %d", rel, info.Size()))
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// the target of a symbolic link, which an in-memory one has as content
+func readlink(env *cmn.Environment, path string) string {
+	if env.IsOS() {
+		target, _ := os.Readlink(path)
+		return target
+	}
+	target, _ := vfs.ReadFile(env.FS, path)
+	return string(target)
+}
+
+// a builder of the tree of a browser in env
+func newBuilder(env *cmn.Environment, browser browsers.Browser, spec *Spec) *builder {
+	return &builder{&Tree{Env: env, Browser: browser}, rand.New(rand.NewSource(spec.Seed)), nil}
+}
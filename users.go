@@ -0,0 +1,141 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * The users of a machine & their part of an --all-users run
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+const (
+	PasswdFile = "/etc/passwd"
+	// the first uid of a person (lower ones are system accounts)
+	MinLoginUID = 1000
+	// nobody, the overflow uid
+	nobodyUID = 65534
+)
+
+// the shells of accounts nobody logs into
+var noLoginShells = []string{"nologin", "false", "true", "sync", "halt", "shutdown"}
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Somebody with a login on this machine (an /etc/passwd entry)
+type LoginUser struct {
+	Name  string `json:"user"`
+	Uid   int    `json:"uid"`
+	Gid   int    `json:"gid"`
+	Home  string `json:"home"`
+	Shell string `json:"-"`
+}
+
+// One user's part of an --all-users scan or wipe: the scan, or a wipe
+// per profile, and how it went.
+type UserRun struct {
+	*LoginUser
+	Scan  *ScanReport   `json:"scan,omitempty"`
+	Wipes []*WipeReport `json:"wipes,omitempty"`
+	Code  int           `json:"exit_code"`
+	Error *ErrorEntry   `json:"error,omitempty"`
+}
+
+// The result of an --all-users scan or wipe, one run per user
+type UsersReport struct {
+	Users []*UserRun `json:"users"`
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+func (u *LoginUser) String() string {
+	return fmt.Sprintf("%s (uid %d) %s", u.Name, u.Uid, u.Home)
+}
+
+// Record the failure of the user's run (or of a profile of it)
+func (r *UserRun) Fail(code int, err error) {
+	if r.Error == nil {
+		r.Code, r.Error = code, NewErrorEntry(code, err)
+	}
+}
+
+// The exit code of the whole: OK if all went well, ExitPartial if some
+// users' runs failed, else the code of the first failure.
+func (r *UsersReport) ExitCode() int {
+	failed := slices.IndexFunc(r.Users, func(run *UserRun) bool { return run.Code != ExitOK })
+	switch {
+	case failed < 0:
+		return ExitOK
+	case slices.ContainsFunc(r.Users, func(run *UserRun) bool { return run.Code == ExitOK }):
+		return ExitPartial
+	default:
+		return r.Users[failed].Code
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// The people with a login on this machine as per the passwd file: a uid
+// of MinLoginUID or more, a real shell & a home directory that exists.
+func LoginUsers(passwdFile string) ([]*LoginUser, error) {
+	f, err := os.Open(passwdFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users, err := ParsePasswd(f)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(users, func(u *LoginUser) bool { return !IsDirectory(u.Home) }), nil
+}
+
+// The login users of a passwd(5) file, see LoginUsers()
+func ParsePasswd(r io.Reader) ([]*LoginUser, error) {
+	users := make([]*LoginUser, 0)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(text, ":")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("passwd line %d: %d fields instead of 7", line, len(fields))
+		}
+		uid, err1 := strconv.Atoi(fields[2])
+		gid, err2 := strconv.Atoi(fields[3])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("passwd line %d: bad uid/gid %q/%q", line, fields[2], fields[3])
+		}
+		user := &LoginUser{fields[0], uid, gid, fields[5], fields[6]}
+		if uid >= MinLoginUID && uid != nobodyUID && filepath.IsAbs(user.Home) && isLoginShell(user.Shell) {
+			users = append(users, user)
+		}
+	}
+	return users, scanner.Err()
+}
+
+func isLoginShell(shell string) bool {
+	return len(shell) != 0 && !slices.Contains(noLoginShells, filepath.Base(shell))
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Doing the work of a user as that user (other platforms)
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// There is no root to become somebody else (i.e. Windows)
+func AsUser(u *LoginUser, fn func() error) error {
+	return ErrNotRoot
+}
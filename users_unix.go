@@ -0,0 +1,72 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Doing the work of a user as that user (Unix)
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+/* ----------------------------------------------------------------
+ *							F u n c t i o n s
+ *-----------------------------------------------------------------*/
+
+// Run fn with the effective uid, gid & groups of the user. The real uid
+// stays root's so that they are root's again afterwards. Whatever fn
+// does on the disk the user could have done as well, and nothing more:
+// a symlink the user planted in a profile can't get /etc removed.
+// It applies to all the threads of the process, so one user at a time.
+func AsUser(u *LoginUser, fn func() error) error {
+	if os.Geteuid() != 0 {
+		return ErrNotRoot
+	}
+	rootGroups, err := syscall.Getgroups()
+	if err != nil {
+		return err
+	}
+	rootGid := os.Getegid()
+
+	restore := func() error {
+		return errors.Join(syscall.Seteuid(0), syscall.Setegid(rootGid), syscall.Setgroups(rootGroups))
+	}
+	if err := syscall.Setgroups(groupsOf(u)); err != nil {
+		return err
+	}
+	if err := syscall.Setegid(u.Gid); err != nil {
+		return errors.Join(err, restore())
+	}
+	if err := syscall.Seteuid(u.Uid); err != nil {
+		return errors.Join(err, restore())
+	}
+	defer func() {
+		if rerr := restore(); rerr != nil {
+			// going on as somebody else is not an option
+			panic("could not become root again: " + rerr.Error())
+		}
+	}()
+	return fn()
+}
+
+// the groups the user is a member of, the primary one at least
+func groupsOf(u *LoginUser) []int {
+	groups := []int{u.Gid}
+	if lu, err := user.LookupId(strconv.Itoa(u.Uid)); err == nil {
+		if ids, err := lu.GroupIds(); err == nil {
+			for _, id := range ids {
+				if gid, err := strconv.Atoi(id); err == nil && gid != u.Gid {
+					groups = append(groups, gid)
+				}
+			}
+		}
+	}
+	return groups
+}
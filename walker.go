@@ -0,0 +1,260 @@
+/* -----------------------------------------------------------------
+ *					L o r d  O f   S c r i p t s (tm)
+ *				  Copyright (C)2024 Dídimo Grimaldo T.
+ * - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - - -
+ * Concurrent directory walker: sizing & deletion in a single pass
+ *-----------------------------------------------------------------*/
+package wipechromium
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/* ----------------------------------------------------------------
+ *							G l o b a l s
+ *-----------------------------------------------------------------*/
+
+var (
+	// Number of goroutines used to walk directory trees. Zero (default)
+	// is one per CPU; one is the plain sequential walk.
+	WalkerJobs int = 0
+)
+
+/* ----------------------------------------------------------------
+ *							T y p e s
+ *-----------------------------------------------------------------*/
+
+// Walks directory trees with a bounded number of goroutines. Subdirectories
+// are handed to an idle goroutine if there is one, otherwise they are
+// walked by the current one, so it never blocks waiting for a slot.
+// Totals are sums and therefore the same regardless of the order in which
+// the tree was walked.
+type Walker struct {
+	jobs  int
+	slots chan struct{}
+	sizer *DiskSizer
+	// progress reporting, see OnProgress()
+	progress  func(done DiskSize, files int)
+	every     time.Duration
+	doneBytes atomic.Int64
+	doneAlloc atomic.Int64
+	doneFiles atomic.Int64
+	lastCall  atomic.Int64 // UnixNano
+}
+
+// errors found while walking, reported in path order
+type walkErrors struct {
+	mu   sync.Mutex
+	errs []*os.PathError
+}
+
+/* ----------------------------------------------------------------
+ *							C o n s t r u c t o r s
+ *-----------------------------------------------------------------*/
+
+// A walker with at most jobs goroutines (zero or less is one per CPU).
+// Hardlinks are counted once across everything the walker visits.
+func NewWalker(jobs int) *Walker {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	return &Walker{
+		jobs: jobs,
+		// the calling goroutine is one of the jobs
+		slots: make(chan struct{}, jobs-1),
+		sizer: NewDiskSizer(),
+	}
+}
+
+/* ----------------------------------------------------------------
+ *							M e t h o d s
+ *-----------------------------------------------------------------*/
+
+// Number of goroutines this walker uses
+func (w *Walker) Jobs() int {
+	return w.jobs
+}
+
+// Have fn called with the running totals (of everything this walker has
+// sized or removed so far) at most once every interval. It may be called
+// from any of the walker's goroutines, keep it short.
+func (w *Walker) OnProgress(every time.Duration, fn func(done DiskSize, files int)) *Walker {
+	w.every, w.progress = every, fn
+	w.lastCall.Store(time.Now().UnixNano())
+	return w
+}
+
+// Apparent & allocated size of root (file or directory) including the
+// directory itself. Symbolic links are not followed.
+func (w *Walker) Usage(ctx context.Context, root string) (DiskSize, error) {
+	return w.run(ctx, root, false)
+}
+
+// Remove root and everything underneath it (like os.RemoveAll) sizing it
+// in the same pass. Returns the size of what was actually removed; on
+// error the rest is left in place as far as possible. When the context
+// is cancelled it stops after the file at hand and returns ctx.Err().
+func (w *Walker) RemoveAll(ctx context.Context, root string) (DiskSize, error) {
+	return w.run(ctx, root, true)
+}
+
+func (w *Walker) run(ctx context.Context, root string, remove bool) (DiskSize, error) {
+	if err := ctx.Err(); err != nil {
+		return DiskSize{}, err
+	}
+
+	finfo, err := os.Lstat(root)
+	if err != nil {
+		if remove && os.IsNotExist(err) {
+			return DiskSize{}, nil // same as os.RemoveAll
+		}
+		return DiskSize{}, err
+	}
+
+	errs := &walkErrors{}
+	var size DiskSize
+	if finfo.IsDir() {
+		size, _ = w.walkDir(ctx, root, finfo, remove, errs)
+	} else {
+		size = w.sizer.Of(finfo)
+		if remove {
+			if err := os.Remove(root); err != nil {
+				errs.add(err)
+				size = DiskSize{}
+			}
+		}
+		w.advance(size)
+	}
+	if err := ctx.Err(); err != nil {
+		return size, err
+	}
+	return size, errs.err()
+}
+
+// size (and remove) a directory. Each subdirectory gets a result slot so
+// that no locking is needed to aggregate the totals. Returns false if
+// anything underneath could not be removed, in which case the directory
+// itself is left alone rather than failing with "directory not empty".
+func (w *Walker) walkDir(ctx context.Context, dir string, finfo os.FileInfo, remove bool, errs *walkErrors) (DiskSize, bool) {
+	self := w.sizer.Of(finfo)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		errs.add(err)
+		return DiskSize{}, false
+	}
+
+	type result struct {
+		size DiskSize
+		ok   bool
+	}
+
+	var sum DiskSize
+	var wg sync.WaitGroup
+	ok := true
+	subdirs := make([]result, len(entries))
+	for i, entry := range entries {
+		if ctx.Err() != nil {
+			ok = false
+			break
+		}
+		fullPath := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			errs.add(err)
+			ok = false
+			continue
+		}
+
+		if info.IsDir() {
+			select {
+			case w.slots <- struct{}{}:
+				wg.Add(1)
+				go func(i int, path string, info os.FileInfo) {
+					defer func() { <-w.slots; wg.Done() }()
+					subdirs[i].size, subdirs[i].ok = w.walkDir(ctx, path, info, remove, errs)
+				}(i, fullPath, info)
+			default:
+				subdirs[i].size, subdirs[i].ok = w.walkDir(ctx, fullPath, info, remove, errs)
+			}
+			continue
+		}
+
+		subdirs[i].ok = true
+		size := w.sizer.Of(info)
+		if remove {
+			if err := os.Remove(fullPath); err != nil {
+				errs.add(err)
+				ok = false
+				continue
+			}
+		}
+		sum.Add(size)
+		w.advance(size)
+	}
+	wg.Wait()
+
+	for _, sub := range subdirs {
+		sum.Add(sub.size)
+		ok = ok && sub.ok
+	}
+	if remove {
+		if !ok {
+			return sum, false
+		}
+		if err := os.Remove(dir); err != nil {
+			errs.add(err)
+			return sum, false
+		}
+	}
+	sum.Add(self)
+	return sum, ok
+}
+
+// account for a file done and report progress if it is time to
+func (w *Walker) advance(size DiskSize) {
+	if w.progress == nil {
+		return
+	}
+	bytes := w.doneBytes.Add(size.Apparent)
+	alloc := w.doneAlloc.Add(size.Allocated)
+	files := w.doneFiles.Add(1)
+
+	last := w.lastCall.Load()
+	now := time.Now().UnixNano()
+	if now-last >= int64(w.every) && w.lastCall.CompareAndSwap(last, now) {
+		w.progress(DiskSize{bytes, alloc}, int(files))
+	}
+}
+
+func (e *walkErrors) add(err error) {
+	var perr *os.PathError
+	if !errors.As(err, &perr) {
+		perr = &os.PathError{Op: "walk", Path: "", Err: err}
+	}
+	e.mu.Lock()
+	e.errs = append(e.errs, perr)
+	e.mu.Unlock()
+}
+
+// nil or all the errors sorted by path
+func (e *walkErrors) err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	sort.Slice(e.errs, func(i, j int) bool {
+		return e.errs[i].Path < e.errs[j].Path
+	})
+	joined := make([]error, 0, len(e.errs))
+	for _, perr := range e.errs {
+		joined = append(joined, perr)
+	}
+	return errors.Join(joined...)
+}